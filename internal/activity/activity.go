@@ -0,0 +1,72 @@
+// Package activity counts typing days and streaks. Days are calendar days
+// in the local time zone, keyed as YYYY-MM-DD.
+package activity
+
+import (
+	"sort"
+	"time"
+)
+
+// DateFormat is the layout of day keys
+const DateFormat = "2006-01-02"
+
+// Day returns the day key of t in local time
+func Day(t time.Time) string {
+	return t.Local().Format(DateFormat)
+}
+
+// Today returns local midnight of the day now falls on
+func Today(now time.Time) time.Time {
+	now = now.Local()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// CurrentStreak counts consecutive days ending today with at least min
+// tests. A streak that ended yesterday still counts, since today isn't
+// over yet. A min below 1 counts as 1.
+func CurrentStreak(counts map[string]int, today time.Time, min int) int {
+	min = max(min, 1)
+	day := today
+	if counts[day.Format(DateFormat)] < min {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for counts[day.Format(DateFormat)] >= min {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// LongestStreak returns the longest run of consecutive days with at least
+// min tests. A min below 1 counts as 1.
+func LongestStreak(counts map[string]int, min int) int {
+	min = max(min, 1)
+	days := make([]string, 0, len(counts))
+	for day, n := range counts {
+		if n >= min {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	longest, run := 0, 0
+	var prev time.Time
+	for _, d := range days {
+		date, err := time.ParseInLocation(DateFormat, d, time.Local)
+		if err != nil {
+			continue
+		}
+		if run > 0 && prev.AddDate(0, 0, 1).Format(DateFormat) == d {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = date
+	}
+	return longest
+}
@@ -2,11 +2,48 @@ package assets
 
 import (
 	_ "embed"
+	"sort"
 )
 
 //go:embed words.txt
 var WordsData string
 
+//go:embed english1k.txt
+var english1kData string
+
+//go:embed spanish.txt
+var spanishData string
+
+//go:embed german.txt
+var germanData string
+
 //go:embed quotes.json
 var QuotesData string
 
+// DefaultLanguage is the word list used when no language is chosen
+const DefaultLanguage = "english"
+
+// wordLists maps language names to their embedded word lists, one word
+// per line
+var wordLists = map[string]string{
+	DefaultLanguage: WordsData,
+	"english1k":     english1kData,
+	"spanish":       spanishData,
+	"german":        germanData,
+}
+
+// WordList returns the embedded word list for a language
+func WordList(language string) (string, bool) {
+	data, ok := wordLists[language]
+	return data, ok
+}
+
+// Languages returns the names of the embedded word lists, sorted
+func Languages() []string {
+	names := make([]string, 0, len(wordLists))
+	for name := range wordLists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -19,6 +19,22 @@ type ChartOptions struct {
 	ShowAxis  bool
 	Title     string
 	ValueUnit string // e.g., "WPM"
+	Smooth    int    // moving-average window in points; 0 or 1 plots raw values
+
+	// Legend labels for dual charts; empty uses ValueUnit and "Raw " plus
+	// ValueUnit
+	PrimaryLabel   string
+	SecondaryLabel string
+
+	// Color enables ANSI colors. The escapes are passed in by the caller so
+	// this package stays independent of the ui package; empty strings leave
+	// that part uncolored.
+	Color          bool
+	PrimaryColor   string // primary series (e.g., WPM)
+	SecondaryColor string // secondary series (e.g., Raw WPM)
+	TertiaryColor  string // error-rate series of triple charts
+	AxisColor      string // axis lines and labels
+	ResetColor     string
 }
 
 // DefaultOptions returns sensible default chart options
@@ -36,6 +52,7 @@ func RenderChart(points []DataPoint, opts ChartOptions) string {
 	if len(points) == 0 {
 		return "No data"
 	}
+	points = smooth(points, opts.Smooth)
 
 	// Ensure minimum dimensions
 	if opts.Width < 20 {
@@ -113,39 +130,15 @@ func RenderChart(points []DataPoint, opts ChartOptions) string {
 	// Render grid with Y-axis labels
 	for row := 0; row < opts.Height; row++ {
 		if opts.ShowAxis {
-			// Calculate value at this row
-			val := mapToRange(float64(row), 0, float64(opts.Height-1), maxVal, minVal)
-			if row == 0 || row == opts.Height-1 || row == opts.Height/2 {
-				sb.WriteString(fmt.Sprintf("%5.0f│", val))
-			} else {
-				sb.WriteString("     │")
-			}
+			sb.WriteString(opts.paint(yAxisLabel(row, opts.Height, minVal, maxVal), opts.AxisColor))
 		}
-		sb.WriteString(string(grid[row]))
+		writeCells(&sb, grid[row], func(int) string { return opts.PrimaryColor }, opts)
 		sb.WriteRune('\n')
 	}
 
 	// X-axis
 	if opts.ShowAxis {
-		sb.WriteString("     └")
-		sb.WriteString(strings.Repeat("─", chartWidth))
-		sb.WriteRune('\n')
-
-		// Time labels
-		totalMs := points[len(points)-1].TimeMs
-		sb.WriteString("     ")
-		sb.WriteString(fmt.Sprintf("0s"))
-		midPadding := chartWidth/2 - 2
-		if midPadding > 0 {
-			sb.WriteString(strings.Repeat(" ", midPadding))
-			sb.WriteString(fmt.Sprintf("%ds", totalMs/2000))
-		}
-		endPadding := chartWidth - midPadding - 6
-		if endPadding > 0 {
-			sb.WriteString(strings.Repeat(" ", endPadding))
-			sb.WriteString(fmt.Sprintf("%ds", totalMs/1000))
-		}
-		sb.WriteRune('\n')
+		writeXAxis(&sb, chartWidth, points[len(points)-1].TimeMs, opts)
 	}
 
 	return sb.String()
@@ -156,9 +149,27 @@ func RenderDualChart(primary, secondary []DataPoint, opts ChartOptions) string {
 	if len(primary) == 0 && len(secondary) == 0 {
 		return "No data"
 	}
+	return renderBlock(primary, secondary, nil, opts)
+}
+
+// RenderTripleChart renders WPM and Raw WPM like RenderDualChart plus an
+// error-rate series in percent, drawn faintly against its own axis on the
+// right
+func RenderTripleChart(primary, secondary, errorRate []DataPoint, opts ChartOptions) string {
+	if len(primary) == 0 && len(secondary) == 0 {
+		return "No data"
+	}
+	return renderBlock(primary, secondary, errorRate, opts)
+}
+
+// renderBlock draws the block-style chart; errorRate may be nil
+func renderBlock(primary, secondary, errorRate []DataPoint, opts ChartOptions) string {
+	primary = smooth(primary, opts.Smooth)
+	secondary = smooth(secondary, opts.Smooth)
+	errorRate = smooth(errorRate, opts.Smooth)
 
 	// Combine all points to find range
-	allPoints := append(primary, secondary...)
+	allPoints := append(append([]DataPoint(nil), primary...), secondary...)
 	minVal, maxVal := findMinMax(allPoints)
 
 	valRange := maxVal - minVal
@@ -169,6 +180,9 @@ func RenderDualChart(primary, secondary []DataPoint, opts ChartOptions) string {
 	maxVal = maxVal + valRange*0.1
 
 	axisWidth := 6
+	if errorRate != nil && opts.ShowAxis {
+		axisWidth += errAxisWidth
+	}
 	chartWidth := opts.Width - axisWidth
 	if chartWidth < 10 {
 		chartWidth = 10
@@ -215,6 +229,18 @@ func RenderDualChart(primary, secondary []DataPoint, opts ChartOptions) string {
 		grid[yIdx][xIdx] = '█'
 	}
 
+	// Plot the error rate on its own scale, only where nothing else is drawn
+	errMax := errAxisMax(errorRate)
+	for _, point := range errorRate {
+		x := mapToRange(float64(point.TimeMs), 0, float64(maxTime), 0, float64(chartWidth-1))
+		y := mapToRange(point.Value, 0, errMax, float64(opts.Height-1), 0)
+		xIdx := clampInt(int(math.Round(x)), 0, chartWidth-1)
+		yIdx := clampInt(int(math.Round(y)), 0, opts.Height-1)
+		if grid[yIdx][xIdx] == ' ' {
+			grid[yIdx][xIdx] = '·'
+		}
+	}
+
 	// Build output
 	var sb strings.Builder
 
@@ -224,47 +250,154 @@ func RenderDualChart(primary, secondary []DataPoint, opts ChartOptions) string {
 	}
 
 	// Legend
-	sb.WriteString("      █ WPM  ░ Raw WPM\n")
+	sb.WriteString("      ")
+	primaryLabel, secondaryLabel := opts.legendLabels()
+	sb.WriteString(opts.paint("█ "+primaryLabel, opts.PrimaryColor))
+	sb.WriteString("  ")
+	sb.WriteString(opts.paint("░ "+secondaryLabel, opts.SecondaryColor))
+	if errorRate != nil {
+		sb.WriteString("  ")
+		sb.WriteString(opts.paint("· err%", opts.TertiaryColor))
+	}
+	sb.WriteRune('\n')
 
 	// Render grid
 	for row := 0; row < opts.Height; row++ {
 		if opts.ShowAxis {
-			val := mapToRange(float64(row), 0, float64(opts.Height-1), maxVal, minVal)
-			if row == 0 || row == opts.Height-1 || row == opts.Height/2 {
-				sb.WriteString(fmt.Sprintf("%5.0f│", val))
-			} else {
-				sb.WriteString("     │")
+			sb.WriteString(opts.paint(yAxisLabel(row, opts.Height, minVal, maxVal), opts.AxisColor))
+		}
+		cells := grid[row]
+		writeCells(&sb, cells, func(i int) string {
+			switch cells[i] {
+			case '░':
+				return opts.SecondaryColor
+			case '·':
+				return opts.TertiaryColor
 			}
+			return opts.PrimaryColor
+		}, opts)
+		if errorRate != nil && opts.ShowAxis {
+			sb.WriteString(opts.paint(errAxisLabel(row, opts.Height, errMax), opts.AxisColor))
 		}
-		sb.WriteString(string(grid[row]))
 		sb.WriteRune('\n')
 	}
 
 	// X-axis
 	if opts.ShowAxis {
-		sb.WriteString("     └")
-		sb.WriteString(strings.Repeat("─", chartWidth))
-		sb.WriteRune('\n')
+		writeXAxis(&sb, chartWidth, maxTime, opts)
+	}
+
+	return sb.String()
+}
+
+// Helper functions
+
+// legendLabels returns the dual chart legend labels, with defaults
+func (o ChartOptions) legendLabels() (primary, secondary string) {
+	primary, secondary = o.PrimaryLabel, o.SecondaryLabel
+	unit := o.ValueUnit
+	if unit == "" {
+		unit = "WPM"
+	}
+	if primary == "" {
+		primary = unit
+	}
+	if secondary == "" {
+		secondary = "Raw " + unit
+	}
+	return primary, secondary
+}
 
-		sb.WriteString("     ")
-		sb.WriteString("0s")
-		midPadding := chartWidth/2 - 2
-		if midPadding > 0 {
-			sb.WriteString(strings.Repeat(" ", midPadding))
-			sb.WriteString(fmt.Sprintf("%ds", maxTime/2000))
+// paint wraps s in color when colors are enabled
+func (o ChartOptions) paint(s, color string) string {
+	if !o.Color || color == "" {
+		return s
+	}
+	return color + s + o.ResetColor
+}
+
+// writeCells writes a row of chart cells, emitting one escape per run of
+// same-colored cells. Blank cells are never colored, so the visible width
+// of the row is always len(cells).
+func writeCells(sb *strings.Builder, cells []rune, colorOf func(i int) string, opts ChartOptions) {
+	start := 0
+	for start < len(cells) {
+		color := ""
+		if cells[start] != ' ' {
+			color = colorOf(start)
 		}
-		endPadding := chartWidth - midPadding - 6
-		if endPadding > 0 {
-			sb.WriteString(strings.Repeat(" ", endPadding))
-			sb.WriteString(fmt.Sprintf("%ds", maxTime/1000))
+		end := start + 1
+		for end < len(cells) {
+			next := ""
+			if cells[end] != ' ' {
+				next = colorOf(end)
+			}
+			if next != color {
+				break
+			}
+			end++
 		}
-		sb.WriteRune('\n')
+		sb.WriteString(opts.paint(string(cells[start:end]), color))
+		start = end
 	}
+}
 
-	return sb.String()
+// errAxisWidth is the width of the right-hand error-rate axis
+const errAxisWidth = 5
+
+// errAxisMax returns the top of the error-rate axis: a little above the
+// highest rate, but at least 5% so a nearly clean test stays near the bottom
+func errAxisMax(points []DataPoint) float64 {
+	top := 5.0
+	for _, p := range points {
+		top = math.Max(top, p.Value*1.1)
+	}
+	return math.Min(top, 100)
 }
 
-// Helper functions
+// errAxisLabel returns the right-hand error-rate axis suffix for a grid row
+func errAxisLabel(row, height int, maxVal float64) string {
+	if row == 0 || row == height-1 || row == height/2 {
+		val := mapToRange(float64(row), 0, float64(height-1), maxVal, 0)
+		return fmt.Sprintf("│%3.0f%%", val)
+	}
+	return "│"
+}
+
+// yAxisLabel returns the 6-column Y-axis prefix for a grid row
+func yAxisLabel(row, height int, minVal, maxVal float64) string {
+	if row == 0 || row == height-1 || row == height/2 {
+		val := mapToRange(float64(row), 0, float64(height-1), maxVal, minVal)
+		return fmt.Sprintf("%5.0f│", val)
+	}
+	return "     │"
+}
+
+// writeXAxis writes the X-axis line and its start, middle, and end time labels
+func writeXAxis(sb *strings.Builder, chartWidth int, maxTime int64, opts ChartOptions) {
+	var axis strings.Builder
+	axis.WriteString("     └")
+	axis.WriteString(strings.Repeat("─", chartWidth))
+	sb.WriteString(opts.paint(axis.String(), opts.AxisColor))
+	sb.WriteRune('\n')
+
+	// Padding is computed on the plain label text, before any coloring
+	var labels strings.Builder
+	labels.WriteString("     ")
+	labels.WriteString("0s")
+	midPadding := chartWidth/2 - 2
+	if midPadding > 0 {
+		labels.WriteString(strings.Repeat(" ", midPadding))
+		labels.WriteString(fmt.Sprintf("%ds", maxTime/2000))
+	}
+	endPadding := chartWidth - midPadding - 6
+	if endPadding > 0 {
+		labels.WriteString(strings.Repeat(" ", endPadding))
+		labels.WriteString(fmt.Sprintf("%ds", maxTime/1000))
+	}
+	sb.WriteString(opts.paint(labels.String(), opts.AxisColor))
+	sb.WriteRune('\n')
+}
 
 func findMinMax(points []DataPoint) (min, max float64) {
 	if len(points) == 0 {
@@ -341,6 +474,36 @@ func connectPoints(grid [][]rune, points []DataPoint, minVal, maxVal float64, wi
 	}
 }
 
+// smooth returns points with each value replaced by the average of the
+// points within window/2 on either side of it. Near the ends the window
+// shrinks to the points available, and times are left untouched so the X
+// axis doesn't move.
+func smooth(points []DataPoint, window int) []DataPoint {
+	if window <= 1 || len(points) < 2 {
+		return points
+	}
+
+	half := window / 2
+	out := make([]DataPoint, len(points))
+	for i := range points {
+		lo := i - half
+		if lo < 0 {
+			lo = 0
+		}
+		hi := i + half
+		if hi > len(points)-1 {
+			hi = len(points) - 1
+		}
+
+		var sum float64
+		for _, p := range points[lo : hi+1] {
+			sum += p.Value
+		}
+		out[i] = DataPoint{TimeMs: points[i].TimeMs, Value: sum / float64(hi-lo+1)}
+	}
+	return out
+}
+
 // SparklineFromSamples creates a simple sparkline from samples
 func SparklineFromSamples(samples []DataPoint, width int) string {
 	if len(samples) == 0 {
@@ -377,4 +540,3 @@ func SparklineFromSamples(samples []DataPoint, width int) string {
 
 	return result.String()
 }
-
@@ -0,0 +1,247 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Style selects how a chart is drawn
+type Style string
+
+const (
+	StyleBlock   Style = "block"   // one block character per point
+	StyleBraille Style = "braille" // 2x4 sub-dots per cell
+)
+
+// ParseStyle validates a chart style name
+func ParseStyle(s string) (Style, error) {
+	switch Style(s) {
+	case StyleBlock, StyleBraille:
+		return Style(s), nil
+	default:
+		return "", fmt.Errorf("unknown chart style: %s (use block or braille)", s)
+	}
+}
+
+// RenderChartStyle renders one data series with the given style
+func RenderChartStyle(points []DataPoint, opts ChartOptions, style Style) string {
+	if style == StyleBraille {
+		return RenderBrailleChart(points, opts)
+	}
+	return RenderChart(points, opts)
+}
+
+// RenderDualChartStyle renders two data series with the given style
+func RenderDualChartStyle(primary, secondary []DataPoint, opts ChartOptions, style Style) string {
+	if style == StyleBraille {
+		return RenderBrailleDualChart(primary, secondary, opts)
+	}
+	return RenderDualChart(primary, secondary, opts)
+}
+
+// RenderTripleChartStyle renders WPM, Raw WPM, and an error-rate series with
+// the given style
+func RenderTripleChartStyle(primary, secondary, errorRate []DataPoint, opts ChartOptions, style Style) string {
+	if style == StyleBraille {
+		return RenderBrailleTripleChart(primary, secondary, errorRate, opts)
+	}
+	return RenderTripleChart(primary, secondary, errorRate, opts)
+}
+
+// brailleBase is the empty Braille pattern; dots are OR'd onto it
+const brailleBase = 0x2800
+
+// brailleDots maps a dot's (column, row) within a cell to its bit
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// brailleCanvas is a grid of Braille cells addressed in sub-dot coordinates.
+// Each cell also remembers which series drew into it, for coloring.
+type brailleCanvas struct {
+	cells  [][]rune
+	series [][]int
+	width  int // in dots
+	height int // in dots
+}
+
+func newBrailleCanvas(cols, rows int) *brailleCanvas {
+	cells := make([][]rune, rows)
+	series := make([][]int, rows)
+	for i := range cells {
+		cells[i] = make([]rune, cols)
+		series[i] = make([]int, cols)
+	}
+	return &brailleCanvas{cells: cells, series: series, width: cols * 2, height: rows * 4}
+}
+
+func (c *brailleCanvas) set(x, y, series int) {
+	x = clampInt(x, 0, c.width-1)
+	y = clampInt(y, 0, c.height-1)
+	c.cells[y/4][x/2] |= brailleDots[y%4][x%2]
+	c.series[y/4][x/2] = series
+}
+
+// line draws a straight line between two dot coordinates
+func (c *brailleCanvas) line(x1, y1, x2, y2 float64, series int) {
+	steps := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1)))
+	if steps == 0 {
+		c.set(int(math.Round(x1)), int(math.Round(y1)), series)
+		return
+	}
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / float64(steps)
+		c.set(int(math.Round(x1+t*(x2-x1))), int(math.Round(y1+t*(y2-y1))), series)
+	}
+}
+
+// plot draws a series onto the canvas, joining consecutive points if connect is set
+func (c *brailleCanvas) plot(points []DataPoint, minVal, maxVal float64, maxTime int64, series int, connect bool) {
+	var prevX, prevY float64
+	for i, point := range points {
+		x := mapToRange(float64(point.TimeMs), 0, float64(maxTime), 0, float64(c.width-1))
+		y := mapToRange(point.Value, minVal, maxVal, float64(c.height-1), 0)
+		if connect && i > 0 {
+			c.line(prevX, prevY, x, y, series)
+		} else {
+			c.set(int(math.Round(x)), int(math.Round(y)), series)
+		}
+		prevX, prevY = x, y
+	}
+}
+
+func (c *brailleCanvas) row(i int) []rune {
+	row := make([]rune, len(c.cells[i]))
+	for j, dots := range c.cells[i] {
+		if dots == 0 {
+			row[j] = ' '
+		} else {
+			row[j] = brailleBase + dots
+		}
+	}
+	return row
+}
+
+// RenderBrailleChart renders a series of data points as a line chart using
+// Braille glyphs, giving each cell 2x4 dots of resolution
+func RenderBrailleChart(points []DataPoint, opts ChartOptions) string {
+	if len(points) == 0 {
+		return "No data"
+	}
+	return renderBraille([][]DataPoint{points}, nil, opts, false)
+}
+
+// RenderBrailleDualChart renders two data series on the same Braille chart.
+// The primary series is drawn as a line and the secondary as unjoined dots.
+func RenderBrailleDualChart(primary, secondary []DataPoint, opts ChartOptions) string {
+	if len(primary) == 0 && len(secondary) == 0 {
+		return "No data"
+	}
+	return renderBraille([][]DataPoint{primary, secondary}, nil, opts, true)
+}
+
+// RenderBrailleTripleChart renders WPM and Raw WPM like
+// RenderBrailleDualChart plus an error-rate series in percent, drawn as dots
+// against its own axis on the right
+func RenderBrailleTripleChart(primary, secondary, errorRate []DataPoint, opts ChartOptions) string {
+	if len(primary) == 0 && len(secondary) == 0 {
+		return "No data"
+	}
+	return renderBraille([][]DataPoint{primary, secondary}, errorRate, opts, true)
+}
+
+// renderBraille draws each series onto one canvas; only the first is
+// connected. errorRate, if not nil, is drawn beneath them on its own scale.
+func renderBraille(series [][]DataPoint, errorRate []DataPoint, opts ChartOptions, legend bool) string {
+	if opts.Width < 20 {
+		opts.Width = 20
+	}
+	if opts.Height < 5 {
+		opts.Height = 5
+	}
+
+	for i := range series {
+		series[i] = smooth(series[i], opts.Smooth)
+	}
+	errorRate = smooth(errorRate, opts.Smooth)
+
+	var allPoints []DataPoint
+	var maxTime int64
+	for _, points := range series {
+		allPoints = append(allPoints, points...)
+		if len(points) > 0 && points[len(points)-1].TimeMs > maxTime {
+			maxTime = points[len(points)-1].TimeMs
+		}
+	}
+	if maxTime == 0 {
+		maxTime = 1
+	}
+
+	minVal, maxVal := findMinMax(allPoints)
+	valRange := maxVal - minVal
+	if valRange < 1 {
+		valRange = 1
+	}
+	minVal = math.Max(0, minVal-valRange*0.1)
+	maxVal = maxVal + valRange*0.1
+
+	axisWidth := 6
+	if errorRate != nil && opts.ShowAxis {
+		axisWidth += errAxisWidth
+	}
+	chartWidth := opts.Width - axisWidth
+	if chartWidth < 10 {
+		chartWidth = 10
+	}
+
+	// Draw in reverse so the primary series owns any cell it shares, with the
+	// error rate first of all
+	canvas := newBrailleCanvas(chartWidth, opts.Height)
+	errMax := errAxisMax(errorRate)
+	canvas.plot(errorRate, 0, errMax, maxTime, len(series), false)
+	for i := len(series) - 1; i >= 0; i-- {
+		canvas.plot(series[i], minVal, maxVal, maxTime, i, i == 0)
+	}
+	seriesColors := []string{opts.PrimaryColor, opts.SecondaryColor, opts.TertiaryColor}
+
+	var sb strings.Builder
+
+	if opts.Title != "" {
+		sb.WriteString(opts.Title)
+		sb.WriteRune('\n')
+	}
+	if legend {
+		sb.WriteString("      ")
+		primaryLabel, secondaryLabel := opts.legendLabels()
+		sb.WriteString(opts.paint("⠤ "+primaryLabel, opts.PrimaryColor))
+		sb.WriteString("  ")
+		sb.WriteString(opts.paint("⠂ "+secondaryLabel, opts.SecondaryColor))
+		if errorRate != nil {
+			sb.WriteString("  ")
+			sb.WriteString(opts.paint("⠈ err%", opts.TertiaryColor))
+		}
+		sb.WriteRune('\n')
+	}
+
+	for row := 0; row < opts.Height; row++ {
+		if opts.ShowAxis {
+			sb.WriteString(opts.paint(yAxisLabel(row, opts.Height, minVal, maxVal), opts.AxisColor))
+		}
+		owners := canvas.series[row]
+		writeCells(&sb, canvas.row(row), func(i int) string { return seriesColors[owners[i]] }, opts)
+		if errorRate != nil && opts.ShowAxis {
+			sb.WriteString(opts.paint(errAxisLabel(row, opts.Height, errMax), opts.AxisColor))
+		}
+		sb.WriteRune('\n')
+	}
+
+	if opts.ShowAxis {
+		writeXAxis(&sb, chartWidth, maxTime, opts)
+	}
+
+	return sb.String()
+}
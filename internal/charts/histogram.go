@@ -0,0 +1,152 @@
+package charts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Bin is one bucket of a histogram, covering [Low, Low+width)
+type Bin struct {
+	Low   float64
+	Count int
+}
+
+// Bucket groups values into bins of the given width. Bins run from the
+// lowest to the highest occupied bin, so empty bins in between are kept.
+func Bucket(values []float64, width float64) []Bin {
+	if len(values) == 0 || width <= 0 {
+		return nil
+	}
+
+	first := math.Floor(values[0] / width)
+	last := first
+	for _, v := range values {
+		b := math.Floor(v / width)
+		first = math.Min(first, b)
+		last = math.Max(last, b)
+	}
+
+	bins := make([]Bin, int(last-first)+1)
+	for i := range bins {
+		bins[i].Low = (first + float64(i)) * width
+	}
+	for _, v := range values {
+		bins[int(math.Floor(v/width)-first)].Count++
+	}
+	return bins
+}
+
+// barEighths are the partial block characters for 1/8 to 7/8 of a cell
+var barEighths = []rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉'}
+
+// RenderHistogram draws bins as horizontal bars, one row per bin, labeled
+// with the bin's range and count. The longest bar is opts.Width cells and
+// uses opts.PrimaryColor; labels use opts.AxisColor.
+func RenderHistogram(bins []Bin, width float64, opts ChartOptions) string {
+	if len(bins) == 0 {
+		return "No data to display\n"
+	}
+
+	maxCount := 0
+	for _, b := range bins {
+		if b.Count > maxCount {
+			maxCount = b.Count
+		}
+	}
+
+	// Range labels are right-aligned to the widest one
+	labels := make([]string, len(bins))
+	labelWidth := 0
+	for i, b := range bins {
+		labels[i] = fmt.Sprintf("%.0f-%.0f", b.Low, b.Low+width)
+		if len(labels[i]) > labelWidth {
+			labelWidth = len(labels[i])
+		}
+	}
+
+	var sb strings.Builder
+	if opts.Title != "" {
+		sb.WriteString(opts.Title)
+		sb.WriteRune('\n')
+	}
+
+	for i, b := range bins {
+		sb.WriteString(opts.paint(fmt.Sprintf("%*s │", labelWidth, labels[i]), opts.AxisColor))
+		sb.WriteString(opts.paint(bar(float64(b.Count), float64(maxCount), opts.Width), opts.PrimaryColor))
+
+		if b.Count > 0 {
+			sb.WriteString(fmt.Sprintf(" %d", b.Count))
+		}
+		sb.WriteRune('\n')
+	}
+
+	return sb.String()
+}
+
+// bar returns a horizontal bar for value, full width cells long at max.
+// Any value above zero gets at least a sliver.
+func bar(value, max float64, width int) string {
+	// Bar length in eighths of a cell
+	eighths := 0
+	if max > 0 {
+		eighths = int(math.Round(value / max * float64(width*8)))
+	}
+	if value > 0 && eighths == 0 {
+		eighths = 1
+	}
+	s := strings.Repeat("█", eighths/8)
+	if eighths%8 > 0 {
+		s += string(barEighths[eighths%8-1])
+	}
+	return s
+}
+
+// Bar is one labeled row of a bar chart
+type Bar struct {
+	Label string
+	Value float64
+	Text  string // shown after the bar, e.g. the value with its unit
+	Color string // bar color; empty uses opts.PrimaryColor
+	Empty bool   // no data: the row shows Text without a bar
+}
+
+// RenderBars draws one horizontal bar per row, scaled so the largest value
+// is opts.Width cells. Labels are right-aligned and use opts.AxisColor.
+func RenderBars(bars []Bar, opts ChartOptions) string {
+	if len(bars) == 0 {
+		return "No data to display\n"
+	}
+
+	var maxValue float64
+	labelWidth := 0
+	for _, b := range bars {
+		if !b.Empty {
+			maxValue = math.Max(maxValue, b.Value)
+		}
+		labelWidth = max(labelWidth, len(b.Label))
+	}
+
+	var sb strings.Builder
+	if opts.Title != "" {
+		sb.WriteString(opts.Title)
+		sb.WriteRune('\n')
+	}
+
+	for _, b := range bars {
+		sb.WriteString(opts.paint(fmt.Sprintf("%*s │", labelWidth, b.Label), opts.AxisColor))
+		if !b.Empty {
+			color := b.Color
+			if color == "" {
+				color = opts.PrimaryColor
+			}
+			sb.WriteString(opts.paint(bar(b.Value, maxValue, opts.Width), color))
+		}
+		if b.Text != "" {
+			sb.WriteString(" " + b.Text)
+		}
+		sb.WriteRune('\n')
+	}
+
+	return sb.String()
+}
@@ -1,19 +1,38 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"github.com/mmdbasi/mtcli/internal/commands/compare"
+	configcmd "github.com/mmdbasi/mtcli/internal/commands/config"
+	"github.com/mmdbasi/mtcli/internal/commands/db"
+	"github.com/mmdbasi/mtcli/internal/commands/delete"
+	"github.com/mmdbasi/mtcli/internal/commands/export"
+	"github.com/mmdbasi/mtcli/internal/commands/goals"
 	"github.com/mmdbasi/mtcli/internal/commands/history"
+	"github.com/mmdbasi/mtcli/internal/commands/importcmd"
+	"github.com/mmdbasi/mtcli/internal/commands/keys"
+	"github.com/mmdbasi/mtcli/internal/commands/languages"
+	"github.com/mmdbasi/mtcli/internal/commands/leaderboard"
+	"github.com/mmdbasi/mtcli/internal/commands/menu"
+	"github.com/mmdbasi/mtcli/internal/commands/quotes"
+	"github.com/mmdbasi/mtcli/internal/commands/replay"
+	"github.com/mmdbasi/mtcli/internal/commands/serve"
 	"github.com/mmdbasi/mtcli/internal/commands/show"
 	"github.com/mmdbasi/mtcli/internal/commands/stats"
+	"github.com/mmdbasi/mtcli/internal/commands/streak"
+	"github.com/mmdbasi/mtcli/internal/commands/sync"
 	"github.com/mmdbasi/mtcli/internal/commands/test"
 	"github.com/mmdbasi/mtcli/internal/config"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
 	cfgFile string
+	dbFile  string
 	rootCmd = &cobra.Command{
 		Use:   "mtcli",
 		Short: "A terminal typing test inspired by Monkeytype",
@@ -23,8 +42,24 @@ It supports multiple modes:
   - Timer mode: Type as many words as you can in a set time
   - Words mode: Type a fixed number of words
   - Quote mode: Type famous quotes
+  - Text mode: Type your own text from a file
+  - Practice mode: Drill words containing your most-missed keys
+  - Code mode: Type source code from a file, tabs and indentation included
+  - Zen mode: Type endless words until you press Esc
 
-Your results are saved locally so you can track your progress over time.`,
+Your results are saved locally so you can track your progress over time.
+
+Run without a command to pick a test, your stats, or your history from a
+menu.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The menu needs a terminal to draw on and read keys from
+			if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+				return cmd.Help()
+			}
+			noColor, _ := cmd.Flags().GetBool("no-color")
+			return menu.Run(cmd.Context(), noColor || config.Get().NoColor)
+		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
@@ -34,19 +69,43 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/mtcli/config.toml)")
+	rootCmd.PersistentFlags().StringVar(&dbFile, "db", "", "database file (default is mtcli.db in the data directory; also MTCLI_DB)")
 	rootCmd.PersistentFlags().Bool("no-color", false, "disable color output")
 
+	rootCmd.Version = version
+	rootCmd.SetVersionTemplate(versionString() + "\n")
+
 	// Add subcommands
 	rootCmd.AddCommand(test.NewTestCmd())
 	rootCmd.AddCommand(stats.NewStatsCmd())
 	rootCmd.AddCommand(history.NewHistoryCmd())
 	rootCmd.AddCommand(show.NewShowCmd())
+	rootCmd.AddCommand(export.NewExportCmd())
+	rootCmd.AddCommand(delete.NewDeleteCmd())
+	rootCmd.AddCommand(keys.NewKeysCmd())
+	rootCmd.AddCommand(compare.NewCompareCmd())
+	rootCmd.AddCommand(configcmd.NewConfigCmd())
+	rootCmd.AddCommand(quotes.NewQuotesCmd())
+	rootCmd.AddCommand(leaderboard.NewLeaderboardCmd())
+	rootCmd.AddCommand(replay.NewReplayCmd())
+	rootCmd.AddCommand(streak.NewStreakCmd())
+	rootCmd.AddCommand(goals.NewGoalsCmd())
+	rootCmd.AddCommand(db.NewDBCmd())
+	rootCmd.AddCommand(languages.NewLanguagesCmd())
+	rootCmd.AddCommand(test.NewPreviewCmd())
+	rootCmd.AddCommand(importcmd.NewImportCmd())
+	rootCmd.AddCommand(sync.NewSyncCmd())
+	rootCmd.AddCommand(serve.NewServeCmd())
+	rootCmd.AddCommand(newVersionCmd())
 }
 
 func initConfig() {
 	if cfgFile != "" {
 		config.SetConfigFile(cfgFile)
 	}
+	if dbFile != "" {
+		config.SetDBPath(dbFile)
+	}
 
 	if err := config.Load(); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not load config: %v\n", err)
@@ -54,6 +113,11 @@ func initConfig() {
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	return ExecuteContext(context.Background())
 }
 
+// ExecuteContext runs the root command with ctx, which commands pass on to
+// the database so cancelling it abandons their queries
+func ExecuteContext(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
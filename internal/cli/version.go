@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, overridden at build time with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/mmdbasi/mtcli/internal/cli.version=v1.2.0" ./cmd/mtcli
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// versionString returns the full version line shown by --version and the
+// version command
+func versionString() string {
+	return fmt.Sprintf("mtcli %s (commit %s, built %s, %s %s/%s)",
+		version, commit, date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print version and build information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("mtcli %s\n", version)
+			fmt.Printf("  Commit:      %s\n", commit)
+			fmt.Printf("  Built:       %s\n", date)
+			fmt.Printf("  Go version:  %s\n", runtime.Version())
+			fmt.Printf("  Platform:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		},
+	}
+}
@@ -0,0 +1,210 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/charts"
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the compare command options
+type Options struct {
+	ChartStyle  string
+	ChartSmooth int
+	NoColor     bool
+}
+
+func NewCompareCmd() *cobra.Command {
+	opts := &Options{}
+	cfg := config.Get()
+
+	cmd := &cobra.Command{
+		Use:   "compare <id1> <id2>",
+		Short: "Compare two test sessions side by side",
+		Long: `Compare two typing test sessions side by side.
+
+Shows:
+  - WPM, raw WPM, accuracy, consistency, and duration for both sessions
+  - The change from the first session to the second
+  - Both sessions' WPM over time on one chart
+
+Examples:
+  mtcli compare 12 15`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+			return runCompare(cmd.Context(), args[0], args[1], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.ChartStyle, "chart-style", cfg.ChartStyle, "chart style: block or braille (braille falls back to block with --no-color)")
+	cmd.Flags().IntVar(&opts.ChartSmooth, "chart-smooth", 0, "smooth the speed chart with a moving average over N samples (0 for none)")
+
+	return cmd
+}
+
+// sessionData is a session together with its speed samples
+type sessionData struct {
+	session *sqlite.Session
+	samples []sqlite.SessionSample
+}
+
+func runCompare(ctx context.Context, firstIDStr, secondIDStr string, opts *Options) error {
+	chartStyle, err := charts.ParseStyle(opts.ChartStyle)
+	if err != nil {
+		return err
+	}
+	colorMode, err := ui.ParseColorMode(config.Get().ColorMode)
+	if err != nil {
+		return err
+	}
+	theme, err := ui.ParseTheme(config.Get().Theme, colorMode)
+	if err != nil {
+		return err
+	}
+	if config.Get().NoColor || colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+	if opts.NoColor {
+		chartStyle = charts.StyleBlock
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	first, err := loadSession(ctx, store, firstIDStr)
+	if err != nil {
+		return err
+	}
+	second, err := loadSession(ctx, store, secondIDStr)
+	if err != nil {
+		return err
+	}
+
+	a, b := first.session, second.session
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Printf("  ║    COMPARE #%-5d vs #%-5d          ║\n", a.ID, b.ID)
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	// Side-by-side table
+	fmt.Printf("  %-12s %12s %12s   %s\n", "", fmt.Sprintf("#%d", a.ID), fmt.Sprintf("#%d", b.ID), "Change")
+	fmt.Println("  ────────────────────────────────────────────────────────")
+	fmt.Printf("  %-12s %12s %12s\n", "Date", a.StartedAt.Format("2006-01-02"), b.StartedAt.Format("2006-01-02"))
+	fmt.Printf("  %-12s %12s %12s\n", "Mode", a.Mode, b.Mode)
+	fmt.Printf("  %-12s %12.1f %12.1f   %s\n", "WPM", a.WPM, b.WPM, formatDelta(b.WPM-a.WPM, " WPM"))
+	fmt.Printf("  %-12s %12.1f %12.1f   %s\n", "Raw WPM", a.RawWPM, b.RawWPM, formatDelta(b.RawWPM-a.RawWPM, " WPM"))
+	fmt.Printf("  %-12s %11.1f%% %11.1f%%   %s\n", "Accuracy", a.Accuracy, b.Accuracy, formatDelta(b.Accuracy-a.Accuracy, "%"))
+	if a.Consistency > 0 && b.Consistency > 0 {
+		fmt.Printf("  %-12s %11.0f%% %11.0f%%   %s\n", "Consistency", a.Consistency, b.Consistency, formatDelta(b.Consistency-a.Consistency, "%"))
+	} else {
+		fmt.Printf("  %-12s %12s %12s\n", "Consistency", formatConsistency(a.Consistency), formatConsistency(b.Consistency))
+	}
+	durationDiff := time.Duration(b.DurationMs-a.DurationMs) * time.Millisecond
+	fmt.Printf("  %-12s %12s %12s   %+.1fs\n", "Duration",
+		formatDuration(time.Duration(a.DurationMs)*time.Millisecond),
+		formatDuration(time.Duration(b.DurationMs)*time.Millisecond),
+		durationDiff.Seconds())
+	fmt.Println()
+
+	// Overlaid speed chart
+	if len(first.samples) > 0 || len(second.samples) > 0 {
+		fmt.Println("  Speed over time")
+		fmt.Println("  ────────────────────────────────────────")
+		fmt.Println()
+
+		chartOpts := charts.DefaultOptions()
+		chartOpts.Smooth = opts.ChartSmooth
+		chartOpts.Width = 60
+		chartOpts.Height = 10
+		chartOpts.PrimaryLabel = fmt.Sprintf("#%d WPM", a.ID)
+		chartOpts.SecondaryLabel = fmt.Sprintf("#%d WPM", b.ID)
+		if !opts.NoColor {
+			ui.ChartColors(&chartOpts, theme)
+		}
+		chart := charts.RenderDualChartStyle(wpmPoints(first.samples), wpmPoints(second.samples), chartOpts, chartStyle)
+
+		for _, line := range strings.Split(strings.TrimRight(chart, "\n"), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	fmt.Println()
+
+	return nil
+}
+
+// loadSession fetches a session and its samples by ID string
+func loadSession(ctx context.Context, store *sqlite.Store, idStr string) (*sessionData, error) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session ID: %s", idStr)
+	}
+
+	session, err := store.GetSessionContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("session %d not found", id)
+	}
+
+	samples, err := store.GetSamplesContext(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get samples: %w", err)
+	}
+
+	return &sessionData{session: session, samples: samples}, nil
+}
+
+// wpmPoints converts samples to chart points on a shared time axis
+func wpmPoints(samples []sqlite.SessionSample) []charts.DataPoint {
+	points := make([]charts.DataPoint, len(samples))
+	for i, s := range samples {
+		points[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.WPM}
+	}
+	return points
+}
+
+// formatDelta renders a change with a trend arrow, e.g. "↑ +4.2 WPM"
+func formatDelta(diff float64, unit string) string {
+	diff = math.Round(diff*10) / 10
+	switch {
+	case diff > 0:
+		return fmt.Sprintf("↑ +%.1f%s", diff, unit)
+	case diff < 0:
+		return fmt.Sprintf("↓ %.1f%s", diff, unit)
+	default:
+		return "→ same"
+	}
+}
+
+func formatConsistency(c float64) string {
+	if c > 0 {
+		return fmt.Sprintf("%.0f%%", c)
+	}
+	return "N/A"
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	m := int(d.Minutes())
+	s := int(d.Seconds()) % 60
+	return fmt.Sprintf("%dm %ds", m, s)
+}
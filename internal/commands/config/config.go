@@ -0,0 +1,222 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/assets"
+	"github.com/mmdbasi/mtcli/internal/charts"
+	appconfig "github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/input"
+	"github.com/mmdbasi/mtcli/internal/test"
+	"github.com/mmdbasi/mtcli/internal/text"
+	"github.com/mmdbasi/mtcli/internal/ui"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+// ShowOptions holds the config show command options
+type ShowOptions struct {
+	JSON bool
+}
+
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View and edit the configuration",
+		Long: `View and edit the mtcli configuration file.
+
+Examples:
+  mtcli config path               # Print the config file location
+  mtcli config show               # Print the effective configuration
+  mtcli config set seconds 60     # Change a default
+  mtcli config init               # Write a default config file`,
+	}
+
+	cmd.AddCommand(newPathCmd())
+	cmd.AddCommand(newShowCmd())
+	cmd.AddCommand(newSetCmd())
+	cmd.AddCommand(newInitCmd())
+
+	return cmd
+}
+
+func newPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file path",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := appconfig.FilePath()
+			if err != nil {
+				return fmt.Errorf("failed to resolve config path: %w", err)
+			}
+			fmt.Println(path)
+			return nil
+		},
+	}
+}
+
+func newShowCmd() *cobra.Command {
+	opts := &ShowOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration",
+		Long: `Print the effective configuration: defaults, overridden by the config
+file, overridden by MTCLI_ environment variables.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShow(opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "print as JSON instead of TOML")
+
+	return cmd
+}
+
+func runShow(opts *ShowOptions) error {
+	values := appconfig.Get().ToMap()
+
+	if opts.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	}
+
+	data, err := toml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func newSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a value in the config file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSet(args[0], args[1])
+		},
+	}
+}
+
+func runSet(key, value string) error {
+	path, err := appconfig.FilePath()
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path: %w", err)
+	}
+
+	// Start from the file alone so environment overrides aren't persisted
+	c, err := appconfig.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := c.Set(key, value); err != nil {
+		return err
+	}
+	if err := validate(key, value); err != nil {
+		return err
+	}
+	if strings.HasPrefix(key, "keymap.") {
+		if _, err := input.ParseKeymap(c.Keymap); err != nil {
+			return fmt.Errorf("invalid keymap: %w", err)
+		}
+	}
+
+	if err := appconfig.WriteFile(c, path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	fmt.Printf("Set %s = %s in %s\n", key, value, path)
+	return nil
+}
+
+// validate checks values for keys that only accept a fixed set of names
+func validate(key, value string) error {
+	// A mode default is checked like the top-level key, in a known mode
+	if rest, ok := strings.CutPrefix(key, "modes."); ok {
+		mode, name, _ := strings.Cut(rest, ".")
+		if err := validate("mode", mode); err != nil {
+			return err
+		}
+		return validate(name, value)
+	}
+
+	var err error
+	switch key {
+	case "mode":
+		switch test.Mode(value) {
+		case test.ModeTimer, test.ModeWords, test.ModeQuote, test.ModeText, test.ModePractice, test.ModeCode, test.ModeZen:
+		default:
+			err = fmt.Errorf("unknown mode: %s (use timer, words, quote, text, practice, code, or zen)", value)
+		}
+	case "language":
+		if _, ok := assets.WordList(value); !ok {
+			err = fmt.Errorf("unknown language: %s (see 'mtcli languages')", value)
+		}
+	case "generator":
+		if !slices.Contains(text.Generators(), value) {
+			err = fmt.Errorf("unknown generator: %s (use %s)", value, strings.Join(text.Generators(), ", "))
+		}
+	case "chart_style":
+		_, err = charts.ParseStyle(value)
+	case "countdown_style":
+		_, err = ui.ParseCountdownStyle(value)
+	case "countdown_words":
+		if len(ui.SplitCountdownWords(value)) == 0 {
+			err = fmt.Errorf("countdown_words needs at least one label")
+		}
+	case "caret_style":
+		_, err = ui.ParseCaretStyle(value)
+	case "unit":
+		_, err = ui.ParseSpeedUnit(value)
+	case "bell":
+		_, err = ui.ParseBellMode(value)
+	case "theme":
+		_, err = ui.ParseTheme(value, ui.ColorMode256)
+	case "color_mode":
+		_, err = ui.ParseColorMode(value)
+	case "goal_tests", "goal_wpm", "caret_blink_ms":
+		if strings.HasPrefix(strings.TrimSpace(value), "-") {
+			err = fmt.Errorf("%s must not be negative", key)
+		}
+	}
+	return err
+}
+
+func newInitCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "init",
+		Short: "Write a default config file if none exists",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit()
+		},
+	}
+}
+
+func runInit() error {
+	path, err := appconfig.FilePath()
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("Config file already exists: %s\n", path)
+		return nil
+	}
+
+	if err := appconfig.WriteFile(appconfig.Default(), path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	fmt.Printf("Wrote default config to %s\n", path)
+	return nil
+}
@@ -0,0 +1,444 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// BackupOptions holds the db backup command options
+type BackupOptions struct {
+	Force bool
+}
+
+// RestoreOptions holds the db restore command options
+type RestoreOptions struct {
+	Yes bool
+}
+
+func NewDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain the results database",
+		Long: `Inspect and maintain the SQLite database that holds your results.
+
+Examples:
+  mtcli db stats    # Row counts and file size
+  mtcli db check    # Run an integrity check
+  mtcli db vacuum   # Reclaim space left by deleted sessions
+  mtcli db backup ~/backups/mtcli.db
+  mtcli db restore ~/backups/mtcli.db
+  mtcli db version  # Schema version and pending migrations
+  mtcli db migrate  # Apply pending migrations now`,
+	}
+
+	cmd.AddCommand(newVacuumCmd())
+	cmd.AddCommand(newCheckCmd())
+	cmd.AddCommand(newStatsCmd())
+	cmd.AddCommand(newBackupCmd())
+	cmd.AddCommand(newRestoreCmd())
+	cmd.AddCommand(newVersionCmd())
+	cmd.AddCommand(newMigrateCmd())
+
+	return cmd
+}
+
+func newVacuumCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "vacuum",
+		Short: "Rebuild the database to reclaim unused space",
+		Long: `Rebuild the database file, returning the space left behind by deleted
+sessions to the file system. Reports the file size before and after.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVacuum()
+		},
+	}
+}
+
+func newCheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Check the database for corruption",
+		Long:  `Run SQLite's integrity check on the database and report any problems.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheck()
+		},
+	}
+}
+
+func newStatsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Show database row counts and file size",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStats()
+		},
+	}
+}
+
+func newBackupCmd() *cobra.Command {
+	opts := &BackupOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "backup <file>",
+		Short: "Copy the database to a backup file",
+		Long: `Write a consistent copy of the database to file. The copy is safe to take
+while another mtcli is running. An existing file is only replaced with
+--force.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBackup(args[0], opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "overwrite an existing backup file")
+
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	opts := &RestoreOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Replace the database with a backup",
+		Long: `Replace the database with a backup made by 'mtcli db backup'. The file is
+checked first: it must be an mtcli database no newer than this version.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestore(args[0], opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "skip the confirmation prompt")
+
+	return cmd
+}
+
+func newVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Show the database schema version",
+		Long: `Print the schema version of the database next to the version this mtcli
+uses, and list any migrations still to apply. The database is read, not
+migrated.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersion()
+		},
+	}
+}
+
+func newMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		Long: `Bring the database up to the schema this mtcli uses, listing each
+migration as it is applied. Every command migrates the database when it
+opens it, so this is only needed to upgrade ahead of time, for example
+right after restoring an old backup. Running it again does nothing.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(cmd.Context())
+		},
+	}
+}
+
+// existingPath returns the path of the database. ok is false, after telling
+// the user, when there is no database yet.
+func existingPath() (path string, ok bool, err error) {
+	path, err = sqlite.Path()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to locate database: %w", err)
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("\n  No database yet at %s.\n", path)
+		fmt.Println("  Run 'mtcli test' to start your first test!")
+		fmt.Println()
+		return path, false, nil
+	} else if err != nil {
+		return path, false, fmt.Errorf("failed to read database: %w", err)
+	}
+	return path, true, nil
+}
+
+// openExisting opens the database without creating it. ok is false, after
+// telling the user, when there is no database yet.
+func openExisting() (store *sqlite.Store, path string, ok bool, err error) {
+	path, ok, err = existingPath()
+	if !ok {
+		return nil, path, false, err
+	}
+
+	store, err = sqlite.OpenPath(path)
+	if err != nil {
+		return nil, path, false, fmt.Errorf("failed to open database: %w", err)
+	}
+	return store, path, true, nil
+}
+
+func runVacuum() error {
+	store, path, ok, err := openExisting()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	before, err := fileSize(path)
+	if err != nil {
+		return err
+	}
+	if err := store.Vacuum(); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	after, err := fileSize(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Vacuumed %s\n", path)
+	fmt.Printf("  Size: %s -> %s (saved %s)\n", formatBytes(before), formatBytes(after), formatBytes(max(before-after, 0)))
+	return nil
+}
+
+func runCheck() error {
+	store, path, ok, err := openExisting()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	results, err := store.IntegrityCheck()
+	if err != nil {
+		return fmt.Errorf("failed to check database: %w", err)
+	}
+
+	if len(results) == 1 && results[0] == "ok" {
+		fmt.Printf("  %s: ok\n", path)
+		return nil
+	}
+	fmt.Printf("  %s: %d problem(s) found\n", path, len(results))
+	for _, line := range results {
+		fmt.Printf("    %s\n", line)
+	}
+	return fmt.Errorf("integrity check failed")
+}
+
+func runStats() error {
+	store, path, ok, err := openExisting()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	counts, err := store.TableCounts()
+	if err != nil {
+		return fmt.Errorf("failed to count rows: %w", err)
+	}
+	freePages, err := store.FreePages()
+	if err != nil {
+		return fmt.Errorf("failed to read free pages: %w", err)
+	}
+	size, err := fileSize(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("  Database")
+	fmt.Println("  ────────────────────────────────────────")
+	fmt.Printf("  Path:       %s\n", path)
+	fmt.Printf("  Size:       %s\n", formatBytes(size))
+	fmt.Printf("  Free pages: %d", freePages)
+	if freePages > 0 {
+		fmt.Print(" (run 'mtcli db vacuum' to reclaim)")
+	}
+	fmt.Println()
+	fmt.Println()
+
+	fmt.Println("  Rows")
+	fmt.Println("  ────────────────────────────────────────")
+	for _, c := range counts {
+		fmt.Printf("  %-11s %d\n", c.Table+":", c.Rows)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func runVersion() error {
+	path, ok, err := existingPath()
+	if !ok {
+		return err
+	}
+
+	version, err := sqlite.CheckFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+
+	fmt.Printf("  Database: %s\n", path)
+	fmt.Printf("  Schema:   version %d (this mtcli uses %d)\n", version, sqlite.CurrentSchemaVersion)
+
+	pending := sqlite.PendingMigrations(version)
+	if len(pending) == 0 {
+		fmt.Println("  Up to date.")
+		return nil
+	}
+	fmt.Printf("  Pending:  %d migration(s); run 'mtcli db migrate' to apply\n", len(pending))
+	for _, m := range pending {
+		fmt.Printf("    %3d  %s\n", m.Version, m.Description)
+	}
+	return nil
+}
+
+func runMigrate(ctx context.Context) error {
+	path, ok, err := existingPath()
+	if !ok {
+		return err
+	}
+
+	// Refuse databases from a newer mtcli rather than reporting them as up
+	// to date
+	if _, err := sqlite.CheckFile(path); err != nil {
+		return fmt.Errorf("cannot migrate %s: %w", path, err)
+	}
+
+	from, applied, err := sqlite.MigratePath(ctx, path)
+	for _, m := range applied {
+		fmt.Printf("  Applied %3d  %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("  %s is up to date (schema version %d)\n", path, from)
+		return nil
+	}
+	fmt.Printf("  Migrated %s from schema version %d to %d\n", path, from, applied[len(applied)-1].Version)
+	return nil
+}
+
+func runBackup(dest string, opts *BackupOptions) error {
+	store, path, ok, err := openExisting()
+	if !ok {
+		return err
+	}
+	defer store.Close()
+
+	if samePath(dest, path) {
+		return fmt.Errorf("backup file is the database itself: %s", dest)
+	}
+	if _, err := os.Stat(dest); err == nil && !opts.Force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", dest)
+	}
+
+	if err := backupTo(store, dest); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	size, err := fileSize(dest)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Backed up %s to %s (%s)\n", path, dest, formatBytes(size))
+	return nil
+}
+
+// backupTo writes a backup of store to dest, replacing any file there. The
+// backup is written to a fresh directory next to dest and renamed over it,
+// so an existing backup is kept until the new one is complete.
+func backupTo(store *sqlite.Store, dest string) error {
+	dir, err := os.MkdirTemp(filepath.Dir(dest), filepath.Base(dest)+".backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, filepath.Base(dest))
+	if err := store.Backup(tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+func runRestore(src string, opts *RestoreOptions) error {
+	version, err := sqlite.CheckFile(src)
+	if err != nil {
+		return fmt.Errorf("cannot restore %s: %w", src, err)
+	}
+
+	path, err := sqlite.Path()
+	if err != nil {
+		return fmt.Errorf("failed to locate database: %w", err)
+	}
+	if samePath(src, path) {
+		return fmt.Errorf("%s is the current database", src)
+	}
+
+	if !opts.Yes && !confirm(fmt.Sprintf("Replace %s with %s? Current results will be lost.", path, src)) {
+		fmt.Println("  Aborted.")
+		return nil
+	}
+
+	if err := sqlite.Restore(src, path); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	fmt.Printf("  Restored %s from %s (schema version %d)\n", path, src, version)
+	return nil
+}
+
+// samePath reports whether a and b name the same file
+func samePath(a, b string) bool {
+	if ai, err := os.Stat(a); err == nil {
+		if bi, err := os.Stat(b); err == nil {
+			return os.SameFile(ai, bi)
+		}
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("  %s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read database size: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// formatBytes returns n as a human-readable size, e.g. "1.5 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	size := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if size < unit {
+			return fmt.Sprintf("%.1f %s", size, suffix)
+		}
+		size /= unit
+	}
+	return fmt.Sprintf("%.1f TB", size)
+}
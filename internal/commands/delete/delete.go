@@ -0,0 +1,140 @@
+package delete
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the delete command options
+type Options struct {
+	Yes bool
+	All bool
+}
+
+func NewDeleteCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "delete <session_id>...",
+		Short: "Delete test sessions",
+		Long: `Delete one or more typing test sessions and their speed samples.
+
+You are asked for confirmation unless --yes is given.
+
+Examples:
+  mtcli delete 42          # Delete session 42
+  mtcli delete 3 7 9 --yes # Delete several sessions without asking
+  mtcli delete --all       # Delete your whole history`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.All {
+				if len(args) > 0 {
+					return fmt.Errorf("--all cannot be combined with session IDs")
+				}
+				return runDeleteAll(opts)
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("requires at least one session ID (or --all)")
+			}
+			return runDelete(cmd.Context(), args, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "skip the confirmation prompt")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "delete all sessions")
+
+	return cmd
+}
+
+func runDelete(ctx context.Context, args []string, opts *Options) error {
+	ids := make([]int64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid session ID: %s", arg)
+		}
+		ids[i] = id
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	// Split into existing and missing sessions
+	var found, missing []int64
+	for _, id := range ids {
+		session, err := store.GetSessionContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+		if session == nil {
+			missing = append(missing, id)
+		} else {
+			found = append(found, id)
+		}
+	}
+
+	if len(found) > 0 {
+		if !opts.Yes && !confirm(fmt.Sprintf("Delete %d session(s)?", len(found))) {
+			fmt.Println("  Aborted.")
+			return nil
+		}
+
+		for _, id := range found {
+			if err := store.DeleteSession(id); err != nil {
+				return fmt.Errorf("failed to delete session %d: %w", id, err)
+			}
+		}
+		fmt.Printf("  Deleted: %s\n", formatIDs(found))
+	}
+
+	if len(missing) > 0 {
+		fmt.Printf("  Not found: %s\n", formatIDs(missing))
+	}
+
+	return nil
+}
+
+func runDeleteAll(opts *Options) error {
+	store, err := sqlite.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	if !opts.Yes && !confirm("Delete ALL sessions? This cannot be undone.") {
+		fmt.Println("  Aborted.")
+		return nil
+	}
+
+	if err := store.DeleteAllSessions(); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	fmt.Println("  All sessions deleted.")
+
+	return nil
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("  %s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func formatIDs(ids []int64) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,195 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/report"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the export command options
+type Options struct {
+	Format      string
+	Mode        string
+	Since       string
+	Until       string
+	WithSamples bool
+	Output      string
+}
+
+// csvHeader is the stable CSV column order. Columns may be appended in
+// future versions but existing ones are never renamed or reordered.
+var csvHeader = []string{
+	"id", "started_at", "mode", "seconds", "words", "quote_id", "source",
+	"target_len", "duration_ms", "correct_chars", "incorrect_chars",
+	"total_typed", "accuracy", "wpm", "raw_wpm", "consistency",
+}
+
+func NewExportCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export your test history as CSV or JSON",
+		Long: `Export all recorded typing tests for analysis in other tools.
+
+CSV output has one row per session with these columns, in this order:
+
+  ` + strings.Join(csvHeader, ",") + `
+
+JSON output is an array of session objects using the same field names.
+With --with-samples each object also carries its speed samples.
+
+Examples:
+  mtcli export --format csv > history.csv
+  mtcli export --format json --with-samples --output history.json
+  mtcli export --mode timer --since 2024-01-01`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "csv", "output format: csv or json")
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", "", "filter by mode (timer, words, quote, text, practice, code, zen)")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "only sessions on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "only sessions on or before this date (YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&opts.WithSamples, "with-samples", false, "include speed samples (json only)")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "write to file instead of stdout")
+
+	return cmd
+}
+
+func runExport(ctx context.Context, opts *Options) error {
+	if opts.Format != "csv" && opts.Format != "json" {
+		return fmt.Errorf("unknown format: %s (use csv or json)", opts.Format)
+	}
+	if opts.WithSamples && opts.Format != "json" {
+		return fmt.Errorf("--with-samples requires --format json")
+	}
+
+	since, err := parseDate(opts.Since)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseDate(opts.Until)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	if !until.IsZero() {
+		// Include the whole final day
+		until = until.AddDate(0, 0, 1)
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	all, err := store.ListAllSessions(opts.Mode)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var sessions []sqlite.Session
+	for _, session := range all {
+		if !since.IsZero() && session.StartedAt.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !session.StartedAt.Before(until) {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	var w io.Writer = os.Stdout
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if opts.Format == "json" {
+		return writeJSON(ctx, w, store, sessions, opts.WithSamples)
+	}
+	return writeCSV(w, sessions)
+}
+
+func writeCSV(w io.Writer, sessions []sqlite.Session) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		record := []string{
+			strconv.FormatInt(s.ID, 10),
+			s.StartedAt.Format(time.RFC3339),
+			s.Mode,
+			strconv.Itoa(s.Seconds),
+			strconv.Itoa(s.Words),
+			s.QuoteID,
+			s.Source,
+			strconv.Itoa(s.TargetLen),
+			strconv.FormatInt(s.DurationMs, 10),
+			strconv.Itoa(s.CorrectChars),
+			strconv.Itoa(s.IncorrectChars),
+			strconv.Itoa(s.TotalTyped),
+			formatFloat(s.Accuracy),
+			formatFloat(s.WPM),
+			formatFloat(s.RawWPM),
+			formatFloat(s.Consistency),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(ctx context.Context, w io.Writer, store *sqlite.Store, sessions []sqlite.Session, withSamples bool) error {
+	out := make([]report.Session, 0, len(sessions))
+	for i := range sessions {
+		session := report.FromStored(&sessions[i])
+
+		if withSamples {
+			samples, err := store.GetSamplesContext(ctx, session.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get samples for session %d: %w", session.ID, err)
+			}
+			session.Samples = report.SamplesFromStored(samples)
+		}
+
+		out = append(out, session)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// parseDate parses a YYYY-MM-DD date in local time; empty means no bound
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
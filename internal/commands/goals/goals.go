@@ -0,0 +1,156 @@
+package goals
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/activity"
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the goals command options
+type Options struct {
+	Tests   int
+	WPM     float64
+	NoColor bool
+}
+
+func NewGoalsCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "goals",
+		Short: "Check today's tests against your daily goal",
+		Long: `Check today's tests against a daily goal, such as three tests a day at
+70 WPM or more, and show your streak of days that met it.
+
+The goal comes from the goal_tests and goal_wpm config keys; the flags
+override them for one run. Tests slower than goal_wpm don't count towards
+it. Days are counted in your local time zone, as in 'mtcli streak'.
+
+Examples:
+  mtcli config set goal_tests 3
+  mtcli config set goal_wpm 70
+  mtcli goals
+  mtcli goals --tests 5 --wpm 0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+			// Flag defaults are read before the config file loads
+			if !cmd.Flags().Changed("tests") {
+				opts.Tests = config.Get().GoalTests
+			}
+			if !cmd.Flags().Changed("wpm") {
+				opts.WPM = config.Get().GoalWPM
+			}
+			return runGoals(opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Tests, "tests", 0, "tests a day the goal asks for (default goal_tests)")
+	cmd.Flags().Float64Var(&opts.WPM, "wpm", 0, "WPM a test needs to count, 0 for any (default goal_wpm)")
+
+	return cmd
+}
+
+func runGoals(opts *Options) error {
+	if opts.Tests < 0 || opts.WPM < 0 {
+		return fmt.Errorf("--tests and --wpm must not be negative")
+	}
+	if opts.Tests == 0 {
+		fmt.Println("\n  No daily goal set.")
+		fmt.Println("  Set one with 'mtcli config set goal_tests 3', and optionally goal_wpm.")
+		fmt.Println()
+		return nil
+	}
+
+	colorMode, err := ui.ParseColorMode(config.Get().ColorMode)
+	if err != nil {
+		return err
+	}
+	theme, err := ui.ParseTheme(config.Get().Theme, colorMode)
+	if err != nil {
+		return err
+	}
+	if config.Get().NoColor || colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+	paint := func(s, color string) string {
+		if opts.NoColor {
+			return s
+		}
+		return ui.ColoredString(s, color)
+	}
+
+	store, err := sqlite.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	counts, err := store.GetDailyCountsMinWPM(opts.WPM)
+	if err != nil {
+		return fmt.Errorf("failed to get daily counts: %w", err)
+	}
+
+	today := activity.Today(time.Now())
+	done := counts[today.Format(activity.DateFormat)]
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Println("  ║            DAILY GOAL                ║")
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	fmt.Printf("  Goal:             %s\n", describe(opts.Tests, opts.WPM))
+	if done >= opts.Tests {
+		fmt.Printf("  Today:            %s\n", paint(fmt.Sprintf("%d/%d, met", done, opts.Tests), theme.Success))
+	} else {
+		fmt.Printf("  Today:            %d/%d, %d to go\n", done, opts.Tests, opts.Tests-done)
+	}
+
+	// One mark per day, oldest first; today can't have failed yet
+	var week []string
+	for i := 6; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		mark := paint("✗", theme.Incorrect)
+		switch {
+		case counts[day.Format(activity.DateFormat)] >= opts.Tests:
+			mark = paint("✓", theme.Success)
+		case i == 0:
+			mark = paint("·", theme.Unattempted)
+		}
+		week = append(week, day.Format("Mon")+" "+mark)
+	}
+	fmt.Printf("  Last 7 days:      %s\n", strings.Join(week, "  "))
+
+	fmt.Printf("  Current streak:   %s\n", formatDays(activity.CurrentStreak(counts, today, opts.Tests)))
+	fmt.Printf("  Longest streak:   %s\n", formatDays(activity.LongestStreak(counts, opts.Tests)))
+	fmt.Println()
+
+	return nil
+}
+
+// describe returns the goal in words, e.g. "3 tests a day at 70+ WPM"
+func describe(tests int, wpm float64) string {
+	s := fmt.Sprintf("%d tests a day", tests)
+	if tests == 1 {
+		s = "1 test a day"
+	}
+	if wpm > 0 {
+		s += fmt.Sprintf(" at %.0f+ WPM", wpm)
+	}
+	return s
+}
+
+func formatDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
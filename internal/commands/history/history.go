@@ -1,18 +1,31 @@
 package history
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/mmdbasi/mtcli/internal/charts"
+	"github.com/mmdbasi/mtcli/internal/daterange"
+	"github.com/mmdbasi/mtcli/internal/report"
 	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
 	"github.com/spf13/cobra"
 )
 
 // Options holds the history command options
 type Options struct {
-	Limit int
-	Mode  string
+	Limit       int
+	Mode        string
+	Since       string
+	Until       string
+	MinWPM      float64
+	MaxWPM      float64
+	MinAccuracy float64
+	JSON        bool
 }
 
 func NewHistoryCmd() *cobra.Command {
@@ -23,35 +36,88 @@ func NewHistoryCmd() *cobra.Command {
 		Short: "Show your test history",
 		Long: `Display a list of your recent typing tests.
 
-Shows date, mode, WPM, raw WPM, accuracy, duration, and session ID for each test.`,
+Shows date, mode, WPM, raw WPM, accuracy, duration, and session ID for each test.
+
+--since and --until accept a date (YYYY-MM-DD) or a duration before now
+such as 7d or 2w.
+
+--min-wpm, --max-wpm, and --min-accuracy narrow the list to your best or
+worst runs, and combine with each other and with --mode.
+
+Examples:
+  mtcli history --min-wpm 80 --min-accuracy 97
+  mtcli history --since 7d
+  mtcli history --since 2024-03-01 --until 2024-03-31`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runHistory(opts)
+			return runHistory(cmd.Context(), opts)
 		},
 	}
 
-	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 20, "number of sessions to show")
-	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", "", "filter by mode (timer, words, quote)")
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 20, "number of sessions to show (0 for all)")
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", "", "filter by mode (timer, words, quote, text, practice, code, zen)")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "only show sessions from this date (YYYY-MM-DD) or duration ago (e.g. 7d)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "only show sessions up to this date (YYYY-MM-DD, inclusive) or duration ago")
+	cmd.Flags().Float64Var(&opts.MinWPM, "min-wpm", 0, "only show sessions with at least this WPM")
+	cmd.Flags().Float64Var(&opts.MaxWPM, "max-wpm", 0, "only show sessions with at most this WPM (0 for no limit)")
+	cmd.Flags().Float64Var(&opts.MinAccuracy, "min-accuracy", 0, "only show sessions with at least this accuracy (percent)")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "print sessions as JSON")
 
 	return cmd
 }
 
-func runHistory(opts *Options) error {
-	store, err := sqlite.Open()
+func runHistory(ctx context.Context, opts *Options) error {
+	since, until, err := daterange.Parse(opts.Since, opts.Until, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if opts.MinWPM < 0 || opts.MaxWPM < 0 || opts.MinAccuracy < 0 {
+		return fmt.Errorf("--min-wpm, --max-wpm, and --min-accuracy must not be negative")
+	}
+	if opts.MaxWPM > 0 && opts.MinWPM > opts.MaxWPM {
+		return fmt.Errorf("--min-wpm (%.1f) is above --max-wpm (%.1f)", opts.MinWPM, opts.MaxWPM)
+	}
+
+	store, err := sqlite.OpenContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer store.Close()
 
-	sessions, err := store.ListSessions(opts.Limit, opts.Mode)
+	sessions, err := store.ListSessionsContext(ctx, sqlite.SessionFilter{
+		Limit:       opts.Limit,
+		Mode:        opts.Mode,
+		Since:       since,
+		Until:       until,
+		MinWPM:      opts.MinWPM,
+		MaxWPM:      opts.MaxWPM,
+		MinAccuracy: opts.MinAccuracy,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to list sessions: %w", err)
 	}
 
+	if opts.JSON {
+		out := make([]report.Session, len(sessions))
+		for i := range sessions {
+			out[i] = report.FromStored(&sessions[i])
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
 	if len(sessions) == 0 {
 		fmt.Println("\n  No typing tests recorded yet.")
 		if opts.Mode != "" {
 			fmt.Printf("  (filtered by mode: %s)\n", opts.Mode)
 		}
+		if period := daterange.Describe(since, until); period != "" {
+			fmt.Printf("  (filtered by date: %s)\n", period)
+		}
+		if opts.MinWPM > 0 || opts.MaxWPM > 0 || opts.MinAccuracy > 0 {
+			fmt.Println("  (filtered by WPM or accuracy)")
+		}
 		fmt.Println("  Run 'mtcli test' to start your first test!")
 		fmt.Println()
 		return nil
@@ -64,9 +130,19 @@ func runHistory(opts *Options) error {
 	fmt.Println("  ╚══════════════════════════════════════════════════════════════════════╝")
 	fmt.Println()
 
+	// WPM curves for the trend column, fetched in one go
+	ids := make([]int64, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	samples, err := store.GetSamplesForSessions(ids)
+	if err != nil {
+		return fmt.Errorf("failed to get samples: %w", err)
+	}
+
 	// Table header
-	fmt.Println("  ID    Date                 Mode    WPM     Raw     Acc      Time")
-	fmt.Println("  ────────────────────────────────────────────────────────────────────────")
+	fmt.Println("  ID    Date              Mode     WPM     Raw     Acc    Time    Trend       Flags")
+	fmt.Println("  ──────────────────────────────────────────────────────────────────────────────")
 
 	for _, session := range sessions {
 		// Format date
@@ -78,7 +154,7 @@ func runHistory(opts *Options) error {
 		// Format duration
 		durationStr := formatDuration(time.Duration(session.DurationMs) * time.Millisecond)
 
-		fmt.Printf("  %-5d %s  %s  %5.1f   %5.1f   %5.1f%%  %s\n",
+		line := fmt.Sprintf("  %-5d %s  %s  %5.1f   %5.1f   %5.1f%%  %-6s  %s  %s",
 			session.ID,
 			dateStr,
 			modeStr,
@@ -86,7 +162,10 @@ func runHistory(opts *Options) error {
 			session.RawWPM,
 			session.Accuracy,
 			durationStr,
+			trend(samples[session.ID]),
+			sessionFlags(session),
 		)
+		fmt.Println(strings.TrimRight(line, " "))
 	}
 
 	fmt.Println()
@@ -116,3 +195,42 @@ func padRight(s string, width int) string {
 	}
 	return s + strings.Repeat(" ", width-len(s))
 }
+
+const (
+	trendWidth      = 10 // width of the trend sparkline column
+	trendMinSamples = 3  // fewest samples worth drawing a curve for
+)
+
+// trend returns a sparkline of a session's WPM over time, padded to
+// trendWidth, or blanks when there are too few samples to show a curve
+func trend(samples []sqlite.SessionSample) string {
+	if len(samples) < trendMinSamples {
+		return strings.Repeat(" ", trendWidth)
+	}
+	points := make([]charts.DataPoint, len(samples))
+	for i, s := range samples {
+		points[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.WPM}
+	}
+	spark := charts.SparklineFromSamples(points, trendWidth)
+	return spark + strings.Repeat(" ", trendWidth-utf8.RuneCountInString(spark))
+}
+
+// sessionFlags lists a session's correction mode, goal outcome, and
+// whether it drilled missed words
+func sessionFlags(s sqlite.Session) string {
+	var flags []string
+	if s.MistakeDrill {
+		flags = append(flags, "drill")
+	}
+	if s.Correction != "" {
+		flags = append(flags, s.Correction)
+	}
+	if s.TargetWPM > 0 {
+		if s.WPM >= s.TargetWPM {
+			flags = append(flags, "goal met")
+		} else {
+			flags = append(flags, "goal missed")
+		}
+	}
+	return strings.Join(flags, ", ")
+}
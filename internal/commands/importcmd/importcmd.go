@@ -0,0 +1,91 @@
+package importcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mmdbasi/mtcli/internal/report"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+func NewImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import sessions exported from mtcli as JSON",
+		Long: `Import typing test sessions from a file written by
+'mtcli export --format json', such as one from another machine.
+
+Sessions keep their original start time. A session with the same start
+time, mode, and target text hash as one already recorded is skipped, so
+importing the same file twice is harmless. The whole file is checked before anything
+is saved; a malformed file imports nothing.
+
+Exports don't carry the target text or per-key statistics, so imported
+sessions can't be repeated or replayed and don't count towards 'mtcli keys'.
+
+Examples:
+  mtcli export --format json --with-samples -o laptop.json   # on one machine
+  mtcli import laptop.json                                   # on another
+  cat laptop.json | mtcli import -`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd.Context(), args[0])
+		},
+	}
+
+	return cmd
+}
+
+func runImport(ctx context.Context, path string) error {
+	sessions, err := readSessions(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	var imported, skipped int
+	for _, session := range sessions {
+		stored, samples := session.ToStored()
+		_, saved, err := store.SaveImportedSessionContext(ctx, stored, samples)
+		if err != nil {
+			return fmt.Errorf("failed to import session %d: %w", session.ID, err)
+		}
+		if saved {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+
+	fmt.Printf("  Imported: %d\n", imported)
+	fmt.Printf("  Skipped:  %d (already recorded)\n", skipped)
+	return nil
+}
+
+// readSessions reads and validates an exported session array from path,
+// or from stdin if path is "-"
+func readSessions(path string) ([]report.Session, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	sessions, err := report.ReadSessions(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return sessions, nil
+}
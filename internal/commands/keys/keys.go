@@ -0,0 +1,156 @@
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the keys command options
+type Options struct {
+	Top         int
+	MinAttempts int
+	FoldCase    bool
+}
+
+func NewKeysCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Show the characters you mistype most",
+		Long: `Display per-character error rates aggregated across all your tests.
+
+Characters are ranked by error rate. Characters with only a few attempts
+are skipped so that a single slip doesn't dominate the list.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runKeys(opts)
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Top, "top", "n", 10, "number of characters to show")
+	cmd.Flags().IntVar(&opts.MinAttempts, "min-attempts", 10, "ignore characters typed fewer times than this")
+	cmd.Flags().BoolVarP(&opts.FoldCase, "fold-case", "i", false, "merge upper and lower case")
+
+	return cmd
+}
+
+func runKeys(opts *Options) error {
+	store, err := sqlite.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	stats, err := store.GetKeyStats()
+	if err != nil {
+		return fmt.Errorf("failed to get key stats: %w", err)
+	}
+
+	if opts.FoldCase {
+		stats = foldCase(stats)
+	}
+	stats = rankKeys(stats, opts.MinAttempts)
+
+	if len(stats) == 0 {
+		fmt.Println("\n  Not enough typing data yet.")
+		fmt.Println("  Run 'mtcli test' a few more times to find your problem keys!")
+		fmt.Println()
+		return nil
+	}
+
+	if opts.Top > 0 && len(stats) > opts.Top {
+		stats = stats[:opts.Top]
+	}
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Println("  ║          PROBLEM KEYS                ║")
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	fmt.Println("  Key      Attempts  Errors  Error rate")
+	fmt.Println("  ────────────────────────────────────────")
+	for _, stat := range stats {
+		fmt.Printf("  %-7s  %8d  %6d  %9.1f%%\n",
+			keyLabel(stat.Key),
+			stat.Attempts,
+			stat.Errors,
+			errorRate(stat),
+		)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// foldCase merges the stats of upper and lower case variants of a letter
+func foldCase(stats []sqlite.KeyStat) []sqlite.KeyStat {
+	merged := make(map[string]sqlite.KeyStat)
+	var order []string
+	for _, stat := range stats {
+		key := strings.ToLower(stat.Key)
+		m, ok := merged[key]
+		if !ok {
+			order = append(order, key)
+		}
+		m.Key = key
+		m.Attempts += stat.Attempts
+		m.Errors += stat.Errors
+		merged[key] = m
+	}
+
+	result := make([]sqlite.KeyStat, len(order))
+	for i, key := range order {
+		result[i] = merged[key]
+	}
+	return result
+}
+
+// rankKeys drops rarely typed characters and sorts the rest by error rate
+func rankKeys(stats []sqlite.KeyStat, minAttempts int) []sqlite.KeyStat {
+	var ranked []sqlite.KeyStat
+	for _, stat := range stats {
+		if stat.Attempts >= minAttempts && stat.Errors > 0 {
+			ranked = append(ranked, stat)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		ri, rj := errorRate(ranked[i]), errorRate(ranked[j])
+		if ri != rj {
+			return ri > rj
+		}
+		return ranked[i].Errors > ranked[j].Errors
+	})
+	return ranked
+}
+
+func errorRate(stat sqlite.KeyStat) float64 {
+	if stat.Attempts == 0 {
+		return 0
+	}
+	return float64(stat.Errors) / float64(stat.Attempts) * 100
+}
+
+// keyLabel returns a printable name for a character
+func keyLabel(key string) string {
+	r, _ := utf8.DecodeRuneInString(key)
+	switch {
+	case r == ' ':
+		return "space"
+	case r == '\n':
+		return "enter"
+	case r == '\t':
+		return "tab"
+	case !unicode.IsPrint(r):
+		return fmt.Sprintf("%U", r)
+	}
+	return key
+}
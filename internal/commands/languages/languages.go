@@ -0,0 +1,62 @@
+package languages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/assets"
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/text"
+	"github.com/spf13/cobra"
+)
+
+func NewLanguagesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "languages",
+		Short: "List the built-in word lists",
+		Long: `List the word lists available to timer, words, and practice modes.
+
+Pick one with 'mtcli test --language <name>' or set 'language' in the config
+file. A --words-file (or words_file in the config) takes precedence.
+
+Examples:
+  mtcli languages
+  mtcli test --language german`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLanguages()
+		},
+	}
+}
+
+func runLanguages() error {
+	cfg := config.Get()
+	current := cfg.Language
+	if current == "" {
+		current = assets.DefaultLanguage
+	}
+
+	fmt.Println()
+	fmt.Printf("    %-12s %-7s %s\n", "Language", "Words", "Sample")
+	fmt.Println("  ────────────────────────────────────────────────────────────────")
+	for _, name := range assets.Languages() {
+		wl, err := text.NewWordList("", name, 1)
+		if err != nil {
+			return fmt.Errorf("failed to load %s words: %w", name, err)
+		}
+
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+		fmt.Printf("  %s %-12s %-7d %s\n", marker, name, wl.Count(), strings.Join(wl.GetRandomWords(6), " "))
+	}
+	fmt.Println()
+	fmt.Printf("  * current language\n")
+	if cfg.WordsFile != "" {
+		fmt.Printf("  words_file is set (%s), which overrides the language\n", cfg.WordsFile)
+	}
+	fmt.Println()
+
+	return nil
+}
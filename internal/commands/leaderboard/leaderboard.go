@@ -0,0 +1,119 @@
+package leaderboard
+
+import (
+	"fmt"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the leaderboard command options
+type Options struct {
+	Top         int
+	Mode        string
+	MinAccuracy float64
+	By          string
+}
+
+func NewLeaderboardCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "leaderboard",
+		Short: "Rank your best test sessions",
+		Long: `Display your all-time best typing tests, ranked by WPM.
+
+Use --min-accuracy so fast runs full of typos don't dominate, and --by to
+rank by accuracy or consistency instead.
+
+Examples:
+  mtcli leaderboard
+  mtcli leaderboard --mode timer --min-accuracy 95
+  mtcli leaderboard --by consistency -n 5`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLeaderboard(opts)
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Top, "top", "n", 10, "number of sessions to show")
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", "", "only rank this mode (timer, words, quote, text, practice, code, zen)")
+	cmd.Flags().Float64Var(&opts.MinAccuracy, "min-accuracy", 0, "ignore sessions below this accuracy (percent)")
+	cmd.Flags().StringVar(&opts.By, "by", sqlite.RankByWPM, "rank by: wpm, accuracy, or consistency")
+
+	return cmd
+}
+
+func runLeaderboard(opts *Options) error {
+	if opts.Top <= 0 {
+		return fmt.Errorf("top must be positive: %d", opts.Top)
+	}
+	switch opts.By {
+	case sqlite.RankByWPM, sqlite.RankByAccuracy, sqlite.RankByConsistency:
+	default:
+		return fmt.Errorf("unknown rank key: %s (use wpm, accuracy, or consistency)", opts.By)
+	}
+
+	store, err := sqlite.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.TopSessions(sqlite.SessionFilter{
+		Limit:       opts.Top,
+		Mode:        opts.Mode,
+		MinAccuracy: opts.MinAccuracy,
+	}, opts.By)
+	if err != nil {
+		return fmt.Errorf("failed to rank sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("\n  No typing tests to rank yet.")
+		if opts.Mode != "" || opts.MinAccuracy > 0 {
+			fmt.Println("  (try a different --mode or a lower --min-accuracy)")
+		}
+		fmt.Println("  Run 'mtcli test' to start your first test!")
+		fmt.Println()
+		return nil
+	}
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════════════════════════════╗")
+	fmt.Println("  ║                        LEADERBOARD                           ║")
+	fmt.Println("  ╚══════════════════════════════════════════════════════════════╝")
+	fmt.Println()
+
+	fmt.Println("  Rank  WPM     Acc      Cons   Mode      Date              ID")
+	fmt.Println("  ──────────────────────────────────────────────────────────────")
+	for i, s := range sessions {
+		consistency := "N/A"
+		if s.Consistency > 0 {
+			consistency = fmt.Sprintf("%.0f%%", s.Consistency)
+		}
+		fmt.Printf("  %-5d %-7.1f %-8s %-6s %-9s %-17s %d\n",
+			i+1,
+			s.WPM,
+			fmt.Sprintf("%.1f%%", s.Accuracy),
+			consistency,
+			s.Mode,
+			s.StartedAt.Format("2006-01-02 15:04"),
+			s.ID,
+		)
+	}
+	fmt.Println()
+	fmt.Printf("  Top %d by %s", len(sessions), opts.By)
+	if opts.Mode != "" {
+		fmt.Printf(" (mode: %s)", opts.Mode)
+	}
+	if opts.MinAccuracy > 0 {
+		fmt.Printf(" with at least %.0f%% accuracy", opts.MinAccuracy)
+	}
+	fmt.Println()
+	fmt.Println("  Use 'mtcli show <id>' to see details of a specific test.")
+	fmt.Println()
+
+	return nil
+}
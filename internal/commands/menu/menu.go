@@ -0,0 +1,258 @@
+package menu
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/commands/history"
+	"github.com/mmdbasi/mtcli/internal/commands/stats"
+	testcmd "github.com/mmdbasi/mtcli/internal/commands/test"
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/input"
+	"github.com/mmdbasi/mtcli/internal/test"
+	"github.com/mmdbasi/mtcli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// item is one choice in a menu
+type item struct {
+	label string
+	value string
+}
+
+// menu is a list of choices, one of them selected
+type menu struct {
+	title    string
+	items    []item
+	selected int
+	top      bool // Esc quits rather than going back
+}
+
+var mainMenu = []item{
+	{label: "Start test", value: "test"},
+	{label: "View stats", value: "stats"},
+	{label: "History", value: "history"},
+	{label: "Quit", value: "quit"},
+}
+
+// Modes that start without a file or other setup
+var modeMenu = []item{
+	{label: "timer     type until time runs out", value: string(test.ModeTimer)},
+	{label: "words     type a fixed number of words", value: string(test.ModeWords)},
+	{label: "quote     type a random quote", value: string(test.ModeQuote)},
+	{label: "practice  drill your most-missed keys", value: string(test.ModePractice)},
+	{label: "zen       type until you press Esc", value: string(test.ModeZen)},
+}
+
+var (
+	secondsChoices = []int{15, 30, 60, 120}
+	wordsChoices   = []int{10, 25, 50, 100}
+)
+
+// Run shows the launcher until the user quits. Each choice runs the same
+// command as typing it would, then comes back to the menu.
+func Run(ctx context.Context, noColor bool) error {
+	reader := input.NewRawReader()
+	top := &menu{title: "mtcli", items: mainMenu, top: true}
+
+	for {
+		choice, ok, err := pick(ctx, reader, top, noColor)
+		if err != nil {
+			return err
+		}
+		if !ok || choice == "quit" {
+			return nil
+		}
+
+		switch choice {
+		case "test":
+			args, ok, err := testArgs(ctx, reader, noColor)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			// The test waits on its own summary, so there's nothing to pause for
+			if err := run(ctx, testcmd.NewTestCmd(), args...); err != nil {
+				if err := pause(ctx, reader, err); err != nil {
+					return err
+				}
+			}
+		case "stats":
+			if err := pause(ctx, reader, run(ctx, stats.NewStatsCmd())); err != nil {
+				return err
+			}
+		case "history":
+			if err := pause(ctx, reader, run(ctx, history.NewHistoryCmd())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// testArgs asks for a mode, and a duration for the modes that take one,
+// and returns the flags for 'mtcli test'. It reports false if the user
+// backed out.
+func testArgs(ctx context.Context, reader *input.RawReader, noColor bool) ([]string, bool, error) {
+	cfg := config.Get()
+	modes := &menu{title: "Mode", items: modeMenu}
+	modes.selectValue(cfg.Mode)
+
+	for {
+		mode, ok, err := pick(ctx, reader, modes, noColor)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		args := []string{"--mode", mode}
+		if noColor {
+			args = append(args, "--no-color")
+		}
+
+		var amount *menu
+		var flag string
+		switch modeCfg := cfg.ForMode(mode); test.Mode(mode) {
+		case test.ModeTimer:
+			amount, flag = countMenu("Seconds", secondsChoices, modeCfg.Seconds, "s"), "--seconds"
+		case test.ModeWords:
+			amount, flag = countMenu("Words", wordsChoices, modeCfg.Words, " words"), "--words"
+		default:
+			return args, true, nil
+		}
+
+		n, ok, err := pick(ctx, reader, amount, noColor)
+		if err != nil {
+			return nil, false, err
+		}
+		// Backing out of the duration goes back to the modes
+		if ok {
+			return append(args, flag, n), true, nil
+		}
+	}
+}
+
+// countMenu lists choices for a number, adding the configured default if it
+// isn't already one of them and selecting it
+func countMenu(title string, choices []int, current int, suffix string) *menu {
+	if current > 0 && !slices.Contains(choices, current) {
+		choices = append(slices.Clone(choices), current)
+		slices.Sort(choices)
+	}
+
+	m := &menu{title: title}
+	for _, n := range choices {
+		m.items = append(m.items, item{label: strconv.Itoa(n) + suffix, value: strconv.Itoa(n)})
+	}
+	m.selectValue(strconv.Itoa(current))
+	return m
+}
+
+// selectValue selects the item with value, if there is one
+func (m *menu) selectValue(value string) {
+	if i := slices.IndexFunc(m.items, func(it item) bool { return it.value == value }); i >= 0 {
+		m.selected = i
+	}
+}
+
+// pick shows m until an item is chosen with Enter, returning its value. It
+// reports false if the user pressed Esc or Ctrl+C instead.
+func pick(ctx context.Context, reader *input.RawReader, m *menu, noColor bool) (string, bool, error) {
+	if err := reader.Init(); err != nil {
+		return "", false, fmt.Errorf("failed to initialize input: %w", err)
+	}
+	defer func() {
+		ui.ClearScreen()
+		ui.MoveHome()
+		ui.ShowCursor()
+		reader.Cleanup()
+	}()
+	ui.HideCursor()
+
+	for {
+		ui.ClearScreen()
+		ui.MoveHome()
+		fmt.Print(m.render(noColor))
+
+		key, err := reader.ReadKeyContext(ctx)
+		if err != nil {
+			return "", false, fmt.Errorf("input error: %w", err)
+		}
+		switch {
+		case key.Type == input.KeyArrowUp || key.Type == input.KeyRune && key.Rune == 'k':
+			m.selected = (m.selected + len(m.items) - 1) % len(m.items)
+		case key.Type == input.KeyArrowDown || key.Type == input.KeyRune && key.Rune == 'j':
+			m.selected = (m.selected + 1) % len(m.items)
+		case key.Type == input.KeyHome:
+			m.selected = 0
+		case key.Type == input.KeyEnd:
+			m.selected = len(m.items) - 1
+		case key.Type == input.KeyEnter:
+			return m.items[m.selected].value, true, nil
+		case key.Type == input.KeyEscape || key.Type == input.KeyCtrlC || key.Type == input.KeyRune && key.Rune == 'q':
+			return "", false, nil
+		}
+	}
+}
+
+// render draws the whole menu; raw mode needs \r\n to start each line
+func (m *menu) render(noColor bool) string {
+	var buf strings.Builder
+	buf.WriteString("\r\n")
+
+	title := "  " + m.title
+	if !noColor {
+		title = ui.CyanString(title)
+	}
+	buf.WriteString(title + "\r\n\r\n")
+
+	for i, it := range m.items {
+		line := "    " + it.label
+		if i == m.selected {
+			line = "  > " + it.label
+			if !noColor {
+				line = ui.GreenString(line)
+			}
+		}
+		buf.WriteString(line + "\r\n")
+	}
+
+	help := "  ↑/↓ to move, Enter to select, Esc to go back"
+	if m.top {
+		help = "  ↑/↓ to move, Enter to select, Esc to quit"
+	}
+	if !noColor {
+		help = ui.GrayString(help)
+	}
+	buf.WriteString("\r\n" + help)
+	return buf.String()
+}
+
+// run executes a fresh copy of a subcommand with args, as if it had been
+// typed after 'mtcli'
+func run(ctx context.Context, cmd *cobra.Command, args ...string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs(args)
+	return cmd.ExecuteContext(ctx)
+}
+
+// pause shows err, if any, and waits for a key so the output above can be
+// read before the menu clears it
+func pause(ctx context.Context, reader *input.RawReader, err error) error {
+	if err != nil {
+		fmt.Printf("\nError: %v\n", err)
+	}
+	fmt.Print("\n  Press any key to return to the menu")
+
+	if err := reader.Init(); err != nil {
+		return fmt.Errorf("failed to initialize input: %w", err)
+	}
+	defer reader.Cleanup()
+	if _, err := reader.ReadKeyContext(ctx); err != nil {
+		return fmt.Errorf("input error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package quotes
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/text"
+	"github.com/spf13/cobra"
+)
+
+// ListOptions holds the quotes list command options
+type ListOptions struct {
+	QuotesFile string
+}
+
+// ShowOptions holds the quotes show command options
+type ShowOptions struct {
+	QuotesFile string
+}
+
+// SearchOptions holds the quotes search command options
+type SearchOptions struct {
+	QuotesFile string
+}
+
+// AddOptions holds the quotes add command options
+type AddOptions struct {
+	QuotesFile string
+	Source     string
+}
+
+func NewQuotesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quotes",
+		Short: "Browse and add quotes",
+		Long: `Browse the quotes used by quote mode and add your own.
+
+Examples:
+  mtcli quotes list                                   # All quote IDs and sources
+  mtcli quotes show 5                                 # Full text of quote 5
+  mtcli quotes search "the only way"                  # Quotes containing a phrase
+  mtcli quotes add "Simplicity is prerequisite for reliability." --source "Edsger Dijkstra"`,
+	}
+
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newShowCmd())
+	cmd.AddCommand(newSearchCmd())
+	cmd.AddCommand(newAddCmd())
+
+	return cmd
+}
+
+func newListCmd() *cobra.Command {
+	opts := &ListOptions{}
+	cfg := config.Get()
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List quote IDs with their sources",
+		Long: `List every quote with its ID, source, and the start of its text.
+
+Use the ID with 'mtcli test --mode quote --quote-id <id>' or part of the
+source with '--quote-source'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.QuotesFile, "quotes-file", cfg.QuotesFile, "custom quotes file")
+
+	return cmd
+}
+
+func runList(opts *ListOptions) error {
+	ql, err := text.NewQuoteList(opts.QuotesFile, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load quotes: %w", err)
+	}
+
+	quotes := ql.All()
+	if len(quotes) == 0 {
+		fmt.Println()
+		fmt.Println("  No quotes available.")
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-6s %-24s %s\n", "ID", "Source", "Text")
+	fmt.Println("  ────────────────────────────────────────────────────────────────────────")
+	for _, q := range quotes {
+		fmt.Printf("  %-6s %-24s %s\n", q.ID, truncate(q.Source, 24), truncate(q.Text, 40))
+	}
+	fmt.Println()
+	fmt.Printf("  %d quotes\n", len(quotes))
+	fmt.Println()
+
+	return nil
+}
+
+// truncate shortens s to at most width runes, ending in "…" when cut
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func newShowCmd() *cobra.Command {
+	opts := &ShowOptions{}
+	cfg := config.Get()
+
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the full text of a quote",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShow(args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.QuotesFile, "quotes-file", cfg.QuotesFile, "custom quotes file")
+
+	return cmd
+}
+
+func runShow(id string, opts *ShowOptions) error {
+	ql, err := text.NewQuoteList(opts.QuotesFile, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load quotes: %w", err)
+	}
+
+	quote, err := ql.GetQuoteByID(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("  Quote #%s\n", quote.ID)
+	fmt.Println("  ────────────────────────────────────────")
+	fmt.Println()
+	fmt.Printf("  %s\n", quote.Text)
+	fmt.Println()
+	if quote.Source != "" {
+		fmt.Printf("  — %s\n", quote.Source)
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func newSearchCmd() *cobra.Command {
+	opts := &SearchOptions{}
+	cfg := config.Get()
+
+	cmd := &cobra.Command{
+		Use:   "search <term>",
+		Short: "Find quotes by their text or source",
+		Long: `List the quotes whose text or source contains the term, ignoring case.
+Several words are matched as a phrase, so quoting them is optional.
+
+Use an ID found here with 'mtcli test --mode quote --quote-id <id>'.
+
+Examples:
+  mtcli quotes search imagination
+  mtcli quotes search "the only way to"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSearch(strings.Join(args, " "), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.QuotesFile, "quotes-file", cfg.QuotesFile, "custom quotes file")
+
+	return cmd
+}
+
+func runSearch(term string, opts *SearchOptions) error {
+	if strings.TrimSpace(term) == "" {
+		return fmt.Errorf("search term must not be empty")
+	}
+
+	ql, err := text.NewQuoteList(opts.QuotesFile, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load quotes: %w", err)
+	}
+
+	matches := ql.Search(term)
+	if len(matches) == 0 {
+		fmt.Println()
+		fmt.Printf("  No quotes match %q.\n", term)
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-6s %-24s %s\n", "ID", "Source", "Text")
+	fmt.Println("  ────────────────────────────────────────────────────────────────────────")
+	for _, q := range matches {
+		fmt.Printf("  %-6s %-24s %s\n", q.ID, truncate(q.Source, 24), snippet(q.Text, term, 40))
+	}
+	fmt.Println()
+	fmt.Printf("  %d of %d quotes\n", len(matches), ql.Count())
+	fmt.Println()
+
+	return nil
+}
+
+// snippet returns at most width runes of s, starting a little before the
+// first match of term so the match shows even deep into a long quote
+func snippet(s, term string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	// Lower-casing can change byte lengths, so find the match by runes
+	lower := []rune(strings.ToLower(s))
+	start := 0
+	if i := strings.Index(string(lower), strings.ToLower(strings.Join(strings.Fields(term), " "))); i > 0 {
+		start = len([]rune(string(lower)[:i]))
+	}
+
+	// Keep some context before the match, but don't cut past the end
+	start = max(min(start-10, len(runes)-width+1), 0)
+	if start == 0 {
+		return truncate(s, width)
+	}
+	return "…" + truncate(string(runes[start:]), width-1)
+}
+
+func newAddCmd() *cobra.Command {
+	opts := &AddOptions{}
+	cfg := config.Get()
+
+	cmd := &cobra.Command{
+		Use:   "add <text>",
+		Short: "Add a quote to your custom quotes file",
+		Long: `Append a quote to your custom quotes file, giving it the next free ID.
+
+The file is the one set with --quotes-file or quotes_file in the config, or
+quotes.json in the config directory if neither is set. The file is created
+if it does not exist yet.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdd(strings.Join(args, " "), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.QuotesFile, "quotes-file", cfg.QuotesFile, "custom quotes file to add to")
+	cmd.Flags().StringVarP(&opts.Source, "source", "s", "", "author or source of the quote")
+
+	return cmd
+}
+
+func runAdd(quoteText string, opts *AddOptions) error {
+	path := opts.QuotesFile
+	if path == "" {
+		configDir, err := config.GetConfigDir()
+		if err != nil {
+			return fmt.Errorf("failed to get config directory: %w", err)
+		}
+		path = filepath.Join(configDir, "quotes.json")
+	}
+
+	quote, err := text.AddQuote(path, quoteText, opts.Source)
+	if err != nil {
+		return fmt.Errorf("failed to add quote: %w", err)
+	}
+
+	fmt.Printf("Added quote #%s to %s\n", quote.ID, path)
+	if opts.QuotesFile == "" {
+		fmt.Printf("Set quotes_file = %q in your config (or pass --quotes-file) to type your quotes.\n", path)
+	}
+	return nil
+}
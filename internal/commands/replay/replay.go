@@ -0,0 +1,358 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/input"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/test"
+	"github.com/mmdbasi/mtcli/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Options holds the replay command options
+type Options struct {
+	Speed   float64
+	NoColor bool
+}
+
+func NewReplayCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "replay <session_id>",
+		Short: "Play back a past test session",
+		Long: `Re-animate a past typing test session in the terminal.
+
+Keystroke timings are not stored, so the text is revealed at the pace
+recorded by the session's speed samples. Mistakes are shown where they were
+left in the final text.
+
+Controls:
+  Space      pause or resume
+  q, Esc     stop
+
+Examples:
+  mtcli replay 42
+  mtcli replay 42 --speed 2`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+			return runReplay(cmd.Context(), args[0], opts)
+		},
+	}
+
+	cmd.Flags().Float64Var(&opts.Speed, "speed", 1, "playback speed multiplier")
+
+	return cmd
+}
+
+func runReplay(ctx context.Context, sessionIDStr string, opts *Options) error {
+	if opts.Speed <= 0 {
+		return fmt.Errorf("speed must be positive: %g", opts.Speed)
+	}
+	colorMode, err := ui.ParseColorMode(config.Get().ColorMode)
+	if err != nil {
+		return err
+	}
+	theme, err := ui.ParseTheme(config.Get().Theme, colorMode)
+	if err != nil {
+		return err
+	}
+	caretStyle, err := ui.ParseCaretStyle(config.Get().CaretStyle)
+	if err != nil {
+		return err
+	}
+	if config.Get().NoColor || colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+
+	sessionID, err := strconv.ParseInt(sessionIDStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid session ID: %s", sessionIDStr)
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	session, err := store.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return fmt.Errorf("session %d not found", sessionID)
+	}
+	if session.TargetText == "" {
+		return fmt.Errorf("session %d has no stored text to replay", sessionID)
+	}
+
+	samples, err := store.GetSamplesContext(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get samples: %w", err)
+	}
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("replay needs a terminal")
+	}
+
+	p := newPlayback(session, samples)
+
+	reader := input.NewRawReader()
+	renderer := ui.NewANSIRenderer(ui.RendererOptions{
+		NoColor:        opts.NoColor,
+		Caret:          caretStyle,
+		Theme:          theme,
+		ShowAccuracy:   config.Get().ShowAccuracy,
+		ShowRaw:        config.Get().ShowRaw,
+		ShowWhitespace: config.Get().ShowWhitespace,
+		Output:         os.Stdout,
+	})
+
+	if err := reader.Init(); err != nil {
+		return fmt.Errorf("failed to initialize input: %w", err)
+	}
+	if err := renderer.Init(); err != nil {
+		reader.Cleanup()
+		return fmt.Errorf("failed to initialize renderer: %w", err)
+	}
+	defer func() {
+		renderer.Cleanup()
+		reader.Cleanup()
+	}()
+
+	hint := "Space to pause, q to quit"
+	if opts.Speed != 1 {
+		hint = fmt.Sprintf("%gx | %s", opts.Speed, hint)
+	}
+
+	// Returning stops the reader before the terminal is restored
+	keyChan := make(chan input.KeyEvent)
+	errChan := make(chan error)
+	readCtx, stopReading := context.WithCancel(ctx)
+	readDone := make(chan struct{})
+	defer func() {
+		stopReading()
+		<-readDone
+	}()
+	go func() {
+		defer close(readDone)
+		for {
+			key, err := reader.ReadKeyContext(readCtx)
+			if readCtx.Err() != nil {
+				return
+			}
+			if err != nil {
+				select {
+				case errChan <- err:
+				case <-readCtx.Done():
+				}
+				return
+			}
+			select {
+			case keyChan <- key:
+			case <-readCtx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	// elapsed is the position in the recording, advanced by wall-clock
+	// time scaled by the speed while not paused
+	var elapsed time.Duration
+	last := time.Now()
+	paused := false
+
+	renderer.Render(p.state(elapsed, paused, hint))
+	for elapsed < p.duration {
+		select {
+		case key := <-keyChan:
+			switch {
+			case key.Type == input.KeyCtrlC, key.Type == input.KeyEscape,
+				key.Type == input.KeyRune && (key.Rune == 'q' || key.Rune == 'Q'):
+				return nil
+			case paused:
+				// Any other key resumes, as in the live test
+				paused = false
+				last = time.Now()
+			case key.Type == input.KeyRune && key.Rune == ' ':
+				paused = true
+			}
+			renderer.Render(p.state(elapsed, paused, hint))
+
+		case now := <-ticker.C:
+			if !paused {
+				elapsed += time.Duration(float64(now.Sub(last)) * opts.Speed)
+				if elapsed > p.duration {
+					elapsed = p.duration
+				}
+			}
+			last = now
+			renderer.Render(p.state(elapsed, paused, hint))
+
+		case err := <-errChan:
+			return fmt.Errorf("input error: %w", err)
+		}
+	}
+
+	// Hold the finished text briefly before clearing the screen
+	time.Sleep(time.Second)
+	return nil
+}
+
+// playback maps a position in a recorded session to what was on screen
+type playback struct {
+	session  *sqlite.Session
+	samples  []sqlite.SessionSample
+	target   []rune
+	typed    []rune           // final typed text, as long as states
+	states   []test.CharState // final state of each typed character
+	duration time.Duration
+
+	// typedAt[i] is the number of keystrokes made by samples[i], recovered
+	// from its raw WPM
+	typedAt []float64
+}
+
+func newPlayback(session *sqlite.Session, samples []sqlite.SessionSample) *playback {
+	target := []rune(session.TargetText)
+	typed := []rune(session.TypedText)
+
+	// Without the typed text, reveal as much of the target as was typed
+	// and show it all as correct
+	var states []test.CharState
+	if len(typed) > 0 {
+		states = make([]test.CharState, min(len(typed), len(target)))
+		for i := range states {
+			if typed[i] == target[i] {
+				states[i] = test.CharCorrect
+			} else {
+				states[i] = test.CharIncorrect
+			}
+		}
+		typed = typed[:len(states)]
+	} else {
+		states = make([]test.CharState, min(session.CorrectChars+session.IncorrectChars, len(target)))
+		for i := range states {
+			states[i] = test.CharCorrect
+		}
+		typed = target[:len(states)]
+	}
+
+	typedAt := make([]float64, len(samples))
+	for i, s := range samples {
+		typedAt[i] = s.RawWPM * 5 * float64(s.TimeMs) / 60000
+	}
+
+	return &playback{
+		session:  session,
+		samples:  samples,
+		target:   target,
+		typed:    typed,
+		states:   states,
+		duration: time.Duration(session.DurationMs) * time.Millisecond,
+		typedAt:  typedAt,
+	}
+}
+
+// revealed returns how many characters of the final text were on screen at
+// elapsed. Keystrokes between samples are assumed evenly spaced; with no
+// samples at all the whole text is spread evenly over the duration.
+func (p *playback) revealed(elapsed time.Duration) int {
+	if elapsed >= p.duration || p.duration <= 0 {
+		return len(p.states)
+	}
+
+	ms := float64(elapsed.Milliseconds())
+	total := float64(p.session.TotalTyped)
+
+	// Walk the samples, ending with the session total at its duration
+	prevMs, prevTyped := 0.0, 0.0
+	keystrokes := total * ms / float64(p.session.DurationMs)
+	for i := 0; i <= len(p.samples); i++ {
+		atMs, atTyped := float64(p.session.DurationMs), total
+		if i < len(p.samples) {
+			atMs, atTyped = float64(p.samples[i].TimeMs), p.typedAt[i]
+		}
+		if ms <= atMs {
+			if atMs > prevMs {
+				keystrokes = prevTyped + (atTyped-prevTyped)*(ms-prevMs)/(atMs-prevMs)
+			}
+			break
+		}
+		prevMs, prevTyped = atMs, atTyped
+	}
+
+	// Keystrokes include characters later deleted; scale to the final text
+	if total <= 0 {
+		return 0
+	}
+	n := int(keystrokes / total * float64(len(p.states)))
+	return max(0, min(n, len(p.states)))
+}
+
+// sampleAt returns the most recent sample at or before elapsed
+func (p *playback) sampleAt(elapsed time.Duration) (sqlite.SessionSample, bool) {
+	var found sqlite.SessionSample
+	ok := false
+	for _, s := range p.samples {
+		if s.TimeMs > elapsed.Milliseconds() {
+			break
+		}
+		found, ok = s, true
+	}
+	return found, ok
+}
+
+// state builds the frame for a position in the recording
+func (p *playback) state(elapsed time.Duration, paused bool, hint string) *ui.RenderState {
+	n := p.revealed(elapsed)
+
+	charStates := make([]test.CharState, len(p.target))
+	copy(charStates, p.states[:n])
+
+	correct := 0
+	for _, s := range p.states[:n] {
+		if s == test.CharCorrect {
+			correct++
+		}
+	}
+	accuracy := 100.0
+	if n > 0 {
+		accuracy = float64(correct) / float64(n) * 100
+	}
+
+	var wpm, raw float64
+	if elapsed >= p.duration {
+		wpm, raw = p.session.WPM, p.session.RawWPM
+	} else if s, ok := p.sampleAt(elapsed); ok {
+		wpm, raw = s.WPM, s.RawWPM
+	}
+
+	return &ui.RenderState{
+		Target:       p.target,
+		Typed:        p.typed[:n],
+		CharStates:   charStates,
+		Caret:        n,
+		Mode:         test.Mode(p.session.Mode),
+		Elapsed:      elapsed.Seconds(),
+		LiveWPM:      wpm,
+		LiveRawWPM:   raw,
+		LiveAccuracy: accuracy,
+		TimeLimit:    p.session.Seconds,
+		Finished:     elapsed >= p.duration,
+		Paused:       paused,
+		Hint:         hint,
+	}
+}
@@ -0,0 +1,145 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/report"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+)
+
+// Store is the part of *sqlite.Store the server reads from, so a fake can
+// stand in for the database in tests
+type Store interface {
+	GetStatsContext(ctx context.Context, since, until time.Time) (*sqlite.Stats, error)
+	ListSessionsContext(ctx context.Context, filter sqlite.SessionFilter) ([]sqlite.Session, error)
+	GetSessionContext(ctx context.Context, id int64) (*sqlite.Session, error)
+	GetSamplesContext(ctx context.Context, sessionID int64) ([]sqlite.SessionSample, error)
+}
+
+// defaultHistoryLimit matches 'mtcli history'
+const defaultHistoryLimit = 20
+
+// NewHandler returns the read-only JSON API over store. With cors set every
+// response allows requests from any origin.
+func NewHandler(store Store, cors bool) http.Handler {
+	h := &handler{store: store}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stats", h.stats)
+	mux.HandleFunc("GET /history", h.history)
+	mux.HandleFunc("GET /session/{id}", h.session)
+	mux.HandleFunc("GET /session/{id}/samples", h.samples)
+	mux.HandleFunc("GET /metrics", h.metrics)
+
+	if !cors {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET")
+		mux.ServeHTTP(w, r)
+	})
+}
+
+type handler struct {
+	store Store
+}
+
+func (h *handler) stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.store.GetStatsContext(r.Context(), time.Time{}, time.Time{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get stats: %w", err))
+		return
+	}
+	writeJSON(w, report.FromStats(stats))
+}
+
+func (h *handler) history(w http.ResponseWriter, r *http.Request) {
+	filter := sqlite.SessionFilter{
+		Limit: defaultHistoryLimit,
+		Mode:  r.URL.Query().Get("mode"),
+	}
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", s))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	sessions, err := h.store.ListSessionsContext(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list sessions: %w", err))
+		return
+	}
+
+	out := make([]report.Session, len(sessions))
+	for i := range sessions {
+		out[i] = report.FromStored(&sessions[i])
+	}
+	writeJSON(w, out)
+}
+
+func (h *handler) session(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, report.FromStored(session))
+}
+
+func (h *handler) samples(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	samples, err := h.store.GetSamplesContext(r.Context(), session.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get samples: %w", err))
+		return
+	}
+	writeJSON(w, report.SamplesFromStored(samples))
+}
+
+// lookup fetches the session named by the {id} path segment, writing the
+// error response itself when there is none
+func (h *handler) lookup(w http.ResponseWriter, r *http.Request) (*sqlite.Session, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid session ID: %s", r.PathValue("id")))
+		return nil, false
+	}
+
+	session, err := h.store.GetSessionContext(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get session: %w", err))
+		return nil, false
+	}
+	if session == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("session %d not found", id))
+		return nil, false
+	}
+	return session, true
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError writes err as a JSON object with an "error" field
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,70 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+)
+
+// metrics serves the stats in the Prometheus text exposition format
+func (h *handler) metrics(w http.ResponseWriter, r *http.Request) {
+	all, err := h.store.GetStatsContext(r.Context(), time.Time{}, time.Time{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get stats: %w", err))
+		return
+	}
+	day, err := h.store.GetStatsContext(r.Context(), time.Now().Add(-24*time.Hour), time.Time{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get stats: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w, all, day.TotalTests)
+}
+
+// writeMetrics writes stats, plus the number of tests in the last 24
+// hours, as Prometheus metrics. Per-mode metrics carry a mode label and
+// come in a stable order.
+func writeMetrics(w io.Writer, stats *sqlite.Stats, lastDay int) {
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+	}
+	metric("mtcli_tests_total", "counter", "Typing tests recorded.", float64(stats.TotalTests))
+	metric("mtcli_typing_seconds_total", "counter", "Time spent typing in tests.", float64(stats.TotalTimeMs)/1000)
+	metric("mtcli_tests_last_24h", "gauge", "Typing tests started in the last 24 hours.", float64(lastDay))
+	metric("mtcli_wpm_average", "gauge", "Average WPM over all tests.", stats.AverageWPM)
+	metric("mtcli_wpm_best", "gauge", "Best final WPM of any test.", stats.BestWPM)
+	metric("mtcli_wpm_peak_best", "gauge", "Best sampled WPM of any test.", stats.BestPeakWPM)
+	metric("mtcli_wpm_average_7d", "gauge", "Average WPM over the last 7 days.", stats.Last7DaysAvgWPM)
+	metric("mtcli_wpm_average_30d", "gauge", "Average WPM over the last 30 days.", stats.Last30DaysAvgWPM)
+	metric("mtcli_accuracy_average_percent", "gauge", "Average accuracy over all tests.", stats.AverageAccuracy)
+	metric("mtcli_accuracy_best_percent", "gauge", "Best accuracy of any test.", stats.BestAccuracy)
+	metric("mtcli_consistency_average_percent", "gauge", "Average consistency of tests long enough to score.", stats.AverageConsistency)
+
+	modes := make([]string, 0, len(stats.ModeStats))
+	for mode := range stats.ModeStats {
+		modes = append(modes, mode)
+	}
+	slices.Sort(modes)
+
+	perMode := func(name, kind, help string, value func(sqlite.ModeStats) float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, mode := range modes {
+			fmt.Fprintf(w, "%s{mode=\"%s\"} %g\n", name, escapeLabel(mode), value(stats.ModeStats[mode]))
+		}
+	}
+	perMode("mtcli_mode_tests_total", "counter", "Typing tests recorded per mode.", func(m sqlite.ModeStats) float64 { return float64(m.TestCount) })
+	perMode("mtcli_mode_wpm_average", "gauge", "Average WPM per mode.", func(m sqlite.ModeStats) float64 { return m.AverageWPM })
+	perMode("mtcli_mode_wpm_best", "gauge", "Best final WPM per mode.", func(m sqlite.ModeStats) float64 { return m.BestWPM })
+}
+
+// escapeLabel escapes a label value for the exposition format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
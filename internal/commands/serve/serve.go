@@ -0,0 +1,101 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the serve command options
+type Options struct {
+	Addr string
+	CORS bool
+}
+
+// shutdownTimeout bounds how long requests in flight get to finish
+const shutdownTimeout = 5 * time.Second
+
+func NewServeCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve your stats and history as JSON over HTTP",
+		Long: `Start a read-only HTTP server for dashboards and scripts.
+
+Endpoints (all GET, JSON in the export format unless noted):
+  /stats                    aggregate statistics, like 'mtcli stats --json'
+  /history?limit=N&mode=M   recent sessions, newest first (limit defaults to 20, 0 for all)
+  /session/{id}             one session
+  /session/{id}/samples     its speed samples
+  /metrics                  the stats in the Prometheus text format, for scraping
+
+Nothing can be changed through the server. It listens on localhost unless
+--addr names another interface. Press Ctrl+C to stop it.
+
+Examples:
+  mtcli serve
+  mtcli serve --addr :8080 --cors`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", "localhost:8080", "address to listen on")
+	cmd.Flags().BoolVar(&opts.CORS, "cors", false, "allow requests from pages on any origin")
+
+	return cmd
+}
+
+func runServe(ctx context.Context, opts *Options) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           NewHandler(store, opts.CORS),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(listener)
+	}()
+	fmt.Printf("  Serving on http://%s (Ctrl+C to stop)\n", listener.Addr())
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	fmt.Println("  Stopped.")
+	return nil
+}
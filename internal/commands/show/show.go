@@ -1,16 +1,34 @@
 package show
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mmdbasi/mtcli/internal/charts"
+	"github.com/mmdbasi/mtcli/internal/config"
 	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/test"
+	"github.com/mmdbasi/mtcli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+// Options holds the show command options
+type Options struct {
+	ChartStyle  string
+	ChartSmooth int
+	ChartErrors bool
+	Text        bool
+	Width       int
+	NoColor     bool
+}
+
 func NewShowCmd() *cobra.Command {
+	opts := &Options{}
+	cfg := config.Get()
+
 	cmd := &cobra.Command{
 		Use:   "show <session_id>",
 		Short: "Show details of a specific test session",
@@ -19,29 +37,68 @@ func NewShowCmd() *cobra.Command {
 Shows:
   - Full summary (WPM, raw WPM, accuracy, time)
   - Speed chart over the duration of the test
-  - Mode and settings used`,
+  - Mode and settings used
+  - With --text, the text you typed with mistakes highlighted`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runShow(args[0])
+			opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+			return runShow(cmd.Context(), args[0], opts)
 		},
 	}
 
+	cmd.Flags().StringVar(&opts.ChartStyle, "chart-style", cfg.ChartStyle, "chart style: block or braille (braille falls back to block with --no-color)")
+	cmd.Flags().BoolVar(&opts.ChartErrors, "chart-errors", false, "add the error rate over time to the speed chart")
+	cmd.Flags().BoolVar(&opts.Text, "text", false, "show the typed text with mistakes highlighted")
+	cmd.Flags().IntVar(&opts.ChartSmooth, "chart-smooth", 0, "smooth the speed chart with a moving average over N samples (0 for none)")
+	cmd.Flags().IntVar(&opts.Width, "width", 0, "output width in columns (0 for the terminal width)")
+
 	return cmd
 }
 
-func runShow(sessionIDStr string) error {
+func runShow(ctx context.Context, sessionIDStr string, opts *Options) error {
+	chartStyle, err := charts.ParseStyle(opts.ChartStyle)
+	if err != nil {
+		return err
+	}
+	colorMode, err := ui.ParseColorMode(config.Get().ColorMode)
+	if err != nil {
+		return err
+	}
+	theme, err := ui.ParseTheme(config.Get().Theme, colorMode)
+	if err != nil {
+		return err
+	}
+	if config.Get().NoColor || colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+	if opts.NoColor {
+		chartStyle = charts.StyleBlock
+	}
+	if opts.Width < 0 {
+		return fmt.Errorf("width must not be negative: %d", opts.Width)
+	}
+
+	// The chart and text fill the terminal, less the margins, up to a
+	// comfortable reading width. Output that isn't a terminal gets 80
+	// columns, so pipes and files are reproducible even without --width.
+	width := opts.Width
+	if width == 0 {
+		width, _, _ = ui.GetTerminalSize()
+	}
+	contentWidth := max(min(width-4, 80), 20)
+
 	sessionID, err := strconv.ParseInt(sessionIDStr, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid session ID: %s", sessionIDStr)
 	}
 
-	store, err := sqlite.Open()
+	store, err := sqlite.OpenContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer store.Close()
 
-	session, err := store.GetSession(sessionID)
+	session, err := store.GetSessionContext(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get session: %w", err)
 	}
@@ -50,7 +107,7 @@ func runShow(sessionIDStr string) error {
 		return fmt.Errorf("session %d not found", sessionID)
 	}
 
-	samples, err := store.GetSamples(sessionID)
+	samples, err := store.GetSamplesContext(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get samples: %w", err)
 	}
@@ -78,6 +135,25 @@ func runShow(sessionIDStr string) error {
 			fmt.Printf("  Quote ID:   %s\n", session.QuoteID)
 		}
 	}
+	if session.Source != "" {
+		fmt.Printf("  Source:     %s\n", session.Source)
+	}
+	if session.Seed != 0 {
+		fmt.Printf("  Seed:       %d\n", session.Seed)
+	}
+	if session.Difficulty != "" {
+		fmt.Printf("  Difficulty: %s\n", session.Difficulty)
+	}
+	if session.Correction != "" {
+		fmt.Printf("  Correction: %s\n", session.Correction)
+	}
+	if session.TargetWPM > 0 {
+		if session.WPM >= session.TargetWPM {
+			fmt.Printf("  Goal:       %.0f WPM (met)\n", session.TargetWPM)
+		} else {
+			fmt.Printf("  Goal:       %.0f WPM (missed by %.1f)\n", session.TargetWPM, session.TargetWPM-session.WPM)
+		}
+	}
 	fmt.Println()
 
 	// Results
@@ -86,10 +162,40 @@ func runShow(sessionIDStr string) error {
 	fmt.Printf("  WPM:        %.1f\n", session.WPM)
 	fmt.Printf("  Raw WPM:    %.1f\n", session.RawWPM)
 	fmt.Printf("  Accuracy:   %.1f%%\n", session.Accuracy)
+	if session.FirstTryAccuracy > 0 {
+		fmt.Printf("  Clean acc:  %.1f%% (first try, corrections not forgiven)\n", session.FirstTryAccuracy)
+	}
+	if session.Consistency > 0 {
+		fmt.Printf("  Consistency: %.0f%%\n", session.Consistency)
+	} else {
+		fmt.Println("  Consistency: N/A")
+	}
+	if session.PeakWPM > 0 {
+		fmt.Printf("  Peak WPM:   %.1f\n", session.PeakWPM)
+		fmt.Printf("  Median WPM: %.1f\n", session.MedianWPM)
+	}
 	fmt.Printf("  Time:       %s\n", formatDuration(time.Duration(session.DurationMs)*time.Millisecond))
+	if session.TimeToFirstKeyMs > 0 {
+		fmt.Printf("  Reaction:   %.2fs to first key\n", float64(session.TimeToFirstKeyMs)/1000)
+	}
 	fmt.Printf("  Characters: %d/%d correct\n", session.CorrectChars, session.TotalTyped)
+	fmt.Printf("  Incorrect:  %d\n", session.IncorrectChars)
+	fmt.Printf("  Corrections: %d\n", session.Corrections)
 	fmt.Println()
 
+	if opts.Text {
+		fmt.Println("  Text")
+		fmt.Println("  ────────────────────────────────────────")
+		if session.TypedText == "" {
+			fmt.Println("  (not recorded for this session)")
+		} else {
+			for _, line := range typedLines(session.TargetText, session.TypedText, contentWidth, theme, opts.NoColor) {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+		fmt.Println()
+	}
+
 	// Speed chart
 	if len(samples) > 0 {
 		fmt.Println("  Speed over time")
@@ -98,15 +204,27 @@ func runShow(sessionIDStr string) error {
 
 		wpmPoints := make([]charts.DataPoint, len(samples))
 		rawPoints := make([]charts.DataPoint, len(samples))
+		errPoints := make([]charts.DataPoint, len(samples))
 		for i, s := range samples {
 			wpmPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.WPM}
 			rawPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.RawWPM}
+			rate := test.Sample{TimeMs: s.TimeMs, RawWPM: s.RawWPM, Errors: s.Errors}.ErrorRate()
+			errPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: rate}
 		}
 
 		chartOpts := charts.DefaultOptions()
-		chartOpts.Width = 60
+		chartOpts.Smooth = opts.ChartSmooth
+		chartOpts.Width = contentWidth
 		chartOpts.Height = 10
-		chart := charts.RenderDualChart(wpmPoints, rawPoints, chartOpts)
+		if !opts.NoColor {
+			ui.ChartColors(&chartOpts, theme)
+		}
+		var chart string
+		if opts.ChartErrors {
+			chart = charts.RenderTripleChartStyle(wpmPoints, rawPoints, errPoints, chartOpts, chartStyle)
+		} else {
+			chart = charts.RenderDualChartStyle(wpmPoints, rawPoints, chartOpts, chartStyle)
+		}
 
 		// Indent each line
 		for _, line := range splitLines(chart) {
@@ -119,6 +237,75 @@ func runShow(sessionIDStr string) error {
 	return nil
 }
 
+// typedLines renders the typed part of target word-wrapped to width, with
+// mistyped characters in the incorrect color. Without colors, a line of
+// carets under each wrapped line marks the mistakes instead.
+func typedLines(target, typed string, width int, theme ui.Theme, noColor bool) []string {
+	targetRunes := []rune(target)
+	typedRunes := []rune(typed)
+	n := min(len(targetRunes), len(typedRunes))
+
+	var lines []string
+	for start := 0; start < n; {
+		end := min(start+width, n)
+		// Break after the last space that fits, unless the word fills the line
+		if end < n {
+			if i := lastSpace(targetRunes[start:end]); i > 0 {
+				end = start + i + 1
+			}
+		}
+
+		var text, marks strings.Builder
+		hasMistake := false
+		for i := start; i < end; i++ {
+			ch := targetRunes[i]
+			wrong := typedRunes[i] != ch
+			switch ch {
+			case '\n':
+				ch = '⏎'
+			case '\t':
+				ch = '→'
+			}
+			if wrong && ch == ' ' {
+				ch = '·'
+			}
+
+			switch {
+			case noColor:
+				text.WriteRune(ch)
+			case wrong:
+				text.WriteString(ui.ColoredString(string(ch), theme.Incorrect))
+			default:
+				text.WriteString(ui.ColoredString(string(ch), theme.Correct))
+			}
+			if wrong {
+				marks.WriteRune('^')
+				hasMistake = true
+			} else {
+				marks.WriteRune(' ')
+			}
+		}
+
+		lines = append(lines, text.String())
+		if noColor && hasMistake {
+			lines = append(lines, strings.TrimRight(marks.String(), " "))
+		}
+		start = end
+	}
+	return lines
+}
+
+// lastSpace returns the index of the last space or line break in runes,
+// or -1 if there is none
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == ' ' || runes[i] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%.1fs", d.Seconds())
@@ -1,14 +1,38 @@
 package stats
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/mmdbasi/mtcli/internal/charts"
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/daterange"
+	"github.com/mmdbasi/mtcli/internal/report"
 	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+// Options holds the stats command options
+type Options struct {
+	JSON      bool
+	Histogram bool
+	ByHour    bool
+	Trend     int
+	Bin       int
+	Mode      string
+	Since     string
+	Until     string
+	NoColor   bool
+}
+
 func NewStatsCmd() *cobra.Command {
+	opts := &Options{}
+
 	cmd := &cobra.Command{
 		Use:   "stats",
 		Short: "Show your typing statistics",
@@ -19,31 +43,81 @@ Shows:
   - Average WPM and best WPM
   - Average accuracy
   - Recent trends (last 7/30 days)
-  - Breakdown by mode`,
+  - Breakdown by mode
+
+With --histogram, shows how your final WPM is distributed across all
+sessions instead. With --by-hour, shows your average WPM for each hour of
+the day you started tests in, marking your best and worst hours. With
+--trend N, follows your last N tests in order, drawing your WPM and its
+rolling average and fitting a line to show how fast you are improving.
+
+--since and --until restrict either view to a date range. They accept a
+date (YYYY-MM-DD) or a duration before now such as 7d or 2w. --mode
+restricts it to one test mode.
+
+Examples:
+  mtcli stats
+  mtcli stats --since 30d
+  mtcli stats --mode timer
+  mtcli stats --histogram
+  mtcli stats --histogram --bin 5
+  mtcli stats --by-hour --since 30d
+  mtcli stats --trend 50 --mode words`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runStats()
+			opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+			if opts.Histogram {
+				return runHistogram(cmd.Context(), opts)
+			}
+			if opts.ByHour {
+				return runByHour(cmd.Context(), opts)
+			}
+			if cmd.Flags().Changed("trend") {
+				return runTrend(cmd.Context(), opts)
+			}
+			return runStats(cmd.Context(), opts)
 		},
 	}
 
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "print statistics as JSON")
+	cmd.Flags().BoolVar(&opts.Histogram, "histogram", false, "show the distribution of final WPM across sessions")
+	cmd.Flags().IntVar(&opts.Bin, "bin", 10, "histogram bin width in WPM")
+	cmd.Flags().BoolVar(&opts.ByHour, "by-hour", false, "show average WPM by the hour of day tests started")
+	cmd.Flags().IntVar(&opts.Trend, "trend", 0, "show the WPM trend over your last N tests")
+	cmd.MarkFlagsMutuallyExclusive("histogram", "by-hour", "trend")
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", "", "only include this mode (timer, words, quote, text, practice, code, zen)")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "only include sessions from this date (YYYY-MM-DD) or duration ago (e.g. 7d)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "only include sessions up to this date (YYYY-MM-DD, inclusive) or duration ago")
+
 	return cmd
 }
 
-func runStats() error {
-	store, err := sqlite.Open()
+func runStats(ctx context.Context, opts *Options) error {
+	since, until, err := daterange.Parse(opts.Since, opts.Until, time.Now())
+	if err != nil {
+		return err
+	}
+
+	store, err := sqlite.OpenContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer store.Close()
 
-	stats, err := store.GetStats()
+	stats, err := store.GetStatsForModeContext(ctx, opts.Mode, since, until)
 	if err != nil {
 		return fmt.Errorf("failed to get stats: %w", err)
 	}
 
+	if opts.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report.FromStats(stats))
+	}
+
+	period := daterange.Describe(since, until)
+
 	if stats.TotalTests == 0 {
-		fmt.Println("\n  No typing tests recorded yet.")
-		fmt.Println("  Run 'mtcli test' to start your first test!")
-		fmt.Println()
+		printNoTests(opts.Mode, period)
 		return nil
 	}
 
@@ -54,6 +128,16 @@ func runStats() error {
 	fmt.Println("  ╚══════════════════════════════════════╝")
 	fmt.Println()
 
+	if opts.Mode != "" {
+		fmt.Printf("  Mode:   %s\n", opts.Mode)
+	}
+	if period != "" {
+		fmt.Printf("  Period: %s\n", period)
+	}
+	if opts.Mode != "" || period != "" {
+		fmt.Println()
+	}
+
 	// Overall stats
 	fmt.Println("  Overall")
 	fmt.Println("  ────────────────────────────────────────")
@@ -61,7 +145,14 @@ func runStats() error {
 	fmt.Printf("  Total Time:       %s\n", formatDuration(time.Duration(stats.TotalTimeMs)*time.Millisecond))
 	fmt.Printf("  Average WPM:      %.1f\n", stats.AverageWPM)
 	fmt.Printf("  Best WPM:         %.1f\n", stats.BestWPM)
+	if stats.BestPeakWPM > 0 {
+		fmt.Printf("  Best Peak WPM:    %.1f\n", stats.BestPeakWPM)
+	}
 	fmt.Printf("  Average Accuracy: %.1f%%\n", stats.AverageAccuracy)
+	fmt.Printf("  Best Accuracy:    %.1f%%\n", stats.BestAccuracy)
+	if stats.AverageConsistency > 0 {
+		fmt.Printf("  Avg Consistency:  %.0f%%\n", stats.AverageConsistency)
+	}
 	fmt.Println()
 
 	// Recent trends
@@ -98,6 +189,255 @@ func runStats() error {
 	return nil
 }
 
+func runHistogram(ctx context.Context, opts *Options) error {
+	if opts.JSON {
+		return fmt.Errorf("--histogram cannot be combined with --json")
+	}
+	if opts.Bin <= 0 {
+		return fmt.Errorf("bin width must be positive: %d", opts.Bin)
+	}
+	sessions, period, err := loadSessions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	chartOpts, _, err := barChartOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		printNoTests(opts.Mode, period)
+		return nil
+	}
+
+	values := make([]float64, len(sessions))
+	for i, s := range sessions {
+		values[i] = s.WPM
+	}
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Println("  ║         WPM DISTRIBUTION             ║")
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	if opts.Mode != "" {
+		fmt.Printf("  Mode:   %s\n", opts.Mode)
+	}
+	if period != "" {
+		fmt.Printf("  Period: %s\n", period)
+	}
+	fmt.Printf("  %d sessions, %d WPM bins\n", len(sessions), opts.Bin)
+	fmt.Println("  ────────────────────────────────────────")
+
+	width := float64(opts.Bin)
+	printChart(charts.RenderHistogram(charts.Bucket(values, width), width, chartOpts))
+
+	return nil
+}
+
+// hourStats is the activity in one hour of the day
+type hourStats struct {
+	tests int
+	wpm   float64 // average
+}
+
+func runByHour(ctx context.Context, opts *Options) error {
+	if opts.JSON {
+		return fmt.Errorf("--by-hour cannot be combined with --json")
+	}
+	sessions, period, err := loadSessions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	chartOpts, theme, err := barChartOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		printNoTests(opts.Mode, period)
+		return nil
+	}
+
+	hours := byHour(sessions)
+
+	// Best and worst only mean something with two hours to compare
+	best, worst := -1, -1
+	for h, stats := range hours {
+		if stats.tests == 0 {
+			continue
+		}
+		if best < 0 || stats.wpm > hours[best].wpm {
+			best = h
+		}
+		if worst < 0 || stats.wpm < hours[worst].wpm {
+			worst = h
+		}
+	}
+	if best == worst {
+		best, worst = -1, -1
+	}
+
+	// Rows run from the first to the last hour with tests, so a gap in
+	// the middle of the day shows as one
+	first, last := 0, 23
+	for hours[first].tests == 0 {
+		first++
+	}
+	for hours[last].tests == 0 {
+		last--
+	}
+
+	var bars []charts.Bar
+	for h := first; h <= last; h++ {
+		bar := charts.Bar{Label: fmt.Sprintf("%02d:00", h)}
+		// Other hours stay muted so the best and worst stand out
+		if !opts.NoColor {
+			bar.Color = theme.Info
+		}
+		switch {
+		case hours[h].tests == 0:
+			bar.Empty = true
+			bar.Text = "-"
+		default:
+			bar.Value = hours[h].wpm
+			bar.Text = fmt.Sprintf("%.1f WPM (%s)", hours[h].wpm, formatTests(hours[h].tests))
+		}
+		switch h {
+		case best:
+			bar.Text += "  best"
+			if !opts.NoColor {
+				bar.Color = theme.Success
+			}
+		case worst:
+			bar.Text += "  worst"
+			if !opts.NoColor {
+				bar.Color = theme.Incorrect
+			}
+		}
+		bars = append(bars, bar)
+	}
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Println("  ║        WPM BY HOUR OF DAY            ║")
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	if opts.Mode != "" {
+		fmt.Printf("  Mode:   %s\n", opts.Mode)
+	}
+	if period != "" {
+		fmt.Printf("  Period: %s\n", period)
+	}
+	fmt.Printf("  %d sessions, by local start time\n", len(sessions))
+	fmt.Println("  ────────────────────────────────────────")
+
+	printChart(charts.RenderBars(bars, chartOpts))
+
+	return nil
+}
+
+// byHour groups sessions by the local hour they started in
+func byHour(sessions []sqlite.Session) [24]hourStats {
+	var hours [24]hourStats
+	var sums [24]float64
+	for _, s := range sessions {
+		h := s.StartedAt.Local().Hour()
+		hours[h].tests++
+		sums[h] += s.WPM
+	}
+	for h := range hours {
+		if hours[h].tests > 0 {
+			hours[h].wpm = sums[h] / float64(hours[h].tests)
+		}
+	}
+	return hours
+}
+
+func formatTests(n int) string {
+	if n == 1 {
+		return "1 test"
+	}
+	return fmt.Sprintf("%d tests", n)
+}
+
+// loadSessions returns the sessions matching the mode and date filters,
+// and the period they describe
+func loadSessions(ctx context.Context, opts *Options) ([]sqlite.Session, string, error) {
+	since, until, err := daterange.Parse(opts.Since, opts.Until, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.ListSessionsContext(ctx, sqlite.SessionFilter{
+		Mode:  opts.Mode,
+		Since: since,
+		Until: until,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, daterange.Describe(since, until), nil
+}
+
+// barChartOptions returns the options for the bar charts, colored with the
+// configured theme unless colors are off
+func barChartOptions(opts *Options) (charts.ChartOptions, ui.Theme, error) {
+	chartOpts := charts.DefaultOptions()
+	chartOpts.Width = 40
+
+	colorMode, err := ui.ParseColorMode(config.Get().ColorMode)
+	if err != nil {
+		return chartOpts, ui.Theme{}, err
+	}
+	theme, err := ui.ParseTheme(config.Get().Theme, colorMode)
+	if err != nil {
+		return chartOpts, ui.Theme{}, err
+	}
+	if config.Get().NoColor || colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+	if !opts.NoColor {
+		ui.ChartColors(&chartOpts, theme)
+	}
+	return chartOpts, theme, nil
+}
+
+// printChart prints an indented chart followed by a blank line
+func printChart(chart string) {
+	for _, line := range strings.Split(strings.TrimRight(chart, "\n"), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+}
+
+// printNoTests explains an empty report, naming the filters that emptied it
+func printNoTests(mode, period string) {
+	switch {
+	case mode != "" && period != "":
+		fmt.Printf("\n  No %s tests in this period (%s).\n", mode, period)
+	case mode != "":
+		fmt.Printf("\n  No %s tests recorded yet.\n", mode)
+		fmt.Printf("  Run 'mtcli test --mode %s' to start one!\n", mode)
+	case period != "":
+		fmt.Printf("\n  No typing tests in this period (%s).\n", period)
+	default:
+		fmt.Println("\n  No typing tests recorded yet.")
+		fmt.Println("  Run 'mtcli test' to start your first test!")
+	}
+	fmt.Println()
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%.0fs", d.Seconds())
@@ -0,0 +1,150 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/charts"
+	"github.com/mmdbasi/mtcli/internal/daterange"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+)
+
+const (
+	trendMinTests = 5  // fewest tests a trend is reported for
+	trendWindow   = 10 // tests in the rolling average
+	trendWidth    = 50 // widest sparkline; longer trends are sampled down
+)
+
+func runTrend(ctx context.Context, opts *Options) error {
+	if opts.JSON {
+		return fmt.Errorf("--trend cannot be combined with --json")
+	}
+	if opts.Trend < trendMinTests {
+		return fmt.Errorf("--trend needs at least %d tests: %d", trendMinTests, opts.Trend)
+	}
+	since, until, err := daterange.Parse(opts.Since, opts.Until, time.Now())
+	if err != nil {
+		return err
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.ListLatestSessionsContext(ctx, sqlite.SessionFilter{
+		Limit: opts.Trend,
+		Mode:  opts.Mode,
+		Since: since,
+		Until: until,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	period := daterange.Describe(since, until)
+	if len(sessions) == 0 {
+		printNoTests(opts.Mode, period)
+		return nil
+	}
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Println("  ║            WPM TREND                 ║")
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	if opts.Mode != "" {
+		fmt.Printf("  Mode:   %s\n", opts.Mode)
+	}
+	if period != "" {
+		fmt.Printf("  Period: %s\n", period)
+	}
+
+	if len(sessions) < trendMinTests {
+		fmt.Printf("  Not enough data: %d tests found, a trend needs at least %d.\n", len(sessions), trendMinTests)
+		fmt.Println()
+		return nil
+	}
+
+	values := make([]float64, len(sessions))
+	for i, s := range sessions {
+		values[i] = s.WPM
+	}
+	rolling := rollingAverage(values, trendWindow)
+
+	fmt.Printf("  Last %d tests, %s\n", len(sessions), describeWindow(len(sessions)))
+	fmt.Println("  ────────────────────────────────────────")
+	fmt.Printf("  Per test:  %s\n", sparkline(values))
+	fmt.Printf("  Rolling:   %s\n", sparkline(rolling))
+	fmt.Println()
+	ends := min(trendWindow, len(values)/2)
+	fmt.Printf("  Average:   %.1f → %.1f WPM (first and last %d tests)\n", mean(values[:ends]), mean(values[len(values)-ends:]), ends)
+	fmt.Printf("  Slope:     %+.2f WPM per test\n", slope(values))
+	fmt.Println()
+
+	return nil
+}
+
+// describeWindow names the rolling average used for n tests
+func describeWindow(n int) string {
+	if n <= trendWindow {
+		return "running average"
+	}
+	return fmt.Sprintf("%d-test rolling average", trendWindow)
+}
+
+// rollingAverage replaces each value with the average of it and the
+// window-1 values before it. The first values average what came before
+// them, so the result has one value per input.
+func rollingAverage(values []float64, window int) []float64 {
+	out := make([]float64, len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= window {
+			sum -= values[i-window]
+		}
+		out[i] = sum / float64(min(i+1, window))
+	}
+	return out
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// slope returns the slope of the least-squares line through values, taking
+// each value's index as x: the average change in value from one to the next
+func slope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range values {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
+
+// sparkline draws values left to right, one test per cell up to trendWidth
+func sparkline(values []float64) string {
+	points := make([]charts.DataPoint, len(values))
+	for i, v := range values {
+		points[i] = charts.DataPoint{TimeMs: int64(i), Value: v}
+	}
+	return charts.SparklineFromSamples(points, min(len(values), trendWidth))
+}
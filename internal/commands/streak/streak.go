@@ -0,0 +1,190 @@
+package streak
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/activity"
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// Options holds the streak command options
+type Options struct {
+	Weeks   int
+	NoColor bool
+}
+
+func NewStreakCmd() *cobra.Command {
+	opts := &Options{}
+
+	cmd := &cobra.Command{
+		Use:   "streak",
+		Short: "Show your daily practice activity and streaks",
+		Long: `Display tests per day as a calendar grid, one column per week, along with
+your current and longest streak of consecutive days with at least one test.
+
+Days are counted in your local time zone.
+
+Examples:
+  mtcli streak
+  mtcli streak --weeks 52`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+			return runStreak(opts)
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Weeks, "weeks", "w", 16, "number of weeks to show")
+
+	return cmd
+}
+
+func runStreak(opts *Options) error {
+	if opts.Weeks <= 0 {
+		return fmt.Errorf("weeks must be positive: %d", opts.Weeks)
+	}
+	colorMode, err := ui.ParseColorMode(config.Get().ColorMode)
+	if err != nil {
+		return err
+	}
+	theme, err := ui.ParseTheme(config.Get().Theme, colorMode)
+	if err != nil {
+		return err
+	}
+	if config.Get().NoColor || colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+
+	store, err := sqlite.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	counts, err := store.GetDailyCounts()
+	if err != nil {
+		return fmt.Errorf("failed to get daily counts: %w", err)
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("\n  No typing tests recorded yet.")
+		fmt.Println("  Run 'mtcli test' to start your first test!")
+		fmt.Println()
+		return nil
+	}
+
+	today := activity.Today(time.Now())
+
+	// Header
+	fmt.Println()
+	fmt.Println("  ╔══════════════════════════════════════╗")
+	fmt.Println("  ║          DAILY ACTIVITY              ║")
+	fmt.Println("  ╚══════════════════════════════════════╝")
+	fmt.Println()
+
+	paint := func(s, color string) string {
+		if opts.NoColor {
+			return s
+		}
+		return ui.ColoredString(s, color)
+	}
+
+	for _, line := range renderGrid(counts, today, opts.Weeks, theme, paint) {
+		if line == "" {
+			fmt.Println()
+			continue
+		}
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+
+	fmt.Printf("  Current streak:   %s\n", formatDays(activity.CurrentStreak(counts, today, 1)))
+	fmt.Printf("  Longest streak:   %s\n", formatDays(activity.LongestStreak(counts, 1)))
+	fmt.Printf("  Active days:      %d\n", len(counts))
+	fmt.Println()
+
+	return nil
+}
+
+// renderGrid lays out the last weeks weeks ending with today's week as
+// columns, Sunday to Saturday as rows, with month labels on top
+func renderGrid(counts map[string]int, today time.Time, weeks int, theme ui.Theme, paint func(s, color string) string) []string {
+	const labelWidth = 4 // weekday label column, e.g. "Mon "
+
+	firstSunday := today.AddDate(0, 0, -int(today.Weekday())-7*(weeks-1))
+
+	// Month labels, placed over the first week that starts in a new month
+	// when there is room for them
+	months := []rune(strings.Repeat(" ", labelWidth+2*weeks))
+	next := 0
+	for w := 0; w < weeks; w++ {
+		sunday := firstSunday.AddDate(0, 0, 7*w)
+		if w > 0 && sunday.Month() == sunday.AddDate(0, 0, -7).Month() {
+			continue
+		}
+		pos := labelWidth + 2*w
+		label := sunday.Format("Jan")
+		if pos < next || pos+len(label) > len(months) {
+			continue
+		}
+		copy(months[pos:], []rune(label))
+		next = pos + len(label) + 1
+	}
+	lines := []string{strings.TrimRight(string(months), " ")}
+
+	weekdays := []string{"", "Mon", "", "Wed", "", "Fri", ""}
+	for day := 0; day < 7; day++ {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%-*s", labelWidth, weekdays[day]))
+		for w := 0; w < weeks; w++ {
+			date := firstSunday.AddDate(0, 0, 7*w+day)
+			if date.After(today) {
+				break
+			}
+			sb.WriteString(cell(counts[date.Format(activity.DateFormat)], theme, paint))
+			sb.WriteRune(' ')
+		}
+		lines = append(lines, strings.TrimRight(sb.String(), " "))
+	}
+
+	// Legend
+	var legend strings.Builder
+	legend.WriteString(strings.Repeat(" ", labelWidth))
+	legend.WriteString("Less ")
+	for _, n := range []int{0, 1, 2, 3, 5} {
+		legend.WriteString(cell(n, theme, paint))
+		legend.WriteRune(' ')
+	}
+	legend.WriteString("More")
+	lines = append(lines, "", legend.String())
+
+	return lines
+}
+
+// cell returns the shaded grid cell for a day with n tests
+func cell(n int, theme ui.Theme, paint func(s, color string) string) string {
+	switch {
+	case n == 0:
+		return paint("·", theme.Unattempted)
+	case n == 1:
+		return paint("░", theme.Success)
+	case n == 2:
+		return paint("▒", theme.Success)
+	case n <= 4:
+		return paint("▓", theme.Success)
+	default:
+		return paint("█", theme.Success)
+	}
+}
+
+func formatDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
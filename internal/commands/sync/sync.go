@@ -0,0 +1,273 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/report"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+// snapshotName is the file kept in the sync directory
+const snapshotName = "mtcli-sync.json"
+
+func NewSyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync <dir>",
+		Short: "Merge your history with a snapshot in a shared directory",
+		Long: `Merge your history with other machines through a directory they all
+see, such as a Dropbox or Syncthing folder.
+
+The directory holds one snapshot file, ` + snapshotName + `, in the format of
+'mtcli export --format json --with-samples'. Sync imports the sessions in
+the snapshot that aren't recorded here yet, then rewrites the snapshot with
+every session in this database, so running it on each machine in turn
+leaves them all with the same history.
+
+Sessions are matched by start time, mode, and a hash of the target text,
+like 'mtcli import', so syncing twice adds nothing. Each session is stamped
+when it first reaches the snapshot, and only sessions stamped since this
+machine last synced are imported; keep the machines' clocks set, or a
+session stamped by a clock running behind may be passed over. Per-key
+stats and target texts stay on the machine that recorded them.
+
+Examples:
+  mtcli sync ~/Dropbox/mtcli`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSync(cmd.Context(), args[0])
+		},
+	}
+
+	return cmd
+}
+
+func runSync(ctx context.Context, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open sync directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	path := filepath.Join(dir, snapshotName)
+
+	remote, err := readSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	lastSync, err := lastSyncTime(store)
+	if err != nil {
+		return err
+	}
+
+	// Pull: sessions recorded elsewhere since the last sync. Sessions
+	// without a stamp, such as a hand-copied export, are always checked.
+	var pulled int
+	for _, session := range remote {
+		if !session.SyncedAt.IsZero() && !session.SyncedAt.After(lastSync) {
+			continue
+		}
+		stored, samples := session.ToStored()
+		_, saved, err := store.SaveImportedSessionContext(ctx, stored, samples)
+		if err != nil {
+			return fmt.Errorf("failed to import session from snapshot: %w", err)
+		}
+		if saved {
+			pulled++
+		}
+	}
+
+	// Push: the merged history replaces the snapshot
+	now := time.Now().UTC()
+	local, err := localSessions(ctx, store)
+	if err != nil {
+		return err
+	}
+	merged, pushed := merge(local, remote, now)
+	if err := writeSnapshot(path, merged); err != nil {
+		return err
+	}
+
+	if err := store.SetMeta(sqlite.MetaLastSync, now.Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("failed to record sync time: %w", err)
+	}
+
+	fmt.Printf("  Snapshot:  %s\n", path)
+	fmt.Printf("  Pulled:    %d new session(s)\n", pulled)
+	fmt.Printf("  Pushed:    %d new session(s), %d in total\n", pushed, len(merged))
+	if lastSync.IsZero() {
+		fmt.Println("  Previous:  never")
+	} else {
+		fmt.Printf("  Previous:  %s\n", lastSync.Local().Format("2006-01-02 15:04"))
+	}
+
+	return nil
+}
+
+// lastSyncTime returns when this database last synced, or the zero time
+// if it never has
+func lastSyncTime(store *sqlite.Store) (time.Time, error) {
+	value, ok, err := store.GetMeta(sqlite.MetaLastSync)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read last sync time: %w", err)
+	}
+	if !ok {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid last sync time %q: %w", value, err)
+	}
+	return t, nil
+}
+
+// merge returns the sessions of the new snapshot and how many of them are
+// new to it. Local sessions keep the stamp of their snapshot copy, and
+// those not in the snapshot yet are stamped now. Snapshot sessions this
+// machine doesn't have, because they were skipped as already synced, are
+// kept so other machines still get them.
+func merge(local, remote []report.Session, now time.Time) ([]report.Session, int) {
+	byKey := make(map[sessionKey][]int, len(remote))
+	for i, session := range remote {
+		byKey[keyOf(session)] = append(byKey[keyOf(session)], i)
+	}
+
+	matched := make([]bool, len(remote))
+	var pushed int
+	for i := range local {
+		stamp := time.Time{}
+		if j := find(remote, byKey[keyOf(local[i])], local[i]); j >= 0 {
+			matched[j] = true
+			stamp = remote[j].SyncedAt
+		} else {
+			pushed++
+		}
+		if stamp.IsZero() {
+			stamp = now
+		}
+		local[i].SyncedAt = stamp
+	}
+
+	merged := local
+	for j, session := range remote {
+		if !matched[j] {
+			merged = append(merged, session)
+		}
+	}
+	sort.SliceStable(merged, func(a, b int) bool {
+		return merged[a].StartedAt.Before(merged[b].StartedAt)
+	})
+	return merged, pushed
+}
+
+// sessionKey groups sessions that may be the same run: the start time
+// and mode, with the target hash compared by sameSession
+type sessionKey struct {
+	startedAt int64 // Unix nanoseconds, so time zones don't matter
+	mode      string
+}
+
+func keyOf(s report.Session) sessionKey {
+	return sessionKey{startedAt: s.StartedAt.UnixNano(), mode: s.Mode}
+}
+
+// find returns the index of the first of the candidate remote sessions
+// that is the same run as s, or -1
+func find(remote []report.Session, candidates []int, s report.Session) int {
+	for _, j := range candidates {
+		if sameSession(remote[j], s) {
+			return j
+		}
+	}
+	return -1
+}
+
+// sameSession reports whether a and b, which share a start time and mode,
+// are the same run. As when importing, a session without a target hash
+// matches any hash.
+func sameSession(a, b report.Session) bool {
+	return a.TargetHash == b.TargetHash || a.TargetHash == "" || b.TargetHash == ""
+}
+
+// readSnapshot reads the snapshot at path; a missing snapshot is an empty
+// one, as on the first sync
+func readSnapshot(path string) ([]report.Session, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	sessions, err := report.ReadSessions(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return sessions, nil
+}
+
+// localSessions returns every stored session with its samples, oldest
+// first
+func localSessions(ctx context.Context, store *sqlite.Store) ([]report.Session, error) {
+	sessions, err := store.ListAllSessionsContext(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	ids := make([]int64, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	samples, err := store.GetSamplesForSessionsContext(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get samples: %w", err)
+	}
+
+	out := make([]report.Session, len(sessions))
+	for i := range sessions {
+		out[i] = report.FromStored(&sessions[i])
+		out[i].Samples = report.SamplesFromStored(samples[sessions[i].ID])
+	}
+	return out, nil
+}
+
+// writeSnapshot replaces the snapshot at path. It writes a temporary file
+// and renames it over the old one, so a file syncer never picks up half a
+// snapshot.
+func writeSnapshot(path string, sessions []report.Session) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), snapshotName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sessions); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/report"
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+)
+
+// useTestDB points the database at a fresh file for the test
+func useTestDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mtcli.db")
+	config.SetDBPath(path)
+	t.Cleanup(func() { config.SetDBPath("") })
+	return path
+}
+
+func TestPullFromOtherTimeZone(t *testing.T) {
+	dbPath := useTestDB(t)
+	dir := t.TempDir()
+
+	// Recorded here at 05:00 UTC
+	localStart := time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC).Local()
+	store, err := sqlite.OpenPath(dbPath)
+	if err != nil {
+		t.Fatalf("OpenPath: %v", err)
+	}
+	localID, err := store.SaveSession(&sqlite.Session{StartedAt: localStart, Mode: "words", WPM: 50}, nil, nil)
+	store.Close()
+	if err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+
+	// Recorded in Tokyo at 12:00 local time, 03:00 UTC, so before the
+	// local session
+	tokyo := time.FixedZone("JST", 9*60*60)
+	remoteStart := time.Date(2026, 3, 1, 12, 0, 0, 0, tokyo)
+	remote := []report.Session{{StartedAt: remoteStart, Mode: "words", WPM: 60, Accuracy: 100}}
+	if err := writeSnapshot(filepath.Join(dir, snapshotName), remote); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+
+	if err := runSync(context.Background(), dir); err != nil {
+		t.Fatalf("runSync: %v", err)
+	}
+
+	store, err = sqlite.OpenPath(dbPath)
+	if err != nil {
+		t.Fatalf("OpenPath: %v", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.ListSessions(sqlite.SessionFilter{})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions after the pull, want 2", len(sessions))
+	}
+	if sessions[0].ID != localID || !sessions[1].StartedAt.Equal(remoteStart) {
+		t.Errorf("ListSessions = %+v, want the local session first, then the pulled one", sessions)
+	}
+
+	// Since 04:00 UTC leaves the pulled session out
+	since, err := store.ListSessions(sqlite.SessionFilter{Since: time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(since) != 1 || since[0].ID != localID {
+		t.Errorf("ListSessions since 04:00 UTC = %+v, want only session %d", since, localID)
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"fmt"
+
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// configFlags maps each flag that defaults to a config value to its key
+var configFlags = map[string]string{
+	"mode":            "mode",
+	"seconds":         "seconds",
+	"words":           "words",
+	"countdown":       "countdown",
+	"countdown-style": "countdown_style",
+	"countdown-words": "countdown_words",
+	"sample-interval": "sample_interval_ms",
+	"afk-timeout":     "afk_timeout",
+	"no-color":        "no_color",
+	"wrap":            "wrap",
+	"chart":           "chart",
+	"chart-style":     "chart_style",
+	"caret-style":     "caret_style",
+	"caret-blink":     "caret_blink_ms",
+	"theme":           "theme",
+	"color-mode":      "color_mode",
+	"minimal":         "minimal",
+	"show-accuracy":   "show_accuracy",
+	"show-raw":        "show_raw",
+	"unit":            "unit",
+	"pace-margin":     "pace_margin",
+	"show-whitespace": "show_whitespace",
+	"review-prompt":   "review_prompt",
+	"bell":            "bell",
+	"words-file":      "words_file",
+	"language":        "language",
+	"generator":       "generator",
+	"quotes-file":     "quotes_file",
+	"numbers-density": "numbers_density",
+}
+
+// applyConfig sets the flags of cmd that weren't given to their config
+// values. Flags are declared before the config file is read, so until now
+// they hold the built-in defaults. The [modes.<mode>] section of the mode
+// being run wins over the top-level keys, and a given flag over both.
+func applyConfig(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	cfg := config.Get()
+
+	mode := cfg.Mode
+	if flags.Changed("mode") {
+		mode = flags.Lookup("mode").Value.String()
+	}
+	values := cfg.ForMode(mode).ToMap()
+
+	for name, key := range configFlags {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		// Setting the value directly leaves the flag unchanged for the
+		// checks that tell given flags from defaults
+		if err := flag.Value.Set(fmt.Sprint(values[key])); err != nil {
+			return fmt.Errorf("invalid config value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
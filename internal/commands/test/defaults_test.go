@@ -0,0 +1,23 @@
+package test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mmdbasi/mtcli/internal/config"
+)
+
+func TestConfigFlagsMatchModeKeys(t *testing.T) {
+	// Every key a [modes.<mode>] section overrides has a flag to apply it
+	// to, and the mode is the one flag that defaults to a top-level key only
+	var keys []string
+	for _, key := range configFlags {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	want := slices.Sorted(slices.Values(append(config.ModeKeys(), "mode")))
+	if !slices.Equal(keys, want) {
+		t.Errorf("configFlags keys = %v, want the mode keys and mode: %v", keys, want)
+	}
+}
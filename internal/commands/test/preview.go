@@ -0,0 +1,95 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/config"
+	"github.com/mmdbasi/mtcli/internal/text"
+	"github.com/spf13/cobra"
+)
+
+func NewPreviewCmd() *cobra.Command {
+	opts := &Options{}
+	cfg := config.Get()
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Print the text a test would use without starting it",
+		Long: `Generate the target text for a test and print it instead of typing it.
+
+Takes the same content flags as 'mtcli test'. The text goes to stdout so it
+can be piped elsewhere; the word count and seed go to stderr. Pass the seed
+to 'mtcli test --seed' to type exactly this text. In timer mode the whole
+generated buffer is printed.
+
+Examples:
+  mtcli preview
+  mtcli preview --mode timer --seconds 60 --seed 42
+  mtcli preview --words 50 --punctuation > drill.txt`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfig(cmd); err != nil {
+				return err
+			}
+			if err := applyDifficulty(opts, cmd.Flags().Changed); err != nil {
+				return err
+			}
+			return runPreview(cmd.Context(), opts)
+		},
+	}
+
+	// Mode flags
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", cfg.Mode, "test mode: timer, words, quote, text, practice, code, or zen")
+	cmd.Flags().IntVarP(&opts.Seconds, "seconds", "s", cfg.Seconds, "duration in seconds (timer mode)")
+	cmd.Flags().IntVarP(&opts.Words, "words", "w", cfg.Words, "number of words (words mode)")
+
+	// Quote flags
+	cmd.Flags().StringVar(&opts.QuoteID, "quote-id", "", "specific quote ID (quote mode)")
+	cmd.Flags().StringVar(&opts.QuoteSource, "quote-source", "", "random quote whose author/source contains this text (quote mode)")
+	cmd.Flags().StringVar(&opts.QuotesFile, "quotes-file", cfg.QuotesFile, "custom quotes file")
+
+	// Text flags
+	cmd.Flags().StringVar(&opts.File, "file", "", "file to use in text or code mode (- for stdin)")
+	cmd.Flags().BoolVar(&opts.KeepNewlines, "keep-newlines", false, "keep line breaks in text mode")
+
+	// Practice flags
+	cmd.Flags().IntVar(&opts.PracticeKeys, "practice-keys", 5, "number of weakest keys to drill (practice mode)")
+
+	// Content flags
+	cmd.Flags().StringVar(&opts.WordsFile, "words-file", cfg.WordsFile, "custom words file (overrides --language)")
+	cmd.Flags().StringVar(&opts.Language, "language", cfg.Language, "word list language (see 'mtcli languages')")
+	cmd.Flags().StringVar(&opts.Generator, "generator", cfg.Generator, "word generator: "+strings.Join(text.Generators(), ", "))
+	cmd.Flags().BoolVar(&opts.Punctuation, "punctuation", false, "add punctuation and capitalization to generated words")
+	cmd.Flags().BoolVar(&opts.Numbers, "numbers", false, "mix random numbers into generated words")
+	cmd.Flags().Float64Var(&opts.NumbersDensity, "numbers-density", cfg.NumbersDensity, "share of words replaced by numbers (0-1)")
+	cmd.Flags().BoolVar(&opts.AllowRepeats, "allow-repeats", false, "allow the same word twice in a row in generated text")
+	cmd.Flags().IntVar(&opts.MinWordLen, "min-word-len", 0, "only use words with at least this many characters (0 for no limit)")
+	cmd.Flags().IntVar(&opts.MaxWordLen, "max-word-len", 0, "only use words with at most this many characters (0 for no limit)")
+	cmd.Flags().StringVar(&opts.Difficulty, "difficulty", "", "preset for generated words: easy, medium, or hard (explicit flags win)")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 0, "random seed for reproducible text")
+
+	return cmd
+}
+
+func runPreview(ctx context.Context, opts *Options) error {
+	target, err := newTarget(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(target.Text)
+
+	summary := fmt.Sprintf("%d words", len(strings.Fields(target.Text)))
+	if target.Metadata.Seed != 0 {
+		summary += fmt.Sprintf(", seed %d", target.Metadata.Seed)
+	}
+	if target.Metadata.Source != "" {
+		summary += ", " + target.Metadata.Source
+	}
+	fmt.Fprintln(os.Stderr, summary)
+
+	return nil
+}
@@ -1,33 +1,105 @@
 package test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
+	"github.com/mmdbasi/mtcli/internal/activity"
 	"github.com/mmdbasi/mtcli/internal/charts"
 	"github.com/mmdbasi/mtcli/internal/config"
 	"github.com/mmdbasi/mtcli/internal/input"
+	"github.com/mmdbasi/mtcli/internal/notify"
+	"github.com/mmdbasi/mtcli/internal/report"
 	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
 	"github.com/mmdbasi/mtcli/internal/test"
 	"github.com/mmdbasi/mtcli/internal/text"
 	"github.com/mmdbasi/mtcli/internal/ui"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // Options holds the test command options
 type Options struct {
-	Mode        string
-	Seconds     int
-	Words       int
-	QuoteID     string
-	QuoteRandom bool
-	QuotesFile  string
-	WordsFile   string
-	Countdown   int
-	Seed        int64
-	NoColor     bool
-	Wrap        int
-	Chart       bool
+	Mode           string
+	Seconds        int
+	Words          int
+	MaxWords       int
+	MaxSeconds     int
+	QuoteID        string
+	QuoteRandom    bool
+	QuoteSource    string
+	QuotesFile     string
+	WordsFile      string
+	Language       string
+	Generator      string // registered text generator, see text.Generators
+	Countdown      int
+	CountdownStyle string  // "number" or "words"
+	CountdownWords string  // comma-separated labels for the words style
+	SampleInterval int     // milliseconds
+	AFKTimeout     float64 // seconds
+	Seed           int64
+	NoColor        bool
+	Wrap           int
+	CaretStyle     string
+	CaretBlink     int // milliseconds; 0 for a steady caret
+	Theme          string
+	ColorMode      string
+	ShowAccuracy   bool
+	ShowRaw        bool
+	PaceMargin     float64 // percent; 0 for a single live WPM color
+	ShowWhitespace bool
+	Unit           string // "wpm" or "cpm"
+	Minimal        bool   // no header line during the test
+	Chart          bool
+	Output         string // "text" or "json"
+	ChartStyle     string
+	ChartSmooth    int
+	ChartErrors    bool
+	ChartLatency   bool
+	SummaryFile    string // also write the summary here, without colors
+	SummaryFormat  string // "text" or "markdown"
+	StrictWords    bool
+	NoBackspace    bool
+	StopOnError    bool
+	Edit           bool // arrow keys move the caret back to edit typed text
+	AllowPaste     bool // type pastes instead of ignoring them
+	Punctuation    bool
+	Numbers        bool
+	NumbersDensity float64
+	AllowRepeats   bool
+	MinWordLen     int
+	MaxWordLen     int
+	Difficulty     string // "easy", "medium", "hard", or empty
+	File           string
+	KeepNewlines   bool
+	PracticeKeys   int
+	Repeat         string // session ID to repeat, or "last"
+	MaxStoredText  int
+	MaxKeystrokes  int // keystroke times kept for latency stats
+	TargetWPM      float64
+	Blind          bool
+	RevealAhead    int    // words visible from the caret on; 0 for the whole text
+	Review         bool   // drill the missed words after the summary
+	ReviewPrompt   bool   // ask whether to drill the missed words
+	Bell           string // "off", "end", or "best"
+	Notify         bool   // desktop notification when a test completes
+
+	// fileData holds the --file contents once read, so a restart builds
+	// its target from them instead of reading stdin a second time
+	fileData []byte
 }
 
 func NewTestCmd() *cobra.Command {
@@ -37,246 +109,1264 @@ func NewTestCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "test",
 		Short: "Start a typing test",
-		Long: `Start a typing test in one of three modes:
+		Long: `Start a typing test in one of seven modes:
 
-  timer  - Type as many words as you can before time runs out
-  words  - Type a fixed number of words as fast as you can
-  quote  - Type a famous quote
+  timer    - Type as many words as you can before time runs out
+  words    - Type a fixed number of words as fast as you can
+  quote    - Type a famous quote
+  text     - Type your own text from a file or stdin
+  practice - Drill words containing your most-missed keys
+  code     - Type a source file verbatim, indentation and all
+  zen      - Type endless words until you press Esc to finish
 
 Examples:
   mtcli test                          # Default: 25 words
   mtcli test --mode timer --seconds 60  # 60 second timed test
   mtcli test --mode words --words 50    # Type 50 words
-  mtcli test --mode quote --quote-random # Random quote`,
+  mtcli test --mode quote --quote-random # Random quote
+  mtcli test --mode quote --quote-source twain # Random Mark Twain quote
+  mtcli test --mode text --file essay.txt # Type a text file
+  mtcli test --mode practice            # Drill your weakest keys
+  mtcli test --difficulty hard          # Long words with punctuation and numbers
+  mtcli test --mode code --file main.go # Type code, tabs and newlines included
+  mtcli test --mode zen                 # Type until you stop
+  mtcli test --edit                     # Go back with the arrow keys to fix mistakes
+  mtcli test --repeat                   # Retry the last test's exact text
+  mtcli test --repeat 12                # Retry the text from session 12`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runTest(opts)
+			// Allow "--repeat 12" as well as "--repeat=12"
+			if len(args) > 0 {
+				if opts.Repeat != repeatLast {
+					return fmt.Errorf("unexpected argument: %s", args[0])
+				}
+				opts.Repeat = args[0]
+			}
+			if err := applyConfig(cmd); err != nil {
+				return err
+			}
+			if err := applyDifficulty(opts, cmd.Flags().Changed); err != nil {
+				return err
+			}
+			return runTest(cmd.Context(), opts)
 		},
 	}
 
 	// Mode flags
-	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", cfg.Mode, "test mode: timer, words, or quote")
+	cmd.Flags().StringVarP(&opts.Mode, "mode", "m", cfg.Mode, "test mode: timer, words, quote, text, practice, code, or zen")
 	cmd.Flags().IntVarP(&opts.Seconds, "seconds", "s", cfg.Seconds, "duration in seconds (timer mode)")
 	cmd.Flags().IntVarP(&opts.Words, "words", "w", cfg.Words, "number of words (words mode)")
+	cmd.Flags().IntVar(&opts.MaxWords, "max-words", 0, "timer mode: also finish after this many words (0 for no cap)")
+	cmd.Flags().IntVar(&opts.MaxSeconds, "max-seconds", 0, "other modes: also finish after this many seconds (0 for no cap)")
 
 	// Quote flags
 	cmd.Flags().StringVar(&opts.QuoteID, "quote-id", "", "specific quote ID (quote mode)")
 	cmd.Flags().BoolVar(&opts.QuoteRandom, "quote-random", true, "random quote (quote mode)")
+	cmd.Flags().StringVar(&opts.QuoteSource, "quote-source", "", "random quote whose author/source contains this text (quote mode)")
 	cmd.Flags().StringVar(&opts.QuotesFile, "quotes-file", cfg.QuotesFile, "custom quotes file")
 
+	// Text flags
+	cmd.Flags().StringVar(&opts.File, "file", "", "file to type in text or code mode (- for stdin)")
+	cmd.Flags().BoolVar(&opts.KeepNewlines, "keep-newlines", false, "keep line breaks in text mode (typed with Enter)")
+
+	// Repeat flags
+	cmd.Flags().StringVar(&opts.Repeat, "repeat", "", "re-run the exact text of a previous session (default: the last one)")
+	cmd.Flags().Lookup("repeat").NoOptDefVal = repeatLast
+	cmd.Flags().IntVar(&opts.MaxStoredText, "max-stored-text", 0, "in timer mode, store at most N characters of the generated text, but never less than was typed (0 for all)")
+	cmd.Flags().IntVar(&opts.MaxKeystrokes, "max-keystrokes", test.DefaultMaxKeyTimes, "keep the times of at most N keystrokes for the latency percentile and chart")
+
+	// Practice flags
+	cmd.Flags().IntVar(&opts.PracticeKeys, "practice-keys", 5, "number of weakest keys to drill (practice mode)")
+
 	// Content flags
-	cmd.Flags().StringVar(&opts.WordsFile, "words-file", cfg.WordsFile, "custom words file")
+	cmd.Flags().StringVar(&opts.WordsFile, "words-file", cfg.WordsFile, "custom words file (overrides --language)")
+	cmd.Flags().StringVar(&opts.Language, "language", cfg.Language, "word list language (see 'mtcli languages')")
+	cmd.Flags().StringVar(&opts.Generator, "generator", cfg.Generator, "word generator: "+strings.Join(text.Generators(), ", "))
+	cmd.Flags().BoolVar(&opts.Punctuation, "punctuation", false, "add punctuation and capitalization to generated words")
+	cmd.Flags().BoolVar(&opts.Numbers, "numbers", false, "mix random numbers into generated words")
+	cmd.Flags().Float64Var(&opts.NumbersDensity, "numbers-density", cfg.NumbersDensity, "share of words replaced by numbers (0-1)")
+	cmd.Flags().BoolVar(&opts.AllowRepeats, "allow-repeats", false, "allow the same word twice in a row in generated text")
+	cmd.Flags().IntVar(&opts.MinWordLen, "min-word-len", 0, "only use words with at least this many characters (0 for no limit)")
+	cmd.Flags().IntVar(&opts.MaxWordLen, "max-word-len", 0, "only use words with at most this many characters (0 for no limit)")
+	cmd.Flags().StringVar(&opts.Difficulty, "difficulty", "", "preset for generated words: easy, medium, or hard (explicit flags win)")
 
 	// Behavior flags
 	cmd.Flags().IntVar(&opts.Countdown, "countdown", cfg.Countdown, "countdown seconds before test starts")
+	cmd.Flags().StringVar(&opts.CountdownStyle, "countdown-style", cfg.CountdownStyle, "countdown display: number (seconds left) or words (labels such as Ready, Set, Go)")
+	cmd.Flags().StringVar(&opts.CountdownWords, "countdown-words", cfg.CountdownWords, "comma-separated labels for --countdown-style words, the last shown last")
+	cmd.Flags().IntVar(&opts.SampleInterval, "sample-interval", cfg.SampleIntervalMs, "milliseconds between speed samples for the chart")
+	cmd.Flags().Float64Var(&opts.AFKTimeout, "afk-timeout", cfg.AFKTimeout, "seconds without a key before the clock stops until the next one (0 to disable)")
 	cmd.Flags().Int64Var(&opts.Seed, "seed", 0, "random seed for reproducible tests")
 	cmd.Flags().BoolVar(&opts.NoColor, "no-color", cfg.NoColor, "disable color output")
+	cmd.Flags().BoolVar(&opts.StrictWords, "strict-words", false, "space skips to the next word, marking skipped characters incorrect")
+	cmd.Flags().BoolVar(&opts.NoBackspace, "no-backspace", false, "ignore backspace so mistakes cannot be corrected")
+	cmd.Flags().BoolVar(&opts.StopOnError, "stop-on-error", false, "reject wrong keys; the correct key must be typed to advance")
+	cmd.Flags().BoolVar(&opts.Edit, "edit", false, "edit text already typed: arrow keys, Home, and End move the caret, Delete removes forward")
+	// Only one correction mode applies, and editing would undo what the
+	// other two forbid undoing
+	cmd.MarkFlagsMutuallyExclusive("edit", "no-backspace", "stop-on-error")
+	cmd.Flags().BoolVar(&opts.Blind, "blind", false, "hide mistakes while typing; accuracy is revealed on the summary")
+	// Rejected keys would give mistakes away
+	cmd.MarkFlagsMutuallyExclusive("blind", "stop-on-error")
+	cmd.Flags().IntVar(&opts.RevealAhead, "reveal-ahead", 0, "only show this many words from the caret on, counting the current one (0 shows all)")
+	cmd.Flags().BoolVar(&opts.AllowPaste, "allow-paste", false, "type pasted text as keystrokes instead of ignoring it")
+	cmd.Flags().BoolVar(&opts.Review, "review", false, "after the summary, start a practice test on the words you missed")
+	cmd.Flags().BoolVar(&opts.ReviewPrompt, "review-prompt", cfg.ReviewPrompt, "after the summary, ask whether to retype the words you missed")
+	cmd.Flags().Float64Var(&opts.TargetWPM, "target-wpm", 0, "WPM goal to show as met or missed on the summary (0 for none)")
 
 	// Output flags
 	cmd.Flags().IntVar(&opts.Wrap, "wrap", cfg.Wrap, "wrap width (0 for auto)")
+	cmd.Flags().StringVar(&opts.CaretStyle, "caret-style", cfg.CaretStyle, "caret at the typing position: block, underline, or off")
+	cmd.Flags().IntVar(&opts.CaretBlink, "caret-blink", cfg.CaretBlinkMs, "milliseconds the caret stays on, then off, as it blinks (0 for a steady caret)")
+	cmd.Flags().StringVar(&opts.Theme, "theme", cfg.Theme, "color theme: "+strings.Join(ui.ThemeNames(), ", "))
+	cmd.Flags().StringVar(&opts.ColorMode, "color-mode", cfg.ColorMode, "color output: auto (from COLORTERM), 256, truecolor, or none")
+	cmd.Flags().BoolVar(&opts.ShowAccuracy, "show-accuracy", cfg.ShowAccuracy, "show live accuracy in the status line")
+	cmd.Flags().BoolVar(&opts.ShowRaw, "show-raw", cfg.ShowRaw, "show live raw WPM in the status line")
+	cmd.Flags().Float64Var(&opts.PaceMargin, "pace-margin", cfg.PaceMargin, "color live WPM by pace against your 7-day average, within this percent counting as on pace (0 to disable)")
+	cmd.Flags().BoolVar(&opts.ShowWhitespace, "show-whitespace", cfg.ShowWhitespace, "draw spaces as visible dots")
+	cmd.Flags().BoolVar(&opts.Minimal, "minimal", cfg.Minimal, "hide the header line and shorten the status line while typing")
+	cmd.Flags().StringVar(&opts.Unit, "unit", cfg.Unit, "speed to lead the status line, summary, and chart with: wpm or cpm")
 	cmd.Flags().BoolVar(&opts.Chart, "chart", cfg.Chart, "show speed chart at end")
+	cmd.Flags().StringVar(&opts.Bell, "bell", cfg.Bell, "ring the terminal bell on the summary: off, end (every test), or best (new personal best)")
+	cmd.Flags().BoolVar(&opts.Notify, "notify", false, "send a desktop notification when a test completes")
+	cmd.Flags().StringVar(&opts.Output, "output", "text", "result format: text (summary screen) or json")
+	cmd.Flags().StringVar(&opts.ChartStyle, "chart-style", cfg.ChartStyle, "chart style: block or braille (braille falls back to block with --no-color)")
+	cmd.Flags().BoolVar(&opts.ChartErrors, "chart-errors", false, "add the error rate over time to the speed chart")
+	cmd.Flags().BoolVar(&opts.ChartLatency, "chart-latency", false, "add a chart of the time between keystrokes below the speed chart")
+	cmd.Flags().IntVar(&opts.ChartSmooth, "chart-smooth", 0, "smooth the speed chart with a moving average over N samples (0 for none)")
+	cmd.Flags().StringVar(&opts.SummaryFile, "summary-file", "", "also write the summary to this file, for sharing")
+	cmd.Flags().StringVar(&opts.SummaryFormat, "summary-format", "markdown", "format of --summary-file: text or markdown")
 
 	return cmd
 }
 
-func runTest(opts *Options) error {
-	// Create text generator
-	gen, err := text.NewGenerator(text.GeneratorOptions{
-		WordsFile:  opts.WordsFile,
-		QuotesFile: opts.QuotesFile,
-		Seed:       opts.Seed,
-	})
+func runTest(ctx context.Context, opts *Options) error {
+	chartStyle, err := charts.ParseStyle(opts.ChartStyle)
 	if err != nil {
-		return fmt.Errorf("failed to initialize text generator: %w", err)
+		return err
 	}
-
-	// Generate target based on mode
-	var target *test.Target
-	switch opts.Mode {
-	case "timer":
-		target, err = gen.GenerateForTimer(opts.Seconds)
-	case "words":
-		target, err = gen.GenerateWords(opts.Words)
-	case "quote":
-		if opts.QuoteID != "" {
-			target, err = gen.GetQuoteByID(opts.QuoteID)
-		} else {
-			target, err = gen.GetRandomQuote()
-		}
-	default:
-		return fmt.Errorf("unknown mode: %s", opts.Mode)
+	colorMode, err := ui.ParseColorMode(opts.ColorMode)
+	if err != nil {
+		return err
+	}
+	if colorMode == ui.ColorModeNone {
+		opts.NoColor = true
+	}
+	if opts.NoColor {
+		chartStyle = charts.StyleBlock
+	}
+	caretStyle, err := ui.ParseCaretStyle(opts.CaretStyle)
+	if err != nil {
+		return err
+	}
+	unit, err := ui.ParseSpeedUnit(opts.Unit)
+	if err != nil {
+		return err
+	}
+	bell, err := ui.ParseBellMode(opts.Bell)
+	if err != nil {
+		return err
+	}
+	if _, err := ui.ParseSummaryFormat(opts.SummaryFormat); err != nil {
+		return err
+	}
+	countdownStyle, err := ui.ParseCountdownStyle(opts.CountdownStyle)
+	if err != nil {
+		return err
+	}
+	countdownWords := ui.SplitCountdownWords(opts.CountdownWords)
+	if countdownStyle == ui.CountdownWords && len(countdownWords) == 0 {
+		return fmt.Errorf("--countdown-words needs at least one label")
+	}
+	theme, err := ui.ParseTheme(opts.Theme, colorMode)
+	if err != nil {
+		return err
+	}
+	if opts.Output != "text" && opts.Output != "json" {
+		return fmt.Errorf("unknown output format: %s (use text or json)", opts.Output)
+	}
+	if opts.Review && opts.Output == "json" {
+		return fmt.Errorf("--review needs the summary screen, not --output json")
+	}
+	if opts.Output == "json" {
+		// There is no summary to ask from
+		opts.ReviewPrompt = false
+	}
+	if opts.TargetWPM < 0 {
+		return fmt.Errorf("target WPM must not be negative")
+	}
+	if opts.MaxWords < 0 || opts.MaxSeconds < 0 {
+		return fmt.Errorf("--max-words and --max-seconds must not be negative")
+	}
+	if opts.CaretBlink < 0 {
+		return fmt.Errorf("--caret-blink must not be negative")
+	}
+	if opts.RevealAhead < 0 {
+		return fmt.Errorf("--reveal-ahead must not be negative")
+	}
+	if opts.MaxKeystrokes < 1 {
+		return fmt.Errorf("--max-keystrokes must be at least 1")
+	}
+	sampleInterval := time.Duration(opts.SampleInterval) * time.Millisecond
+	if sampleInterval < test.MinSampleInterval {
+		return fmt.Errorf("sample interval must be at least %dms", test.MinSampleInterval.Milliseconds())
+	}
+	if opts.AFKTimeout < 0 {
+		return fmt.Errorf("AFK timeout must not be negative")
 	}
 
+	target, err := newTarget(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("failed to generate target text: %w", err)
+		return err
 	}
 
-	// Create renderer
-	renderer := ui.NewANSIRenderer(ui.RendererOptions{
-		Width:   opts.Wrap,
-		NoColor: opts.NoColor,
-	})
+	// Each cap only makes sense where the mode doesn't already end on it
+	if opts.MaxWords > 0 && target.Mode != test.ModeTimer {
+		return fmt.Errorf("--max-words only applies to timer mode (use --max-seconds to cap other modes)")
+	}
+	if opts.MaxSeconds > 0 && target.Mode == test.ModeTimer {
+		return fmt.Errorf("--max-seconds does not apply to timer mode (use --seconds, or --max-words to cap it)")
+	}
+
+	keymap, err := input.ParseKeymap(config.Get().Keymap)
+	if err != nil {
+		return fmt.Errorf("invalid keymap: %w", err)
+	}
 
 	// Create input reader
 	reader := input.NewRawReader()
 
-	// Create session
-	session := test.NewSession(test.SessionOptions{
-		Target:       target,
-		TimerSeconds: opts.Seconds,
+	// Draw on the terminal even when stdout is redirected, so that only the
+	// JSON result ends up in the pipe
+	output, closeOutput := terminalOutput()
+	defer closeOutput()
+
+	// Create renderer
+	renderer := ui.NewANSIRenderer(ui.RendererOptions{
+		Width:          opts.Wrap,
+		NoColor:        opts.NoColor,
+		Caret:          caretStyle,
+		Theme:          theme,
+		ShowAccuracy:   opts.ShowAccuracy,
+		ShowRaw:        opts.ShowRaw,
+		ShowWhitespace: opts.ShowWhitespace,
+		Unit:           unit,
+		PaceMargin:     opts.PaceMargin,
+		Bell:           bell,
+		Output:         output,
 	})
 
 	// Initialize raw mode
 	if err := reader.Init(); err != nil {
 		return fmt.Errorf("failed to initialize input: %w", err)
 	}
-	defer reader.Cleanup()
 
 	// Initialize renderer
 	if err := renderer.Init(); err != nil {
 		reader.Cleanup()
 		return fmt.Errorf("failed to initialize renderer: %w", err)
 	}
-	defer renderer.Cleanup()
 
-	// Countdown
-	if opts.Countdown > 0 {
-		for i := opts.Countdown; i > 0; i-- {
-			renderer.RenderCountdown(i)
-			time.Sleep(time.Second)
-		}
+	// restore returns the terminal to normal; it runs at most once so it can
+	// be called early before printing JSON, or from the signal handler
+	var restoreOnce sync.Once
+	restore := func() {
+		restoreOnce.Do(func() {
+			renderer.Cleanup()
+			reader.Cleanup()
+		})
 	}
+	defer restore()
 
-	// Initial render
-	state := session.GetState()
-	renderState := buildRenderState(session, state, opts)
-	renderer.Render(renderState)
+	// Raw mode turns Ctrl+C into a key, but a signal from elsewhere (kill,
+	// a closed terminal) would otherwise leave the terminal raw and the
+	// cursor hidden
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer func() {
+		signal.Stop(sigChan)
+		close(sigChan)
+	}()
+	go func() {
+		sig, ok := <-sigChan
+		if !ok {
+			return
+		}
+		restore()
+		code := 1
+		if s, ok := sig.(syscall.Signal); ok {
+			code = 128 + int(s)
+		}
+		os.Exit(code)
+	}()
 
 	// Channel for key events
 	keyChan := make(chan input.KeyEvent)
 	errChan := make(chan error)
 
-	// Read input in goroutine
+	// Read input in goroutine. Returning cancels the read and waits for the
+	// goroutine, so it isn't left blocked on the terminal when restore runs.
+	readCtx, stopReading := context.WithCancel(ctx)
+	readDone := make(chan struct{})
+	defer func() {
+		stopReading()
+		<-readDone
+	}()
 	go func() {
-		for {
-			key, err := reader.ReadKey()
-			if err != nil {
-				errChan <- err
-				return
-			}
-			keyChan <- key
-		}
+		defer close(readDone)
+		readKeys(readCtx, reader, keyChan, errChan, restore)
 	}()
 
-	// Ticker for periodic updates (timer display, live WPM)
-	ticker := time.NewTicker(200 * time.Millisecond)
+	scr := &screen{renderer: renderer, keys: keyChan, errs: errChan, keymap: keymap}
+	scr.countdown = countdownLabels(opts.Countdown, countdownStyle, countdownWords)
+	if caretStyle != ui.CaretOff {
+		scr.blink = time.Duration(opts.CaretBlink) * time.Millisecond
+	}
+	if opts.Notify {
+		scr.notifier = notify.New()
+	}
+	if opts.PaceMargin > 0 {
+		scr.paceWPM = recentAverage(ctx)
+	}
+
+	// A restart rolls new text unless the seed was given, and retypes
+	// --file text from what was read the first time
+	result, err := playRestartable(scr, target, opts, sampleInterval, func() (*test.Target, error) {
+		return newTarget(ctx, opts)
+	})
+	if err != nil || result == nil {
+		return err
+	}
+
+	// Offer to drill the missed words unless --review drills them anyway
+	var prompt string
+	if opts.ReviewPrompt && !opts.Review {
+		prompt = reviewPrompt(len(result.MissedWords))
+	}
+	retype, err := finishSession(ctx, scr, result, opts, chartStyle, theme, restore, prompt)
+	if err != nil {
+		return err
+	}
+	if !(opts.Review || retype) || len(result.MissedWords) == 0 {
+		return nil
+	}
+
+	missed := result.MissedWords
+	target, err = reviewTarget(opts, missed)
+	if err != nil {
+		return err
+	}
+	result, err = playRestartable(scr, target, opts, sampleInterval, func() (*test.Target, error) {
+		return reviewTarget(opts, missed)
+	})
+	if err != nil || result == nil {
+		return err
+	}
+	_, err = finishSession(ctx, scr, result, opts, chartStyle, theme, restore, "")
+	return err
+}
+
+// reviewPrompt returns the question offering to retype n missed words, or
+// "" if there are none
+func reviewPrompt(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "Retype the word you missed? [y/N]"
+	default:
+		return fmt.Sprintf("Retype the %d words you missed? [y/N]", n)
+	}
+}
+
+// screen is the terminal a test runs on. One goroutine reads keys for the
+// whole run, so a follow-up test doesn't race it for input.
+type screen struct {
+	renderer  *ui.ANSIRenderer
+	keys      <-chan input.KeyEvent
+	errs      <-chan error
+	keymap    input.Keymap
+	paceWPM   float64         // 7-day average WPM for live colors; 0 for none
+	countdown []string        // countdown steps shown a second each; empty for none
+	blink     time.Duration   // caret blink interval; 0 for a steady caret
+	notifier  notify.Notifier // nil unless --notify
+}
+
+// countdownLabels returns the label of each second of a countdown of the
+// given length. In the words style the last seconds take the words, so a
+// longer countdown counts down to them and a shorter one starts part way
+// through them.
+func countdownLabels(seconds int, style ui.CountdownStyle, words []string) []string {
+	labels := make([]string, 0, max(seconds, 0))
+	for left := seconds; left > 0; left-- {
+		if style == ui.CountdownWords && left <= len(words) {
+			labels = append(labels, words[len(words)-left])
+		} else {
+			labels = append(labels, strconv.Itoa(left))
+		}
+	}
+	return labels
+}
+
+// waitForKey blocks until a key is pressed and returns it
+func (scr *screen) waitForKey() (input.KeyEvent, error) {
+	select {
+	case key := <-scr.keys:
+		return key, nil
+	case err := <-scr.errs:
+		return input.KeyEvent{}, fmt.Errorf("input error: %w", err)
+	}
+}
+
+// errRestart is returned by playSession when the restart key abandons the
+// session
+var errRestart = errors.New("restart")
+
+// playRestartable plays target, starting over on the target from next each
+// time the restart key is pressed. The abandoned sessions aren't saved.
+func playRestartable(scr *screen, target *test.Target, opts *Options, sampleInterval time.Duration, next func() (*test.Target, error)) (*test.SessionResult, error) {
+	for {
+		result, err := playSession(scr, target, opts, sampleInterval)
+		if !errors.Is(err, errRestart) {
+			return result, err
+		}
+		if target, err = next(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// playSession runs the countdown and one typing session on target. It
+// returns a nil result if the session was aborted.
+func playSession(scr *screen, target *test.Target, opts *Options, sampleInterval time.Duration) (*test.SessionResult, error) {
+	// Caps the target's mode doesn't support are dropped, which only matters
+	// for a follow-up in a different mode
+	maxWords, maxSeconds := opts.MaxWords, opts.MaxSeconds
+	if target.Mode == test.ModeTimer {
+		maxSeconds = 0
+	} else {
+		maxWords = 0
+	}
+
+	// Create session
+	session := test.NewSession(test.SessionOptions{
+		Target:         target,
+		TimerSeconds:   opts.Seconds,
+		MaxWords:       maxWords,
+		MaxSeconds:     maxSeconds,
+		StrictWords:    opts.StrictWords,
+		Correction:     correctionMode(opts),
+		Edit:           opts.Edit,
+		SampleInterval: sampleInterval,
+		MaxKeyTimes:    opts.MaxKeystrokes,
+		AFKTimeout:     time.Duration(opts.AFKTimeout * float64(time.Second)),
+	})
+
+	// Countdown
+	for _, label := range scr.countdown {
+		scr.renderer.RenderCountdown(label)
+		time.Sleep(time.Second)
+	}
+	session.Arm()
+
+	// Line breaks in the target are typed with Enter
+	multiline := strings.ContainsRune(target.Text, '\n')
+	tabs := strings.ContainsRune(target.Text, '\t')
+
+	// A rejected paste is pointed out in the header for a moment
+	var pasteIgnoredAt time.Time
+	// Blinks are counted on their own clock, so typing doesn't reset them
+	var blinks int
+	help := keyHelp(scr.keymap, target.Mode)
+	render := func() {
+		renderState := buildRenderState(session, session.GetState(), opts)
+		renderState.Hint = help
+		renderState.Blinks = blinks
+		renderState.PaceWPM = scr.paceWPM
+		if time.Since(pasteIgnoredAt) < pasteHintDuration {
+			renderState.Hint = "Paste ignored (--allow-paste to type it)"
+		}
+		scr.renderer.Render(renderState)
+	}
+
+	// Initial render
+	render()
+
+	// Ticker for periodic updates (timer display, live WPM), at least as
+	// often as samples are due
+	ticker := time.NewTicker(min(200*time.Millisecond, sampleInterval))
 	defer ticker.Stop()
 
+	// A steady caret leaves this nil, so it never fires
+	var blink <-chan time.Time
+	if scr.blink > 0 {
+		blinkTicker := time.NewTicker(scr.blink)
+		defer blinkTicker.Stop()
+		blink = blinkTicker.C
+	}
+
 	// Main event loop
 	for !session.IsFinished() {
 		select {
-		case key := <-keyChan:
-			switch key.Type {
-			case input.KeyCtrlC, input.KeyEscape:
+		case key := <-scr.keys:
+			switch action := scr.keymap.Resolve(key); {
+			case action == input.ActionAbort:
+				session.Abort()
+			case action == input.ActionRestart && !(tabs && key.Type == input.KeyTab):
+				// Abort stops the session's timer before the next one starts
 				session.Abort()
-			case input.KeyRune:
-				session.HandleKey(test.KeyTypeRune, key.Rune)
-			case input.KeyBackspace:
-				session.HandleKey(test.KeyTypeBackspace, 0)
+				return nil, errRestart
+			case action == input.ActionPause && target.Mode == test.ModeZen:
+				// Zen mode has no end of its own; the pause key ends it.
+				// Before the first key there is nothing to report.
+				if !session.Stop() {
+					session.Abort()
+				}
+			case session.IsPaused():
+				// Any other key resumes without being typed
+				session.Resume()
+			case action == input.ActionPause:
+				session.Pause()
+			case action == input.ActionDeleteWord:
+				session.HandleKey(test.KeyTypeDeleteWord, 0)
+			case key.Type == input.KeyPaste && !opts.AllowPaste:
+				pasteIgnoredAt = time.Now()
+			case key.Type == input.KeyPaste:
+				for _, k := range input.PastedKeys(key.Text) {
+					handleKey(session, k, multiline, tabs)
+				}
+			default:
+				handleKey(session, key, multiline, tabs)
 			}
 
 			// Update display after keypress
-			state = session.GetState()
-			renderState = buildRenderState(session, state, opts)
-			renderer.Render(renderState)
+			render()
 
 		case <-ticker.C:
 			// Periodic update for timer mode and live WPM
 			if !session.IsFinished() {
 				// Collect sample for chart
 				session.TakeSample()
-				
-				state = session.GetState()
-				renderState = buildRenderState(session, state, opts)
-				renderer.Render(renderState)
+				render()
 			}
 
-		case err := <-errChan:
-			return fmt.Errorf("input error: %w", err)
+		case <-blink:
+			blinks++
+			render()
+
+		case err := <-scr.errs:
+			return nil, fmt.Errorf("input error: %w", err)
 		}
 	}
 
 	// If aborted, exit without summary
 	if session.IsAborted() {
-		return nil
+		return nil, nil
 	}
 
 	// Get results
 	result := session.GetResult()
+	result.TargetWPM = opts.TargetWPM
+	result.Blind = opts.Blind
+	return result, nil
+}
 
-	// Generate chart
-	var chartStr string
-	if opts.Chart && len(result.Samples) > 1 {
-		// Convert samples to chart data points
-		wpmPoints := make([]charts.DataPoint, len(result.Samples))
-		rawPoints := make([]charts.DataPoint, len(result.Samples))
-		for i, s := range result.Samples {
-			wpmPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.WPM}
-			rawPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.RawWPM}
+// pasteHintDuration is how long the header says a paste was ignored
+const pasteHintDuration = 2 * time.Second
+
+// finishSession reports and saves a finished session: as JSON on stdout,
+// leaving raw mode first via restore, or as the summary screen, which
+// waits for a key. With a prompt, the summary asks it instead and
+// finishSession reports whether it was answered yes.
+func finishSession(ctx context.Context, scr *screen, result *test.SessionResult, opts *Options, chartStyle charts.Style, theme ui.Theme, restore func(), prompt string) (bool, error) {
+	// Compare before saving so the run isn't measured against itself
+	record, err := personalBest(ctx, result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to check personal best: %v\n", err)
+	}
+	result.Record = record
+	if err := dailyGoal(ctx, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to check daily goal: %v\n", err)
+	}
+
+	// The file is for the test itself, not the drill that may follow
+	if opts.SummaryFile != "" && !result.Metadata.MistakeDrill {
+		if err := writeSummaryFile(result, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if scr.notifier != nil {
+		if err := scr.notifier.Notify("mtcli", notification(result)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to send notification: %v\n", err)
 		}
+	}
+
+	if opts.Output == "json" {
+		// Leave raw mode and clear the UI before anything reaches stdout
+		restore()
 
-		chartOpts := charts.DefaultOptions()
-		chartOpts.Width = renderer.GetWidth() - 4
-		if chartOpts.Width > 70 {
-			chartOpts.Width = 70
+		id, err := saveSession(ctx, result, opts.MaxStoredText)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save session: %v\n", err)
 		}
-		chartStr = charts.RenderDualChart(wpmPoints, rawPoints, chartOpts)
+
+		out := report.FromResult(result)
+		out.ID = id
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return false, enc.Encode(out)
+	}
+
+	// Generate chart
+	var chartStr string
+	if opts.Chart {
+		chartStr = speedChart(result, opts, scr.renderer.GetWidth()-4, &theme, chartStyle)
 	}
 
 	// Show summary
-	renderer.RenderSummary(result, chartStr)
+	scr.renderer.RenderSummary(result, chartStr)
+	if prompt != "" {
+		scr.renderer.RenderPrompt(prompt)
+	}
+	key, err := scr.waitForKey()
+	if err != nil {
+		return false, err
+	}
+	yes := prompt != "" && key.Type == input.KeyRune && (key.Rune == 'y' || key.Rune == 'Y')
 
 	// Save to storage
-	if err := saveSession(result); err != nil {
+	if _, err := saveSession(ctx, result, opts.MaxStoredText); err != nil {
 		fmt.Printf("Warning: failed to save session: %v\n", err)
 	}
 
+	return yes, nil
+}
+
+// writeSummaryFile writes the shareable summary of result to
+// opts.SummaryFile. The chart is always included, in plain blocks so it
+// survives being pasted.
+func writeSummaryFile(result *test.SessionResult, opts *Options) error {
+	f, err := os.Create(opts.SummaryFile)
+	if err != nil {
+		return fmt.Errorf("failed to create summary file: %w", err)
+	}
+
+	chart := speedChart(result, opts, 70, nil, charts.StyleBlock)
+	if err := ui.WriteSummary(f, result, chart, ui.SummaryFormat(opts.SummaryFormat), ui.SpeedUnit(opts.Unit)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
 	return nil
 }
 
+// speedChart draws the summary chart of result at most width columns
+// wide, or returns "" with too few samples. A nil theme leaves it
+// uncolored.
+func speedChart(result *test.SessionResult, opts *Options, width int, theme *ui.Theme, style charts.Style) string {
+	if len(result.Samples) < 2 {
+		return ""
+	}
+
+	// Convert samples to chart data points
+	wpmPoints := make([]charts.DataPoint, len(result.Samples))
+	rawPoints := make([]charts.DataPoint, len(result.Samples))
+	errPoints := make([]charts.DataPoint, len(result.Samples))
+	unit := ui.SpeedUnit(opts.Unit)
+	for i, s := range result.Samples {
+		wpmPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: unit.FromWPM(s.WPM)}
+		rawPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: unit.FromWPM(s.RawWPM)}
+		errPoints[i] = charts.DataPoint{TimeMs: s.TimeMs, Value: s.ErrorRate()}
+	}
+
+	chartOpts := charts.DefaultOptions()
+	chartOpts.ValueUnit = unit.Label()
+	chartOpts.Smooth = opts.ChartSmooth
+	chartOpts.Width = min(width, 70)
+	if theme != nil && !opts.NoColor {
+		ui.ChartColors(&chartOpts, *theme)
+	}
+
+	var chart string
+	if opts.ChartErrors {
+		chart = charts.RenderTripleChartStyle(wpmPoints, rawPoints, errPoints, chartOpts, style)
+	} else {
+		chart = charts.RenderDualChartStyle(wpmPoints, rawPoints, chartOpts, style)
+	}
+
+	if latency := latencyPoints(result); opts.ChartLatency && len(latency) > 1 {
+		chartOpts.Title = "Key latency over time:\n"
+		chartOpts.ValueUnit = "ms"
+		chart += "\n" + charts.RenderChartStyle(latency, chartOpts, style)
+	}
+	return chart
+}
+
+// latencyPoints returns the mean time between keystrokes within each
+// sample interval, so the latency chart lines up with the speed chart.
+// Intervals without a keystroke are left out.
+func latencyPoints(result *test.SessionResult) []charts.DataPoint {
+	times := result.KeyTimesMs
+	gaps := test.KeyGaps(times)
+
+	var points []charts.DataPoint
+	var from int64
+	i := 0
+	for _, sample := range result.Samples {
+		var sum, n int64
+		// gaps[i] ends at times[i+1]
+		for ; i < len(gaps) && times[i+1] <= sample.TimeMs; i++ {
+			if times[i+1] > from {
+				sum += gaps[i]
+				n++
+			}
+		}
+		if n > 0 {
+			points = append(points, charts.DataPoint{TimeMs: sample.TimeMs, Value: float64(sum) / float64(n)})
+		}
+		from = sample.TimeMs
+	}
+	return points
+}
+
+// notification returns the body of the desktop notification for result
+func notification(result *test.SessionResult) string {
+	body := fmt.Sprintf("Test complete: %.1f WPM, %.1f%% accuracy", result.WPM, result.Accuracy)
+	if result.Record != test.RecordNone {
+		body += " (new personal best!)"
+	}
+	return body
+}
+
+// reviewTarget builds a practice target that drills the words missed in
+// the previous test, at least as many words as a words-mode test
+func reviewTarget(opts *Options, missed []string) (*test.Target, error) {
+	gen, err := text.NewGenerator(text.GeneratorOptions{
+		AllowRepeats: opts.AllowRepeats,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize text generator: %w", err)
+	}
+	target, err := gen.GenerateFromWords(missed, max(opts.Words, len(missed)))
+	if err != nil {
+		return nil, err
+	}
+	target.Metadata.MistakeDrill = true
+	return target, nil
+}
+
+// personalBest checks result against the stored best WPM, overall and for
+// its mode. A first run, overall or in a mode, sets no record since there
+// was nothing to beat.
+func personalBest(ctx context.Context, result *test.SessionResult) (test.Record, error) {
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return test.RecordNone, err
+	}
+	defer store.Close()
+
+	stats, err := store.GetStatsContext(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		return test.RecordNone, err
+	}
+
+	if stats.TotalTests > 0 && result.WPM > stats.BestWPM {
+		return test.RecordOverall, nil
+	}
+	if mode, ok := stats.ModeStats[string(result.Mode)]; ok && mode.TestCount > 0 && result.WPM > mode.BestWPM {
+		return test.RecordMode, nil
+	}
+	return test.RecordNone, nil
+}
+
+// dailyGoal fills in the progress result makes towards the daily goal,
+// if one is set. It runs before the session is saved, so result is
+// counted on top of the stored tests.
+func dailyGoal(ctx context.Context, result *test.SessionResult) error {
+	cfg := config.Get()
+	if cfg.GoalTests <= 0 {
+		return nil
+	}
+
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	counts, err := store.GetDailyCountsMinWPMContext(ctx, cfg.GoalWPM)
+	if err != nil {
+		return err
+	}
+
+	result.GoalTests = cfg.GoalTests
+	result.GoalDone = counts[activity.Day(time.Now())]
+	if result.WPM >= cfg.GoalWPM {
+		result.GoalDone++
+	}
+	return nil
+}
+
+// keyHelp returns the header's reminder of the keys for restarting,
+// pausing, and leaving a test; in zen mode the pause key finishes it
+func keyHelp(keymap input.Keymap, mode test.Mode) string {
+	help := keymap.Name(input.ActionAbort) + " to exit"
+	if pause := keymap.Name(input.ActionPause); pause != "" && mode == test.ModeZen {
+		help = pause + " to finish, " + help
+	} else if pause != "" {
+		help = pause + " to pause, " + help
+	}
+	if restart := keymap.Name(input.ActionRestart); restart != "" {
+		help = restart + " to restart, " + help
+	}
+	return help
+}
+
+// recentAverage returns the average WPM of the last 7 days, or 0 if there
+// is none or it can't be read; the live WPM is then shown in one color
+func recentAverage(ctx context.Context) float64 {
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return 0
+	}
+	defer store.Close()
+
+	stats, err := store.GetStatsContext(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		return 0
+	}
+	return stats.Last7DaysAvgWPM
+}
+
+// handleKey forwards a key event to the session. Enter on the last
+// character submits the test; otherwise Enter and Tab are only typed when
+// the target contains line breaks or tabs.
+func handleKey(session *test.Session, key input.KeyEvent, multiline, tabs bool) {
+	switch key.Type {
+	case input.KeyRune:
+		session.HandleKey(test.KeyTypeRune, key.Rune)
+	case input.KeyBackspace:
+		session.HandleKey(test.KeyTypeBackspace, 0)
+	case input.KeyDelete:
+		session.HandleKey(test.KeyTypeDelete, 0)
+	case input.KeyArrowLeft:
+		session.HandleKey(test.KeyTypeLeft, 0)
+	case input.KeyArrowRight:
+		session.HandleKey(test.KeyTypeRight, 0)
+	case input.KeyHome:
+		session.HandleKey(test.KeyTypeHome, 0)
+	case input.KeyEnd:
+		session.HandleKey(test.KeyTypeEnd, 0)
+	case input.KeyEnter:
+		if !session.Submit() && multiline {
+			session.HandleKey(test.KeyTypeRune, '\n')
+		}
+	case input.KeyTab:
+		if tabs {
+			session.HandleKey(test.KeyTypeRune, '\t')
+		}
+	}
+}
+
+// keyReader reads one key at a time, giving up once ctx is done
+type keyReader interface {
+	ReadKeyContext(ctx context.Context) (input.KeyEvent, error)
+}
+
+// readKeys sends keys from reader to keys until ctx is done, or sends the
+// first read error to errs. A panic here would skip the deferred restore of
+// the terminal, so cleanup runs before the panic goes on.
+func readKeys(ctx context.Context, reader keyReader, keys chan<- input.KeyEvent, errs chan<- error, cleanup func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			cleanup()
+			panic(p)
+		}
+	}()
+	for {
+		key, err := reader.ReadKeyContext(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			select {
+			case errs <- err:
+			case <-ctx.Done():
+			}
+			return
+		}
+		select {
+		case keys <- key:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// newTarget builds the text for a test: the stored text for --repeat, or
+// freshly generated text for the selected mode
+func newTarget(ctx context.Context, opts *Options) (*test.Target, error) {
+	if opts.Repeat != "" {
+		target, err := repeatTarget(ctx, opts.Repeat)
+		if err != nil {
+			return nil, err
+		}
+		opts.Seconds = target.Metadata.Seconds
+		return target, nil
+	}
+	return generateTarget(ctx, opts)
+}
+
+// generateTarget creates a new target text for the selected mode
+func generateTarget(ctx context.Context, opts *Options) (*test.Target, error) {
+	gen, err := text.NewGenerator(text.GeneratorOptions{
+		Kind:           opts.Generator,
+		WordsFile:      opts.WordsFile,
+		Language:       opts.Language,
+		QuotesFile:     opts.QuotesFile,
+		Seed:           opts.Seed,
+		Punctuation:    opts.Punctuation,
+		Numbers:        opts.Numbers,
+		NumbersDensity: opts.NumbersDensity,
+		AllowRepeats:   opts.AllowRepeats,
+		MinWordLen:     opts.MinWordLen,
+		MaxWordLen:     opts.MaxWordLen,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize text generator: %w", err)
+	}
+
+	// Generate target based on mode
+	var target *test.Target
+	switch opts.Mode {
+	case "timer":
+		target, err = gen.GenerateForTimer(opts.Seconds)
+	case "words":
+		target, err = gen.GenerateWords(opts.Words)
+	case "quote":
+		if opts.QuoteID != "" {
+			target, err = gen.GetQuoteByID(opts.QuoteID)
+		} else if opts.QuoteSource != "" {
+			target, err = gen.GetRandomQuoteBySource(opts.QuoteSource)
+		} else {
+			target, err = gen.GetRandomQuote()
+		}
+	case "text", "code":
+		target, err = generateFromFile(gen, opts)
+	case "practice":
+		target, err = generatePractice(ctx, gen, opts)
+	case "zen":
+		target, err = generateZen(gen)
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", opts.Mode)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate target text: %w", err)
+	}
+
+	// The generator picked a seed if none was given; it is kept with the
+	// session so the text can be typed again with --seed
+	if opts.Mode != "text" && opts.Mode != "code" {
+		target.Metadata.Seed = gen.Seed()
+		target.Metadata.Difficulty = opts.Difficulty
+	}
+	return target, nil
+}
+
+// repeatLast is the --repeat value used when no session ID is given
+const repeatLast = "last"
+
+// repeatTarget rebuilds the target of a stored session so its exact text
+// can be typed again
+func repeatTarget(ctx context.Context, repeat string) (*test.Target, error) {
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer store.Close()
+
+	var session *sqlite.Session
+	if repeat == repeatLast {
+		sessions, err := store.ListSessionsContext(ctx, sqlite.SessionFilter{Limit: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if len(sessions) == 0 {
+			return nil, fmt.Errorf("no previous test to repeat")
+		}
+		session = &sessions[0]
+	} else {
+		id, err := strconv.ParseInt(repeat, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session ID: %s", repeat)
+		}
+		session, err = store.GetSessionContext(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+		if session == nil {
+			return nil, fmt.Errorf("session %d not found", id)
+		}
+	}
+
+	if session.TargetText == "" {
+		return nil, fmt.Errorf("session %d has no stored text to repeat", session.ID)
+	}
+
+	return &test.Target{
+		Text: session.TargetText,
+		Mode: test.Mode(session.Mode),
+		Metadata: test.TargetMetadata{
+			WordCount:  session.Words,
+			Seconds:    session.Seconds,
+			QuoteID:    session.QuoteID,
+			Source:     session.Source,
+			Seed:       session.Seed,
+			Difficulty: session.Difficulty,
+		},
+	}, nil
+}
+
+// difficultyPreset holds the generation options a --difficulty implies
+type difficultyPreset struct {
+	minWordLen  int
+	maxWordLen  int
+	punctuation bool
+	numbers     bool
+}
+
+// difficultyPresets maps each --difficulty to its preset: short common
+// words, the full list with punctuation, or long words with punctuation
+// and numbers
+var difficultyPresets = map[string]difficultyPreset{
+	"easy":   {maxWordLen: 5},
+	"medium": {punctuation: true},
+	"hard":   {minWordLen: 7, punctuation: true, numbers: true},
+}
+
+// applyDifficulty fills in the generation options from the --difficulty
+// preset. Options whose flag was set explicitly, as reported by changed,
+// are left alone so they override the preset.
+func applyDifficulty(opts *Options, changed func(name string) bool) error {
+	if opts.Difficulty == "" {
+		return nil
+	}
+	preset, ok := difficultyPresets[opts.Difficulty]
+	if !ok {
+		return fmt.Errorf("unknown difficulty: %s (use easy, medium, or hard)", opts.Difficulty)
+	}
+	if opts.Repeat != "" {
+		return fmt.Errorf("--difficulty cannot be combined with --repeat")
+	}
+	switch opts.Mode {
+	case "timer", "words", "practice":
+	default:
+		return fmt.Errorf("--difficulty only applies to timer, words, and practice modes")
+	}
+
+	if !changed("min-word-len") {
+		opts.MinWordLen = preset.minWordLen
+	}
+	if !changed("max-word-len") {
+		opts.MaxWordLen = preset.maxWordLen
+	}
+	if !changed("punctuation") {
+		opts.Punctuation = preset.punctuation
+	}
+	if !changed("numbers") {
+		opts.Numbers = preset.numbers
+	}
+	return nil
+}
+
+// correctionMode maps the correction flags to a test.Correction
+func correctionMode(opts *Options) test.Correction {
+	switch {
+	case opts.NoBackspace:
+		return test.CorrectionNoBackspace
+	case opts.StopOnError:
+		return test.CorrectionStopOnError
+	default:
+		return test.CorrectionNormal
+	}
+}
+
+// generateFromFile builds a text- or code-mode target from --file ("-"
+// reads stdin)
+func generateFromFile(gen text.Generator, opts *Options) (*test.Target, error) {
+	if opts.File == "" {
+		return nil, fmt.Errorf("%s mode requires --file", opts.Mode)
+	}
+
+	source := "stdin"
+	if opts.File != "-" {
+		source = filepath.Base(opts.File)
+	}
+	if opts.fileData == nil {
+		data, err := readFile(opts.File)
+		if err != nil {
+			return nil, err
+		}
+		opts.fileData = data
+	}
+
+	r := bytes.NewReader(opts.fileData)
+	if opts.Mode == "code" {
+		return gen.GenerateCode(r, source)
+	}
+	return gen.GenerateFromReader(r, source, opts.KeepNewlines)
+}
+
+// readFile reads all of path, or of stdin for "-"
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return data, nil
+	}
+	return os.ReadFile(path)
+}
+
+// practiceMinAttempts is how many times a key must have been typed before
+// its error rate is trusted for practice mode
+const practiceMinAttempts = 10
+
+// generatePractice builds a practice-mode target from the keys with the
+// highest historical error rate, falling back to regular words when there
+// is no key history yet
+func generatePractice(ctx context.Context, gen text.Generator, opts *Options) (*test.Target, error) {
+	store, err := sqlite.OpenContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	weakest, err := store.GetWeakestKeysContext(ctx, opts.PracticeKeys, practiceMinAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	var chars []rune
+	for _, stat := range weakest {
+		r := []rune(stat.Key)
+		if len(r) == 1 && r[0] != ' ' && r[0] != '\n' && r[0] != '\t' {
+			chars = append(chars, r[0])
+		}
+	}
+
+	if len(chars) > 0 {
+		target, err := gen.GeneratePractice(chars, opts.Words)
+		if err == nil {
+			return target, nil
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Not enough key history to practice yet, using regular words.")
+	return gen.GenerateWords(opts.Words)
+}
+
+// zenChunkWords is how many words zen mode generates at a time
+const zenChunkWords = 50
+
+// generateZen creates an endless target that draws more words from gen
+// whenever the caret nears the end
+func generateZen(gen text.Generator) (*test.Target, error) {
+	target, err := gen.GenerateWords(zenChunkWords)
+	if err != nil {
+		return nil, err
+	}
+	target.Mode = test.ModeZen
+	target.Metadata.WordCount = 0
+	target.Extend = func() string {
+		more, err := gen.GenerateWords(zenChunkWords)
+		if err != nil {
+			return ""
+		}
+		return more.Text
+	}
+	return target, nil
+}
+
 func buildRenderState(session *test.Session, state *test.SessionState, opts *Options) *ui.RenderState {
+	timeLimit := opts.MaxSeconds
+	if state.Target.Mode == test.ModeTimer {
+		timeLimit = opts.Seconds
+	}
+
 	return &ui.RenderState{
-		Target:     state.TargetRunes,
-		Typed:      state.TypedRunes,
-		CharStates: state.CharStates,
-		Mode:       state.Target.Mode,
-		Elapsed:    session.GetElapsed().Seconds(),
-		LiveWPM:    session.GetLiveWPM(),
-		TimeLimit:  opts.Seconds,
-		Finished:   state.Finished,
+		Target:       state.TargetRunes,
+		Typed:        state.TypedRunes,
+		CharStates:   state.CharStates,
+		Caret:        state.Caret,
+		Mode:         state.Target.Mode,
+		Elapsed:      session.GetElapsed().Seconds(),
+		LiveWPM:      session.GetLiveWPM(),
+		LiveRawWPM:   session.GetLiveRawWPM(),
+		LiveAccuracy: session.GetLiveAccuracy(),
+		TimeLimit:    timeLimit,
+		Finished:     state.Finished,
+		Paused:       session.IsPaused(),
+		Blind:        opts.Blind,
+		Minimal:      opts.Minimal,
+		RevealAhead:  opts.RevealAhead,
 	}
 }
 
-func saveSession(result *test.SessionResult) error {
-	store, err := sqlite.Open()
+// storedTargetText returns the target text to save. Timer mode generates
+// far more text than anyone types, so with a limit its unreached tail is
+// dropped; the typed part is always kept so show can line the two up.
+func storedTargetText(result *test.SessionResult, limit int) string {
+	target := []rune(result.TargetText)
+	if result.Mode != test.ModeTimer || limit <= 0 || len(target) <= limit {
+		return result.TargetText
+	}
+	keep := max(limit, utf8.RuneCountInString(result.TypedText))
+	return string(target[:min(keep, len(target))])
+}
+
+func saveSession(ctx context.Context, result *test.SessionResult, textLimit int) (int64, error) {
+	store, err := sqlite.OpenContext(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer store.Close()
 
 	// Convert to storage types
 	session := &sqlite.Session{
-		StartedAt:    result.StartedAt,
-		Mode:         string(result.Mode),
-		Seconds:      result.Metadata.Seconds,
-		Words:        result.Metadata.WordCount,
-		QuoteID:      result.Metadata.QuoteID,
-		TargetLen:    result.TargetLen,
-		DurationMs:   result.Duration.Milliseconds(),
-		CorrectChars: result.CorrectChars,
-		TotalTyped:   result.TotalTyped,
-		Accuracy:     result.Accuracy,
-		WPM:          result.WPM,
-		RawWPM:       result.RawWPM,
+		StartedAt:        result.StartedAt,
+		Mode:             string(result.Mode),
+		Seconds:          result.Metadata.Seconds,
+		Words:            result.Metadata.WordCount,
+		QuoteID:          result.Metadata.QuoteID,
+		TargetLen:        result.TargetLen,
+		DurationMs:       result.Duration.Milliseconds(),
+		CorrectChars:     result.CorrectChars,
+		IncorrectChars:   result.IncorrectChars,
+		TotalTyped:       result.TotalTyped,
+		Accuracy:         result.Accuracy,
+		WPM:              result.WPM,
+		RawWPM:           result.RawWPM,
+		Source:           result.Metadata.Source,
+		Consistency:      result.Consistency,
+		Correction:       string(result.Correction),
+		TargetWPM:        result.TargetWPM,
+		Corrections:      result.Corrections,
+		TimeToFirstKeyMs: result.TimeToFirstKeyMs,
+		PeakWPM:          result.PeakWPM,
+		MedianWPM:        result.MedianWPM,
+		TargetText:       storedTargetText(result, textLimit),
+		TargetHash:       sqlite.TargetHash(result.TargetText),
+		TypedText:        result.TypedText,
+		Seed:             result.Metadata.Seed,
+		Difficulty:       result.Metadata.Difficulty,
+		MistakeDrill:     result.Metadata.MistakeDrill,
+		FirstTryAccuracy: result.FirstTryAccuracy,
 	}
 
 	samples := make([]sqlite.SessionSample, len(result.Samples))
@@ -285,9 +1375,33 @@ func saveSession(result *test.SessionResult) error {
 			TimeMs: s.TimeMs,
 			WPM:    s.WPM,
 			RawWPM: s.RawWPM,
+			Errors: s.Errors,
 		}
 	}
 
-	_, err = store.SaveSession(session, samples)
-	return err
+	keyStats := make([]sqlite.SessionKeyStat, 0, len(result.KeyStats))
+	for key, stat := range result.KeyStats {
+		keyStats = append(keyStats, sqlite.SessionKeyStat{
+			Key:      string(key),
+			Attempts: stat.Attempts,
+			Errors:   stat.Errors,
+		})
+	}
+
+	return store.SaveSessionContext(ctx, session, samples, keyStats)
+}
+
+// terminalOutput returns where the test UI should be drawn: stdout, or the
+// controlling terminal when stdout is not one
+func terminalOutput() (*os.File, func()) {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		return os.Stdout, func() {}
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		// No terminal at all; draw on stdout as before
+		return os.Stdout, func() {}
+	}
+	return tty, func() { tty.Close() }
 }
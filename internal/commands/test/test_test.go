@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/input"
+)
+
+// scriptedReader returns its keys in order, then the result of last
+type scriptedReader struct {
+	keys []input.KeyEvent
+	last func() (input.KeyEvent, error)
+}
+
+func (r *scriptedReader) ReadKeyContext(ctx context.Context) (input.KeyEvent, error) {
+	if len(r.keys) > 0 {
+		key := r.keys[0]
+		r.keys = r.keys[1:]
+		return key, nil
+	}
+	return r.last()
+}
+
+func TestReadKeysPanicRunsCleanup(t *testing.T) {
+	reader := &scriptedReader{
+		keys: []input.KeyEvent{{Type: input.KeyRune, Rune: 'a'}},
+		last: func() (input.KeyEvent, error) { panic("reader broke") },
+	}
+	keys := make(chan input.KeyEvent, 1)
+	errs := make(chan error, 1)
+
+	cleaned := make(chan struct{})
+	cleanup := func() { close(cleaned) }
+
+	// The panic carries on past readKeys, as it would crash the program
+	repanicked := make(chan any, 1)
+	go func() {
+		defer func() { repanicked <- recover() }()
+		readKeys(context.Background(), reader, keys, errs, cleanup)
+	}()
+
+	select {
+	case p := <-repanicked:
+		if p != "reader broke" {
+			t.Errorf("panic = %v, want the reader's panic", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("readKeys did not panic")
+	}
+	select {
+	case <-cleaned:
+	default:
+		t.Error("cleanup did not run before the panic went on")
+	}
+	if key := <-keys; key.Rune != 'a' {
+		t.Errorf("key before the panic = %+v, want 'a'", key)
+	}
+}
+
+func TestReadKeysErrorSkipsCleanup(t *testing.T) {
+	readErr := errors.New("read failed")
+	reader := &scriptedReader{last: func() (input.KeyEvent, error) { return input.KeyEvent{}, readErr }}
+	errs := make(chan error, 1)
+
+	cleaned := false
+	readKeys(context.Background(), reader, nil, errs, func() { cleaned = true })
+
+	if err := <-errs; err != readErr {
+		t.Errorf("error = %v, want %v", err, readErr)
+	}
+	// The caller's deferred restore handles an ordinary return
+	if cleaned {
+		t.Error("cleanup ran without a panic")
+	}
+}
+
+func TestRestartFromStdinReusesText(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	w.WriteString("the cat sat")
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin; r.Close() })
+
+	// The first target and a restart, when stdin is already drained
+	opts := &Options{Mode: "text", File: "-"}
+	for i := range 2 {
+		target, err := newTarget(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("target %d: %v", i, err)
+		}
+		if target.Text != "the cat sat" {
+			t.Errorf("target %d text = %q, want %q", i, target.Text, "the cat sat")
+		}
+	}
+}
@@ -1,28 +1,75 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 
+	"github.com/mmdbasi/mtcli/internal/input"
 	"github.com/spf13/viper"
 )
 
 // Config holds the application configuration
 type Config struct {
 	// Test defaults
-	Mode     string `mapstructure:"mode"`
-	Seconds  int    `mapstructure:"seconds"`
-	Words    int    `mapstructure:"words"`
-	Countdown int   `mapstructure:"countdown"`
+	Mode      string `mapstructure:"mode"`
+	Seconds   int    `mapstructure:"seconds"`
+	Words     int    `mapstructure:"words"`
+	Countdown int    `mapstructure:"countdown"`
+
+	// Countdown display
+	CountdownStyle string `mapstructure:"countdown_style"` // number or words
+	CountdownWords string `mapstructure:"countdown_words"` // comma-separated labels for the words style
+
+	// Metrics
+	SampleIntervalMs int     `mapstructure:"sample_interval_ms"` // time between speed samples
+	AFKTimeout       float64 `mapstructure:"afk_timeout"`        // seconds without a key before the clock stops; 0 disables
 
 	// Display
-	NoColor bool `mapstructure:"no_color"`
-	Wrap    int  `mapstructure:"wrap"`
-	Chart   bool `mapstructure:"chart"`
+	NoColor      bool   `mapstructure:"no_color"`
+	Wrap         int    `mapstructure:"wrap"`
+	Chart        bool   `mapstructure:"chart"`
+	ChartStyle   string `mapstructure:"chart_style"`
+	CaretStyle   string `mapstructure:"caret_style"`
+	CaretBlinkMs int    `mapstructure:"caret_blink_ms"` // time the caret spends on and then off; 0 keeps it steady
+	Theme        string `mapstructure:"theme"`
+	ColorMode    string `mapstructure:"color_mode"`
+	Minimal      bool   `mapstructure:"minimal"` // no header line while typing
+
+	// Status line
+	ShowAccuracy bool    `mapstructure:"show_accuracy"`
+	ShowRaw      bool    `mapstructure:"show_raw"`
+	Unit         string  `mapstructure:"unit"`        // speed to lead with: wpm or cpm
+	PaceMargin   float64 `mapstructure:"pace_margin"` // percent around the 7-day average for live WPM colors; 0 disables
+
+	// Target text
+	ShowWhitespace bool `mapstructure:"show_whitespace"` // draw every space as a visible glyph
+
+	// Summary
+	ReviewPrompt bool   `mapstructure:"review_prompt"` // offer to retype missed words
+	Bell         string `mapstructure:"bell"`          // terminal bell: off, end, or best
+
+	// Daily goal, see 'mtcli goals'
+	GoalTests int     `mapstructure:"goal_tests"` // tests a day; 0 for no goal
+	GoalWPM   float64 `mapstructure:"goal_wpm"`   // WPM a test needs to count towards the goal; 0 counts every test
 
 	// Content
-	WordsFile  string `mapstructure:"words_file"`
-	QuotesFile string `mapstructure:"quotes_file"`
+	WordsFile      string  `mapstructure:"words_file"`
+	Language       string  `mapstructure:"language"`  // embedded word list; words_file wins
+	Generator      string  `mapstructure:"generator"` // word generator, see 'mtcli test --help'
+	QuotesFile     string  `mapstructure:"quotes_file"`
+	NumbersDensity float64 `mapstructure:"numbers_density"`
+
+	// Storage
+	DB string `mapstructure:"db"` // database path; empty for the default location
+
+	// Keys for in-test actions, by action name; see input.ParseKeymap
+	Keymap map[string]string `mapstructure:"keymap"`
+
+	// Test defaults for one mode, by mode name: a [modes.timer] section
+	// overrides the keys above in timer tests only; see ModeKeys
+	Modes map[string]map[string]any `mapstructure:"modes"`
 }
 
 var (
@@ -30,6 +77,7 @@ var (
 	// read defaults before Load() runs.
 	cfg        = Default()
 	configFile string
+	dbPath     string
 )
 
 // Default returns the default configuration
@@ -42,6 +90,24 @@ func Default() Config {
 		NoColor:   false,
 		Wrap:      0, // 0 means auto
 		Chart:     true,
+
+		CountdownStyle: "number",
+		CountdownWords: "Ready...,Set...,Go!",
+		ChartStyle:     "block",
+		CaretStyle:     "underline",
+		CaretBlinkMs:   500,
+		Theme:          "default",
+		ColorMode:      "auto",
+		NumbersDensity: 0.15,
+
+		SampleIntervalMs: 500,
+		AFKTimeout:       3,
+		Language:         "english",
+		Generator:        "default",
+		Keymap:           input.DefaultBindings(),
+		Unit:             "wpm",
+		PaceMargin:       5,
+		Bell:             "off",
 	}
 }
 
@@ -50,6 +116,21 @@ func SetConfigFile(path string) {
 	configFile = path
 }
 
+// SetDBPath sets a database path that overrides the config file and
+// environment
+func SetDBPath(path string) {
+	dbPath = path
+}
+
+// DBPath returns the database path from --db, MTCLI_DB, or the config file,
+// in that order; empty means the default location in the data directory
+func DBPath() string {
+	if dbPath != "" {
+		return dbPath
+	}
+	return cfg.DB
+}
+
 // Load reads the configuration from file and environment
 func Load() error {
 	cfg = Default()
@@ -74,9 +155,35 @@ func Load() error {
 	viper.SetDefault("seconds", cfg.Seconds)
 	viper.SetDefault("words", cfg.Words)
 	viper.SetDefault("countdown", cfg.Countdown)
+	viper.SetDefault("countdown_style", cfg.CountdownStyle)
+	viper.SetDefault("countdown_words", cfg.CountdownWords)
+	viper.SetDefault("sample_interval_ms", cfg.SampleIntervalMs)
+	viper.SetDefault("afk_timeout", cfg.AFKTimeout)
 	viper.SetDefault("no_color", cfg.NoColor)
 	viper.SetDefault("wrap", cfg.Wrap)
 	viper.SetDefault("chart", cfg.Chart)
+	viper.SetDefault("chart_style", cfg.ChartStyle)
+	viper.SetDefault("caret_style", cfg.CaretStyle)
+	viper.SetDefault("caret_blink_ms", cfg.CaretBlinkMs)
+	viper.SetDefault("theme", cfg.Theme)
+	viper.SetDefault("color_mode", cfg.ColorMode)
+	viper.SetDefault("minimal", cfg.Minimal)
+	viper.SetDefault("show_accuracy", cfg.ShowAccuracy)
+	viper.SetDefault("show_raw", cfg.ShowRaw)
+	viper.SetDefault("unit", cfg.Unit)
+	viper.SetDefault("pace_margin", cfg.PaceMargin)
+	viper.SetDefault("show_whitespace", cfg.ShowWhitespace)
+	viper.SetDefault("review_prompt", cfg.ReviewPrompt)
+	viper.SetDefault("bell", cfg.Bell)
+	viper.SetDefault("goal_tests", cfg.GoalTests)
+	viper.SetDefault("goal_wpm", cfg.GoalWPM)
+	viper.SetDefault("numbers_density", cfg.NumbersDensity)
+	viper.SetDefault("language", cfg.Language)
+	viper.SetDefault("generator", cfg.Generator)
+	viper.SetDefault("db", cfg.DB)
+	for action, key := range cfg.Keymap {
+		viper.SetDefault("keymap."+action, key)
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -85,7 +192,18 @@ func Load() error {
 		// Config file not found is OK
 	}
 
-	return viper.Unmarshal(&cfg)
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return err
+	}
+	if _, err := input.ParseKeymap(cfg.Keymap); err != nil {
+		cfg.Keymap = input.DefaultBindings()
+		return fmt.Errorf("invalid keymap, using the defaults: %w", err)
+	}
+	if err := checkModes(cfg.Modes); err != nil {
+		cfg.Modes = nil
+		return fmt.Errorf("invalid mode defaults, ignoring them: %w", err)
+	}
+	return nil
 }
 
 // Get returns the current configuration
@@ -102,12 +220,22 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(configDir, "mtcli"), nil
 }
 
-// GetDataDir returns the data directory path (for SQLite DB)
+// GetDataDir returns the data directory path (for SQLite DB):
+// $XDG_DATA_HOME/mtcli if set, ~/.local/share/mtcli on Linux and other
+// Unix systems, and the OS application data directory on macOS and Windows
 func GetDataDir() (string, error) {
-	configDir, err := GetConfigDir()
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "mtcli"), nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return GetConfigDir()
+	}
+
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return configDir, nil
+	return filepath.Join(home, ".local", "share", "mtcli"), nil
 }
-
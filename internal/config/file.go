@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+// FilePath returns the config file in use: the --config path if given,
+// otherwise the file Load found, otherwise where one would be created
+func FilePath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.toml"), nil
+}
+
+// ReadFile returns the defaults overlaid with the values in the file at
+// path, ignoring environment variables. A missing file yields the defaults.
+func ReadFile(path string) (Config, error) {
+	c := Default()
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	err := v.Unmarshal(&c)
+	return c, err
+}
+
+// WriteFile writes c as TOML to path, creating its directory if needed
+func WriteFile(c Config, path string) error {
+	data, err := toml.Marshal(c.ToMap())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Keys returns the config file keys in sorted order
+func Keys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, t.Field(i).Tag.Get("mapstructure"))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ToMap returns c keyed by config file key
+func (c Config) ToMap() map[string]interface{} {
+	v := reflect.ValueOf(c)
+	t := v.Type()
+	m := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		m[t.Field(i).Tag.Get("mapstructure")] = v.Field(i).Interface()
+	}
+	return m
+}
+
+// Set parses value according to the type of the field behind key and
+// stores it in c
+func (c *Config) Set(key, value string) error {
+	// Keymap entries are set one action at a time
+	if action, ok := strings.CutPrefix(key, "keymap."); ok {
+		c.Keymap = maps.Clone(c.Keymap)
+		if c.Keymap == nil {
+			c.Keymap = make(map[string]string)
+		}
+		c.Keymap[action] = value
+		return nil
+	}
+
+	// Mode defaults too, one key of one mode at a time; the value is parsed
+	// like the top-level key's so the file keeps its type
+	if rest, ok := strings.CutPrefix(key, "modes."); ok {
+		mode, name, ok := strings.Cut(rest, ".")
+		if !ok || !slices.Contains(modeKeys, name) {
+			return fmt.Errorf("unknown mode key: %s (use modes.<mode>.<key> with one of: %s)", key, strings.Join(ModeKeys(), ", "))
+		}
+		var scratch Config
+		if err := scratch.Set(name, value); err != nil {
+			return err
+		}
+
+		c.Modes = maps.Clone(c.Modes)
+		if c.Modes == nil {
+			c.Modes = make(map[string]map[string]any)
+		}
+		section := maps.Clone(c.Modes[mode])
+		if section == nil {
+			section = make(map[string]any)
+		}
+		section[name] = scratch.ToMap()[name]
+		c.Modes[mode] = section
+		return nil
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("mapstructure") != key {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer: %s", key, value)
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false: %s", key, value)
+			}
+			field.SetBool(b)
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number: %s", key, value)
+			}
+			field.SetFloat(f)
+		default:
+			return fmt.Errorf("cannot set %s", key)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown config key: %s (valid keys: %s)", key, strings.Join(Keys(), ", "))
+}
+
+// modeKeys are the keys a [modes.<mode>] section can override: the test
+// defaults other than the mode itself
+var modeKeys = []string{
+	"seconds", "words", "countdown", "countdown_style", "countdown_words",
+	"sample_interval_ms", "afk_timeout",
+	"no_color", "wrap", "chart", "chart_style", "caret_style", "caret_blink_ms", "theme", "color_mode", "minimal",
+	"show_accuracy", "show_raw", "unit", "pace_margin", "show_whitespace",
+	"review_prompt", "bell",
+	"words_file", "language", "generator", "quotes_file", "numbers_density",
+}
+
+// ModeKeys returns the keys a [modes.<mode>] section can override, in
+// sorted order
+func ModeKeys() []string {
+	return slices.Sorted(slices.Values(modeKeys))
+}
+
+// ForMode returns c with the [modes.<mode>] section, if there is one, over
+// its top-level keys
+func (c Config) ForMode(mode string) Config {
+	for key, value := range c.Modes[mode] {
+		// Load has already rejected values that don't fit their key
+		_ = c.Set(key, fmt.Sprint(value))
+	}
+	return c
+}
+
+// checkModes checks that every [modes.<mode>] key can be set per mode and
+// holds a value of the type of the top-level key
+func checkModes(modes map[string]map[string]any) error {
+	for mode, section := range modes {
+		for key, value := range section {
+			if !slices.Contains(modeKeys, key) {
+				return fmt.Errorf("%s can't be set in [modes.%s] (valid keys: %s)", key, mode, strings.Join(ModeKeys(), ", "))
+			}
+			var scratch Config
+			if err := scratch.Set(key, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("[modes.%s]: %w", mode, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package daterange
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// Parse returns the [since, until) range described by the two flag values.
+// Either may be empty, leaving that end unbounded as the zero time.
+//
+// Values are a date (YYYY-MM-DD, local time) or a relative duration
+// before now: "7d" for days or "2w" for weeks. A date given for until
+// includes that whole day.
+func Parse(since, until string, now time.Time) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if since != "" {
+		from, err = parseBound(since, false, now)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if until != "" {
+		to, err = parseBound(until, true, now)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("--since must be before --until")
+	}
+	return from, to, nil
+}
+
+// parseBound parses one date or relative duration. For dates, end selects
+// the following midnight so the range includes the day itself.
+func parseBound(s string, end bool, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation(dateFormat, s, time.Local); err == nil {
+		if end {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or duration (e.g. 7d, 2w)", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or duration (e.g. 7d, 2w)", s)
+	}
+
+	switch s[len(s)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	default:
+		return time.Time{}, fmt.Errorf("%q has an unknown unit (use d or w)", s)
+	}
+}
+
+// Describe returns a short human-readable form of the range, e.g.
+// "since 2024-03-01" or "2024-03-01 to 2024-03-31", or "" if unbounded
+func Describe(since, until time.Time) string {
+	// until is exclusive; show the last day it includes
+	last := until.Add(-time.Nanosecond)
+	switch {
+	case since.IsZero() && until.IsZero():
+		return ""
+	case until.IsZero():
+		return "since " + since.Format(dateFormat)
+	case since.IsZero():
+		return "until " + last.Format(dateFormat)
+	default:
+		return since.Format(dateFormat) + " to " + last.Format(dateFormat)
+	}
+}
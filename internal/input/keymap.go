@@ -0,0 +1,132 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Action is something a key can be bound to during a test
+type Action string
+
+const (
+	ActionNone       Action = ""
+	ActionAbort      Action = "abort"       // leave the test without saving
+	ActionRestart    Action = "restart"     // start over on fresh text
+	ActionPause      Action = "pause"       // freeze the clock until the next key
+	ActionDeleteWord Action = "delete-word" // erase back to the start of the word
+)
+
+// actions lists the bindable actions, in the order conflicts are reported
+var actions = []Action{ActionAbort, ActionRestart, ActionPause, ActionDeleteWord}
+
+// DefaultBindings returns the key for each action when the config file
+// names none
+func DefaultBindings() map[string]string {
+	return map[string]string{
+		string(ActionAbort):      "ctrl+c",
+		string(ActionRestart):    "tab",
+		string(ActionPause):      "esc",
+		string(ActionDeleteWord): "ctrl+w",
+	}
+}
+
+// Keymap resolves key events to the actions bound to them
+type Keymap map[KeyEvent]Action
+
+// ParseKeymap builds a keymap from action names to key specs, as in the
+// config file's keymap section. Actions left out keep their default key,
+// and "none" unbinds one. Unknown actions, unknown keys, two actions on one
+// key, and an unbound abort are errors.
+func ParseKeymap(bindings map[string]string) (Keymap, error) {
+	specs := DefaultBindings()
+	for action, spec := range bindings {
+		if _, ok := specs[action]; !ok {
+			return nil, fmt.Errorf("unknown action: %s (use %s)", action, actionNames())
+		}
+		specs[action] = spec
+	}
+
+	keymap := make(Keymap)
+	for _, action := range actions {
+		spec := specs[string(action)]
+		key, bound, err := ParseKey(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", action, err)
+		}
+		if !bound {
+			if action == ActionAbort {
+				return nil, fmt.Errorf("abort must be bound to a key")
+			}
+			continue
+		}
+		if other, ok := keymap[key]; ok {
+			return nil, fmt.Errorf("%s is bound to both %s and %s", spec, other, action)
+		}
+		keymap[key] = action
+	}
+	return keymap, nil
+}
+
+// ParseKey parses a key spec: esc, tab, or ctrl+ and a letter. It reports
+// false for "none", which binds nothing. Keys that can't be told apart
+// from typing are rejected.
+func ParseKey(spec string) (KeyEvent, bool, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	switch spec {
+	case "none":
+		return KeyEvent{}, false, nil
+	case "esc", "escape":
+		return KeyEvent{Type: KeyEscape}, true, nil
+	case "tab":
+		return KeyEvent{Type: KeyTab}, true, nil
+	case "ctrl+c":
+		return KeyEvent{Type: KeyCtrlC}, true, nil
+	}
+
+	if letter, ok := strings.CutPrefix(spec, "ctrl+"); ok && len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' {
+		switch letter[0] {
+		case 'h', 'i', 'm':
+			return KeyEvent{}, false, fmt.Errorf("%s sends the same code as backspace, tab, or enter", spec)
+		}
+		return KeyEvent{Type: KeyCtrl, Rune: rune(letter[0])}, true, nil
+	}
+	return KeyEvent{}, false, fmt.Errorf("unknown key: %q (use esc, tab, ctrl+<letter>, or none)", spec)
+}
+
+// Resolve returns the action bound to key, or ActionNone
+func (k Keymap) Resolve(key KeyEvent) Action {
+	if key.Type == KeyPaste {
+		return ActionNone
+	}
+	return k[KeyEvent{Type: key.Type, Rune: key.Rune}]
+}
+
+// Name returns how the key bound to action is written in help text, such
+// as "Ctrl+W", or "" if the action is unbound
+func (k Keymap) Name(action Action) string {
+	for key, bound := range k {
+		if bound != action {
+			continue
+		}
+		switch key.Type {
+		case KeyEscape:
+			return "Esc"
+		case KeyTab:
+			return "Tab"
+		case KeyCtrlC:
+			return "Ctrl+C"
+		case KeyCtrl:
+			return "Ctrl+" + strings.ToUpper(string(key.Rune))
+		}
+	}
+	return ""
+}
+
+// actionNames lists the bindable actions for error messages
+func actionNames() string {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = string(action)
+	}
+	return strings.Join(names, ", ")
+}
@@ -2,42 +2,87 @@ package input
 
 import (
 	"bufio"
+	"context"
 	"os"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"golang.org/x/term"
+	"golang.org/x/text/unicode/norm"
 )
 
+// pasteThreshold is how many bytes arriving at once count as a paste in
+// terminals without bracketed paste; no one types that fast
+const pasteThreshold = 32
+
+// maxPasteBytes bounds how much of a paste is kept; the rest is discarded
+const maxPasteBytes = 64 << 10
+
 // RawReader reads keyboard input in raw terminal mode
 type RawReader struct {
 	oldState *term.State
+	file     *os.File
 	reader   *bufio.Reader
+	wake     waker // interrupts a ReadKeyContext waiting for input
 }
 
-// NewRawReader creates a new raw input reader
+// NewRawReader creates a new raw input reader. Keys are read from stdin,
+// or from the controlling terminal when stdin is not a terminal (e.g. when
+// the test text itself is piped in).
 func NewRawReader() *RawReader {
+	file := os.Stdin
+	if !term.IsTerminal(int(file.Fd())) {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			file = tty
+		}
+	}
+
 	return &RawReader{
-		reader: bufio.NewReader(os.Stdin),
+		file:   file,
+		reader: bufio.NewReader(file),
 	}
 }
 
+// File returns the file keys are read from
+func (r *RawReader) File() *os.File {
+	return r.file
+}
+
 // Init puts the terminal in raw mode
 func (r *RawReader) Init() error {
 	var err error
-	r.oldState, err = term.MakeRaw(int(os.Stdin.Fd()))
+	r.oldState, err = term.MakeRaw(int(r.file.Fd()))
 	return err
 }
 
-// Cleanup restores the terminal to its original state
+// Cleanup restores the terminal to its original state. Calling it more
+// than once is safe; only the first call restores.
 func (r *RawReader) Cleanup() error {
-	if r.oldState != nil {
-		return term.Restore(int(os.Stdin.Fd()), r.oldState)
+	if r.oldState == nil {
+		return nil
 	}
-	return nil
+	oldState := r.oldState
+	r.oldState = nil
+	return term.Restore(int(r.file.Fd()), oldState)
 }
 
 // ReadKey reads a single key event from stdin
 func (r *RawReader) ReadKey() (KeyEvent, error) {
+	return r.ReadKeyContext(context.Background())
+}
+
+// ReadKeyContext reads a single key event from stdin, giving up with
+// ctx.Err() once ctx is done. Cancelling only interrupts the wait for a key
+// to start; the rest of a key's bytes arrive together with the first. The
+// terminal is left as it was, so Cleanup still restores it afterwards.
+func (r *RawReader) ReadKeyContext(ctx context.Context) (KeyEvent, error) {
+	if r.reader.Buffered() == 0 {
+		if err := r.wait(ctx); err != nil {
+			return KeyEvent{Type: KeyUnknown}, err
+		}
+	}
+
 	buf := make([]byte, 4) // UTF-8 can be up to 4 bytes
 
 	// Read first byte
@@ -51,6 +96,12 @@ func (r *RawReader) ReadKey() (KeyEvent, error) {
 
 	b := buf[0]
 
+	// A burst of bytes too large to be typing is a paste from a terminal
+	// that doesn't bracket them
+	if b != 27 && r.reader.Buffered() >= pasteThreshold {
+		return r.readBurst(b), nil
+	}
+
 	// Handle control characters
 	switch b {
 	case 3: // Ctrl+C
@@ -58,22 +109,25 @@ func (r *RawReader) ReadKey() (KeyEvent, error) {
 	case 27: // Escape or escape sequence
 		// Check if there's more data (escape sequence)
 		if r.reader.Buffered() > 0 {
-			// Read escape sequence
-			seq := make([]byte, 2)
-			r.reader.Read(seq)
-			// For now, ignore escape sequences (arrow keys, etc.)
-			return KeyEvent{Type: KeyUnknown}, nil
+			return r.readEscapeSequence(), nil
 		}
 		return KeyEvent{Type: KeyEscape}, nil
 	case 13: // Enter/Return
 		return KeyEvent{Type: KeyEnter}, nil
+	case 9: // Tab
+		return KeyEvent{Type: KeyTab}, nil
 	case 127, 8: // Backspace (127 = DEL on most terminals, 8 = BS)
 		return KeyEvent{Type: KeyBackspace}, nil
 	}
 
+	// Other Ctrl+letter combinations, for the keymap
+	if b >= 1 && b <= 26 {
+		return KeyEvent{Type: KeyCtrl, Rune: rune('a' + b - 1)}, nil
+	}
+
 	// Handle printable ASCII
 	if b >= 32 && b < 127 {
-		return KeyEvent{Type: KeyRune, Rune: rune(b)}, nil
+		return KeyEvent{Type: KeyRune, Rune: r.compose(rune(b))}, nil
 	}
 
 	// Handle UTF-8 multi-byte sequences
@@ -100,10 +154,133 @@ func (r *RawReader) ReadKey() (KeyEvent, error) {
 		// Decode the rune
 		ru, _ := utf8.DecodeRune(buf[:runeLen])
 		if ru != utf8.RuneError {
-			return KeyEvent{Type: KeyRune, Rune: ru}, nil
+			return KeyEvent{Type: KeyRune, Rune: r.compose(ru)}, nil
 		}
 	}
 
 	return KeyEvent{Type: KeyUnknown}, nil
 }
 
+// compose folds combining marks that arrived together with base, as input
+// methods and pastes send them, into a single precomposed character. Only
+// buffered bytes are looked at, and a mark that doesn't compose is left for
+// the next ReadKey.
+func (r *RawReader) compose(base rune) rune {
+	for r.reader.Buffered() > 0 {
+		peek, _ := r.reader.Peek(min(utf8.UTFMax, r.reader.Buffered()))
+		mark, size := utf8.DecodeRune(peek)
+		if !unicode.Is(unicode.M, mark) {
+			break
+		}
+		composed := []rune(norm.NFC.String(string([]rune{base, mark})))
+		if len(composed) != 1 {
+			break
+		}
+		base = composed[0]
+		r.reader.Discard(size)
+	}
+	return base
+}
+
+// readEscapeSequence parses the bytes following an ESC. Only bytes that are
+// already buffered are consumed, so a partial or malformed sequence yields
+// KeyUnknown instead of blocking for input that may never arrive.
+func (r *RawReader) readEscapeSequence() KeyEvent {
+	intro, err := r.reader.ReadByte()
+	if err != nil || (intro != '[' && intro != 'O') {
+		return KeyEvent{Type: KeyUnknown}
+	}
+
+	// Collect parameter bytes up to the final byte (0x40-0x7E)
+	var params []byte
+	for r.reader.Buffered() > 0 {
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			return KeyEvent{Type: KeyUnknown}
+		}
+		if b < 0x40 || b > 0x7E {
+			params = append(params, b)
+			continue
+		}
+		key := decodeEscapeSequence(b, string(params))
+		if key == KeyPaste {
+			return r.readPaste()
+		}
+		return KeyEvent{Type: key}
+	}
+
+	return KeyEvent{Type: KeyUnknown}
+}
+
+// pasteEnd is the sequence that closes a bracketed paste
+const pasteEnd = "\033[201~"
+
+// readPaste reads a bracketed paste up to its closing ESC[201~. Unlike
+// other sequences this blocks, since the terminal always sends the end
+// marker, however long the paste.
+func (r *RawReader) readPaste() KeyEvent {
+	var text []byte
+	var tail []byte // the last bytes read, to spot pasteEnd
+	for {
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			break
+		}
+		tail = append(tail, b)
+		if len(tail) > len(pasteEnd) {
+			tail = tail[1:]
+		}
+		if string(tail) == pasteEnd {
+			break
+		}
+		if len(text) < maxPasteBytes {
+			text = append(text, b)
+		}
+	}
+	text = []byte(strings.TrimSuffix(string(text), pasteEnd[:len(pasteEnd)-1]))
+	return KeyEvent{Type: KeyPaste, Text: string(text)}
+}
+
+// readBurst returns first and everything buffered behind it as a paste
+func (r *RawReader) readBurst(first byte) KeyEvent {
+	data, _ := r.reader.Peek(r.reader.Buffered())
+	text := append([]byte{first}, data...)
+	r.reader.Discard(len(data))
+	if len(text) > maxPasteBytes {
+		text = text[:maxPasteBytes]
+	}
+	return KeyEvent{Type: KeyPaste, Text: string(text)}
+}
+
+// decodeEscapeSequence maps the final byte and parameters of a CSI/SS3
+// sequence to a key type
+func decodeEscapeSequence(final byte, params string) KeyType {
+	switch final {
+	case 'A':
+		return KeyArrowUp
+	case 'B':
+		return KeyArrowDown
+	case 'C':
+		return KeyArrowRight
+	case 'D':
+		return KeyArrowLeft
+	case 'H':
+		return KeyHome
+	case 'F':
+		return KeyEnd
+	case '~':
+		// VT-style sequences: ESC [ 1 ~ / ESC [ 7 ~ (Home), ESC [ 3 ~ (Delete),
+		// ESC [ 4 ~ / ESC [ 8 ~ (End)
+		switch params {
+		case "1", "7":
+			return KeyHome
+		case "3":
+			return KeyDelete
+		case "4", "8":
+			return KeyEnd
+		case "200":
+			return KeyPaste
+		}
+	}
+	return KeyUnknown
+}
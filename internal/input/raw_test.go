@@ -0,0 +1,104 @@
+//go:build unix
+
+package input
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// pipeReader returns a RawReader reading from a pipe, and the pipe's
+// write end for feeding it keys
+func pipeReader(t *testing.T) (*RawReader, *os.File) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+	return &RawReader{file: r, reader: bufio.NewReader(r)}, w
+}
+
+// readResult is what a ReadKeyContext call returned
+type readResult struct {
+	key KeyEvent
+	err error
+}
+
+func readAsync(ctx context.Context, reader *RawReader) <-chan readResult {
+	done := make(chan readResult, 1)
+	go func() {
+		key, err := reader.ReadKeyContext(ctx)
+		done <- readResult{key, err}
+	}()
+	return done
+}
+
+func TestReadKeyContextCancel(t *testing.T) {
+	reader, _ := pipeReader(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := readAsync(ctx, reader)
+
+	// Nothing is written, so the read blocks until cancelled
+	select {
+	case res := <-done:
+		t.Fatalf("ReadKeyContext returned %+v, %v before cancel", res.key, res.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case res := <-done:
+		if !errors.Is(res.err, context.Canceled) || res.err != ctx.Err() {
+			t.Errorf("ReadKeyContext error = %v, want %v", res.err, ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadKeyContext did not return within 1s of cancel")
+	}
+}
+
+func TestReadKeyContextDeadline(t *testing.T) {
+	reader, _ := pipeReader(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	select {
+	case res := <-readAsync(ctx, reader):
+		if !errors.Is(res.err, context.DeadlineExceeded) {
+			t.Errorf("ReadKeyContext error = %v, want %v", res.err, context.DeadlineExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadKeyContext did not return within 1s of the deadline")
+	}
+}
+
+func TestReadKeyContextAfterCancelledWait(t *testing.T) {
+	reader, w := pipeReader(t)
+
+	// A cancelled wait leaves the reader usable for the next key
+	ctx, cancel := context.WithCancel(context.Background())
+	done := readAsync(ctx, reader)
+	cancel()
+	if res := <-done; res.err != context.Canceled {
+		t.Fatalf("ReadKeyContext error = %v, want %v", res.err, context.Canceled)
+	}
+
+	if _, err := w.Write([]byte("a")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case res := <-readAsync(context.Background(), reader):
+		if res.err != nil || res.key.Type != KeyRune || res.key.Rune != 'a' {
+			t.Errorf("ReadKeyContext = %+v, %v, want the rune 'a'", res.key, res.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadKeyContext did not return the written key within 1s")
+	}
+}
@@ -1,21 +1,58 @@
 package input
 
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
 // KeyType represents the type of key pressed
 type KeyType int
 
 const (
-	KeyRune      KeyType = iota // Regular printable character
-	KeyBackspace               // Backspace/Delete
-	KeyEnter                   // Enter/Return
-	KeyEscape                  // Escape
-	KeyCtrlC                   // Ctrl+C
-	KeyUnknown                 // Unknown/unhandled key
+	KeyRune       KeyType = iota // Regular printable character
+	KeyBackspace                 // Backspace/Delete
+	KeyEnter                     // Enter/Return
+	KeyTab                       // Tab
+	KeyEscape                    // Escape
+	KeyCtrlC                     // Ctrl+C
+	KeyCtrl                      // Another Ctrl+letter; Rune holds the letter
+	KeyArrowUp                   // Up arrow
+	KeyArrowDown                 // Down arrow
+	KeyArrowLeft                 // Left arrow
+	KeyArrowRight                // Right arrow
+	KeyHome                      // Home
+	KeyEnd                       // End
+	KeyDelete                    // Delete (forward delete)
+	KeyPaste                     // Pasted text, delivered as one event
+	KeyUnknown                   // Unknown/unhandled key
 )
 
 // KeyEvent represents a keyboard input event
 type KeyEvent struct {
 	Type KeyType
-	Rune rune // Only valid when Type == KeyRune
+	Rune rune   // Only valid when Type == KeyRune or KeyCtrl
+	Text string // Only valid when Type == KeyPaste
+}
+
+// PastedKeys returns the key events that typing text would produce, for
+// replaying a paste as keystrokes. Line breaks become Enter and tabs Tab.
+func PastedKeys(text string) []KeyEvent {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var keys []KeyEvent
+	for _, r := range text {
+		switch r {
+		case '\r', '\n':
+			keys = append(keys, KeyEvent{Type: KeyEnter})
+		case '\t':
+			keys = append(keys, KeyEvent{Type: KeyTab})
+		default:
+			if unicode.IsPrint(r) || unicode.Is(unicode.M, r) {
+				keys = append(keys, KeyEvent{Type: KeyRune, Rune: r})
+			}
+		}
+	}
+	return keys
 }
 
 // Reader defines the interface for reading keyboard input
@@ -26,7 +63,10 @@ type Reader interface {
 	// ReadKey reads a single key event (blocking)
 	ReadKey() (KeyEvent, error)
 
+	// ReadKeyContext reads a single key event, or stops waiting for one
+	// when ctx is done
+	ReadKeyContext(ctx context.Context) (KeyEvent, error)
+
 	// Cleanup restores terminal state
 	Cleanup() error
 }
-
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package input
+
+import "context"
+
+// waker is unused where the terminal can't be polled
+type waker struct{}
+
+// wait only checks ctx before blocking in the read, so cancellation takes
+// effect at the next key
+func (r *RawReader) wait(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package input
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// waker is a self-pipe: writing a byte to it wakes a poll waiting on the
+// terminal. It is opened on first use and lives as long as the reader.
+type waker struct {
+	once sync.Once
+	fds  [2]int
+	err  error
+}
+
+// open creates the pipe on first use
+func (w *waker) open() error {
+	w.once.Do(func() {
+		if w.err = unix.Pipe(w.fds[:]); w.err != nil {
+			return
+		}
+		for _, fd := range w.fds {
+			unix.CloseOnExec(fd)
+			if w.err = unix.SetNonblock(fd, true); w.err != nil {
+				return
+			}
+		}
+	})
+	return w.err
+}
+
+// signal wakes the poll in wait
+func (w *waker) signal() {
+	unix.Write(w.fds[1], []byte{0})
+}
+
+// drain empties the pipe after a wakeup
+func (w *waker) drain() {
+	var buf [16]byte
+	for {
+		if n, err := unix.Read(w.fds[0], buf[:]); n <= 0 || err != nil {
+			return
+		}
+	}
+}
+
+// wait blocks until the terminal has input or ctx is done
+func (r *RawReader) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := r.wake.open(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, r.wake.signal)
+	defer stop()
+
+	for {
+		fds := []unix.PollFd{
+			{Fd: int32(r.file.Fd()), Events: unix.POLLIN},
+			{Fd: int32(r.wake.fds[0]), Events: unix.POLLIN},
+		}
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return err
+		}
+		if fds[1].Revents != 0 {
+			// A wakeup left over from an earlier, finished wait is ignored
+			r.wake.drain()
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if fds[0].Revents != 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Package notify sends desktop notifications
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Notifier delivers a desktop notification
+type Notifier interface {
+	Notify(title, body string) error
+}
+
+// New returns the notifier for this platform: notify-send on Linux and the
+// BSDs, osascript on macOS. Elsewhere every notification fails.
+func New() Notifier {
+	switch runtime.GOOS {
+	case "darwin":
+		return command{name: "osascript", args: func(title, body string) []string {
+			return []string{"-e", fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))}
+		}}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return command{name: "notify-send", args: func(title, body string) []string {
+			return []string{title, body}
+		}}
+	default:
+		return unsupported{}
+	}
+}
+
+// command notifies by running an external program
+type command struct {
+	name string
+	args func(title, body string) []string
+}
+
+func (c command) Notify(title, body string) error {
+	if err := exec.Command(c.name, c.args(title, body)...).Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", c.name, err)
+	}
+	return nil
+}
+
+// unsupported is the notifier of platforms without one
+type unsupported struct{}
+
+func (unsupported) Notify(title, body string) error {
+	return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
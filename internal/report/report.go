@@ -0,0 +1,302 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mmdbasi/mtcli/internal/storage/sqlite"
+	"github.com/mmdbasi/mtcli/internal/test"
+)
+
+// Session is the JSON representation of a session, shared by export,
+// history --json and test --output json. Field names are stable.
+type Session struct {
+	ID               int64     `json:"id"`
+	StartedAt        time.Time `json:"started_at"`
+	Mode             string    `json:"mode"`
+	Seconds          int       `json:"seconds"`
+	Words            int       `json:"words"`
+	QuoteID          string    `json:"quote_id"`
+	Source           string    `json:"source"`
+	Seed             int64     `json:"seed"`
+	TargetLen        int       `json:"target_len"`
+	DurationMs       int64     `json:"duration_ms"`
+	TimeToFirstKeyMs int64     `json:"time_to_first_key_ms"`
+	CorrectChars     int       `json:"correct_chars"`
+	IncorrectChars   int       `json:"incorrect_chars"`
+	TotalTyped       int       `json:"total_typed"`
+	Corrections      int       `json:"corrections"`
+	Accuracy         float64   `json:"accuracy"`
+	FirstTryAccuracy float64   `json:"first_try_accuracy"`
+	WPM              float64   `json:"wpm"`
+	RawWPM           float64   `json:"raw_wpm"`
+	CPM              float64   `json:"cpm"`
+	RawCPM           float64   `json:"raw_cpm"`
+	Consistency      float64   `json:"consistency"`
+	PeakWPM          float64   `json:"peak_wpm"`
+	MedianWPM        float64   `json:"median_wpm"`
+	TargetWPM        float64   `json:"target_wpm"`
+	Difficulty       string    `json:"difficulty"`
+	MistakeDrill     bool      `json:"mistake_drill"`
+	Correction       string    `json:"correction,omitempty"`         // "no-backspace", "stop-on-error", or empty
+	TargetHash       string    `json:"target_hash,omitempty"`        // SHA-256 of the target text, in hex
+	SyncedAt         time.Time `json:"synced_at,omitzero"`           // when sync first wrote it to a snapshot; sync only
+	PersonalBest     string    `json:"personal_best,omitempty"`      // "overall" or "mode"; test output only
+	AvgKeyLatencyMs  float64   `json:"avg_key_latency_ms,omitempty"` // test output only
+	P95KeyLatencyMs  float64   `json:"p95_key_latency_ms,omitempty"` // test output only
+	KeyTimesMs       []int64   `json:"key_times_ms,omitempty"`       // test output only
+	Samples          []Sample  `json:"samples,omitempty"`
+}
+
+// Sample is the JSON representation of a speed sample
+type Sample struct {
+	TimeMs int64   `json:"time_ms"`
+	WPM    float64 `json:"wpm"`
+	RawWPM float64 `json:"raw_wpm"`
+	Errors int     `json:"errors"`
+}
+
+// FromStored converts a stored session, without samples
+func FromStored(s *sqlite.Session) Session {
+	return Session{
+		ID:               s.ID,
+		StartedAt:        s.StartedAt,
+		Mode:             s.Mode,
+		Seconds:          s.Seconds,
+		Words:            s.Words,
+		QuoteID:          s.QuoteID,
+		Source:           s.Source,
+		Seed:             s.Seed,
+		TargetLen:        s.TargetLen,
+		DurationMs:       s.DurationMs,
+		TimeToFirstKeyMs: s.TimeToFirstKeyMs,
+		CorrectChars:     s.CorrectChars,
+		IncorrectChars:   s.IncorrectChars,
+		TotalTyped:       s.TotalTyped,
+		Corrections:      s.Corrections,
+		Accuracy:         s.Accuracy,
+		FirstTryAccuracy: s.FirstTryAccuracy,
+		WPM:              s.WPM,
+		RawWPM:           s.RawWPM,
+		CPM:              s.WPM * 5, // not stored; WPM counts five characters a word
+		RawCPM:           s.RawWPM * 5,
+		Consistency:      s.Consistency,
+		PeakWPM:          s.PeakWPM,
+		MedianWPM:        s.MedianWPM,
+		TargetWPM:        s.TargetWPM,
+		Difficulty:       s.Difficulty,
+		MistakeDrill:     s.MistakeDrill,
+		Correction:       s.Correction,
+		TargetHash:       s.TargetHash,
+	}
+}
+
+// ToStored converts an exported session back for saving, with its
+// samples. The ID is left for the database to assign; fields the export
+// doesn't carry, such as the target text, stay empty.
+func (s Session) ToStored() (*sqlite.Session, []sqlite.SessionSample) {
+	stored := &sqlite.Session{
+		StartedAt:        s.StartedAt,
+		Mode:             s.Mode,
+		Seconds:          s.Seconds,
+		Words:            s.Words,
+		QuoteID:          s.QuoteID,
+		Source:           s.Source,
+		Seed:             s.Seed,
+		TargetLen:        s.TargetLen,
+		DurationMs:       s.DurationMs,
+		TimeToFirstKeyMs: s.TimeToFirstKeyMs,
+		CorrectChars:     s.CorrectChars,
+		IncorrectChars:   s.IncorrectChars,
+		TotalTyped:       s.TotalTyped,
+		Corrections:      s.Corrections,
+		Accuracy:         s.Accuracy,
+		FirstTryAccuracy: s.FirstTryAccuracy,
+		WPM:              s.WPM,
+		RawWPM:           s.RawWPM,
+		Consistency:      s.Consistency,
+		PeakWPM:          s.PeakWPM,
+		MedianWPM:        s.MedianWPM,
+		TargetWPM:        s.TargetWPM,
+		Difficulty:       s.Difficulty,
+		MistakeDrill:     s.MistakeDrill,
+		Correction:       s.Correction,
+		TargetHash:       s.TargetHash,
+	}
+
+	samples := make([]sqlite.SessionSample, len(s.Samples))
+	for i, sample := range s.Samples {
+		samples[i] = sqlite.SessionSample{TimeMs: sample.TimeMs, WPM: sample.WPM, RawWPM: sample.RawWPM, Errors: sample.Errors}
+	}
+	return stored, samples
+}
+
+// Validate checks that s is a session mtcli could have recorded
+func (s Session) Validate() error {
+	switch test.Mode(s.Mode) {
+	case test.ModeTimer, test.ModeWords, test.ModeQuote, test.ModeText, test.ModePractice, test.ModeCode, test.ModeZen:
+	default:
+		return fmt.Errorf("unknown mode: %q", s.Mode)
+	}
+	switch test.Correction(s.Correction) {
+	case test.CorrectionNormal, test.CorrectionNoBackspace, test.CorrectionStopOnError:
+	default:
+		return fmt.Errorf("unknown correction: %q", s.Correction)
+	}
+	if s.TargetHash != "" && !isTargetHash(s.TargetHash) {
+		return fmt.Errorf("invalid target_hash: %q", s.TargetHash)
+	}
+	if s.StartedAt.IsZero() {
+		return fmt.Errorf("missing started_at")
+	}
+	if s.DurationMs < 0 || s.TargetLen < 0 || s.CorrectChars < 0 || s.IncorrectChars < 0 || s.TotalTyped < 0 || s.Corrections < 0 {
+		return fmt.Errorf("negative duration or character count")
+	}
+	if s.Accuracy < 0 || s.Accuracy > 100 {
+		return fmt.Errorf("accuracy out of range: %.1f", s.Accuracy)
+	}
+	if s.FirstTryAccuracy < 0 || s.FirstTryAccuracy > 100 {
+		return fmt.Errorf("first_try_accuracy out of range: %.1f", s.FirstTryAccuracy)
+	}
+	if s.WPM < 0 || s.RawWPM < 0 {
+		return fmt.Errorf("negative WPM")
+	}
+	for _, sample := range s.Samples {
+		if sample.TimeMs < 0 || sample.WPM < 0 || sample.RawWPM < 0 {
+			return fmt.Errorf("invalid sample at %dms", sample.TimeMs)
+		}
+	}
+	return nil
+}
+
+// isTargetHash reports whether hash looks like sqlite.TargetHash output
+func isTargetHash(hash string) bool {
+	b, err := hex.DecodeString(hash)
+	return err == nil && len(b) == sha256.Size
+}
+
+// ReadSessions decodes and validates an array of sessions in the export
+// format
+func ReadSessions(r io.Reader) ([]Session, error) {
+	dec := json.NewDecoder(r)
+	var sessions []Session
+	if err := dec.Decode(&sessions); err != nil {
+		return nil, fmt.Errorf("%w (expected the output of 'mtcli export --format json')", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the session array")
+	}
+
+	for i, session := range sessions {
+		if err := session.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid entry %d: %w", i+1, err)
+		}
+	}
+	return sessions, nil
+}
+
+// SamplesFromStored converts stored speed samples
+func SamplesFromStored(samples []sqlite.SessionSample) []Sample {
+	out := make([]Sample, len(samples))
+	for i, s := range samples {
+		out[i] = Sample{TimeMs: s.TimeMs, WPM: s.WPM, RawWPM: s.RawWPM, Errors: s.Errors}
+	}
+	return out
+}
+
+// FromResult converts a finished test result, including its samples. The
+// ID is left for the caller to fill in once the session is saved.
+func FromResult(r *test.SessionResult) Session {
+	samples := make([]Sample, len(r.Samples))
+	for i, s := range r.Samples {
+		samples[i] = Sample{TimeMs: s.TimeMs, WPM: s.WPM, RawWPM: s.RawWPM, Errors: s.Errors}
+	}
+
+	return Session{
+		StartedAt:        r.StartedAt,
+		Mode:             string(r.Mode),
+		Seconds:          r.Metadata.Seconds,
+		Words:            r.Metadata.WordCount,
+		QuoteID:          r.Metadata.QuoteID,
+		Source:           r.Metadata.Source,
+		Seed:             r.Metadata.Seed,
+		TargetLen:        r.TargetLen,
+		DurationMs:       r.Duration.Milliseconds(),
+		TimeToFirstKeyMs: r.TimeToFirstKeyMs,
+		CorrectChars:     r.CorrectChars,
+		IncorrectChars:   r.IncorrectChars,
+		TotalTyped:       r.TotalTyped,
+		Corrections:      r.Corrections,
+		Accuracy:         r.Accuracy,
+		FirstTryAccuracy: r.FirstTryAccuracy,
+		WPM:              r.WPM,
+		RawWPM:           r.RawWPM,
+		CPM:              r.CPM,
+		RawCPM:           r.RawCPM,
+		Consistency:      r.Consistency,
+		PeakWPM:          r.PeakWPM,
+		MedianWPM:        r.MedianWPM,
+		TargetWPM:        r.TargetWPM,
+		Difficulty:       r.Metadata.Difficulty,
+		MistakeDrill:     r.Metadata.MistakeDrill,
+		Correction:       string(r.Correction),
+		TargetHash:       sqlite.TargetHash(r.TargetText),
+		PersonalBest:     string(r.Record),
+		AvgKeyLatencyMs:  r.AvgKeyLatencyMs,
+		P95KeyLatencyMs:  r.P95KeyLatencyMs,
+		KeyTimesMs:       r.KeyTimesMs,
+		Samples:          samples,
+	}
+}
+
+// Stats is the JSON representation of the aggregate statistics
+type Stats struct {
+	TotalTests         int                  `json:"total_tests"`
+	TotalTimeMs        int64                `json:"total_time_ms"`
+	AverageWPM         float64              `json:"average_wpm"`
+	BestWPM            float64              `json:"best_wpm"`
+	BestPeakWPM        float64              `json:"best_peak_wpm"`
+	AverageAccuracy    float64              `json:"average_accuracy"`
+	BestAccuracy       float64              `json:"best_accuracy"`
+	AverageConsistency float64              `json:"average_consistency"`
+	Last7DaysAvgWPM    float64              `json:"last_7_days_avg_wpm"`
+	Last30DaysAvgWPM   float64              `json:"last_30_days_avg_wpm"`
+	ModeStats          map[string]ModeStats `json:"mode_stats"`
+}
+
+// ModeStats is the JSON representation of one mode's statistics
+type ModeStats struct {
+	TestCount  int     `json:"test_count"`
+	AverageWPM float64 `json:"average_wpm"`
+	BestWPM    float64 `json:"best_wpm"`
+}
+
+// FromStats converts the stored aggregate statistics
+func FromStats(s *sqlite.Stats) Stats {
+	modes := make(map[string]ModeStats, len(s.ModeStats))
+	for mode, m := range s.ModeStats {
+		modes[mode] = ModeStats{
+			TestCount:  m.TestCount,
+			AverageWPM: m.AverageWPM,
+			BestWPM:    m.BestWPM,
+		}
+	}
+
+	return Stats{
+		TotalTests:         s.TotalTests,
+		TotalTimeMs:        s.TotalTimeMs,
+		AverageWPM:         s.AverageWPM,
+		BestWPM:            s.BestWPM,
+		BestPeakWPM:        s.BestPeakWPM,
+		AverageAccuracy:    s.AverageAccuracy,
+		BestAccuracy:       s.BestAccuracy,
+		AverageConsistency: s.AverageConsistency,
+		Last7DaysAvgWPM:    s.Last7DaysAvgWPM,
+		Last30DaysAvgWPM:   s.Last30DaysAvgWPM,
+		ModeStats:          modes,
+	}
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Backup writes a consistent copy of the database to path, which must not
+// exist yet. SQLite builds the copy from a single read transaction, so it is
+// safe while the database is in use.
+func (s *Store) Backup(path string) error {
+	_, err := s.db.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+// CheckFile verifies that path is an mtcli database this version can open
+// and returns its schema version. The file is opened read-only.
+func CheckFile(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var tables int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'table' AND name IN ('schema_version', 'sessions')
+	`).Scan(&tables)
+	if err != nil {
+		return 0, fmt.Errorf("not an SQLite database: %w", err)
+	}
+	if tables != 2 {
+		return 0, fmt.Errorf("not an mtcli database")
+	}
+
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("not an mtcli database")
+	}
+	if version > CurrentSchemaVersion {
+		return version, fmt.Errorf("schema version %d is newer than this mtcli supports (%d); upgrade mtcli first", version, CurrentSchemaVersion)
+	}
+	return version, nil
+}
+
+// Restore replaces the database at dst with a copy of src after checking
+// src with CheckFile. The copy is written next to dst and renamed over it,
+// so dst is never left half-written. Older schemas are migrated the next
+// time the database is opened.
+func Restore(src, dst string) error {
+	if _, err := CheckFile(src); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
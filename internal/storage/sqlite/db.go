@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -10,20 +11,36 @@ import (
 	"github.com/mmdbasi/mtcli/internal/config"
 )
 
-const currentSchemaVersion = 1
+// CurrentSchemaVersion is the schema version this mtcli migrates databases to
+const CurrentSchemaVersion = 18
 
 // Store represents the SQLite storage
 type Store struct {
 	db *sql.DB
 }
 
-// Open opens or creates the SQLite database
+// Open opens or creates the SQLite database at the configured path
 func Open() (*Store, error) {
+	return OpenContext(context.Background())
+}
+
+// OpenContext is Open with a context that bounds the migration queries
+func OpenContext(ctx context.Context) (*Store, error) {
 	dbPath, err := getDBPath()
 	if err != nil {
 		return nil, err
 	}
+	return OpenPathContext(ctx, dbPath)
+}
+
+// OpenPath opens or creates the SQLite database at dbPath
+func OpenPath(dbPath string) (*Store, error) {
+	return OpenPathContext(context.Background(), dbPath)
+}
 
+// OpenPathContext is OpenPath with a context that bounds the migration
+// queries
+func OpenPathContext(ctx context.Context, dbPath string) (*Store, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -38,7 +55,7 @@ func Open() (*Store, error) {
 	store := &Store{db: db}
 
 	// Run migrations
-	if err := store.migrate(); err != nil {
+	if err := store.migrate(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -51,54 +68,173 @@ func (s *Store) Close() error {
 	return s.db.Close()
 }
 
-// getDBPath returns the path to the SQLite database file
+// getDBPath returns the path to the SQLite database file: the configured
+// override if any, otherwise mtcli.db in the data directory
 func getDBPath() (string, error) {
+	if override := config.DBPath(); override != "" {
+		return override, nil
+	}
+
 	dataDir, err := config.GetDataDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dataDir, "mtcli.db"), nil
+	dbPath := filepath.Join(dataDir, "mtcli.db")
+
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return dbPath, nil
+	}
+	return migrateLegacyDB(filepath.Join(configDir, "mtcli.db"), dbPath), nil
+}
+
+// migrateLegacyDB moves a database left in the config directory by older
+// versions to dbPath, unless a database already exists there. It returns the
+// path to open, which stays the legacy one if the move fails.
+func migrateLegacyDB(legacyPath, dbPath string) string {
+	if legacyPath == dbPath {
+		return dbPath
+	}
+	if _, err := os.Stat(dbPath); err == nil {
+		return dbPath
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		return dbPath
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err == nil {
+		if err := os.Rename(legacyPath, dbPath); err == nil {
+			fmt.Fprintf(os.Stderr, "Notice: moved database from %s to %s\n", legacyPath, dbPath)
+			return dbPath
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Notice: using database at old location %s; move it to %s to silence this\n", legacyPath, dbPath)
+	return legacyPath
+}
+
+// Migration is one step in the schema's history. Each runs in its own
+// transaction together with the schema_version row recording it, so a
+// database is never left between two versions.
+type Migration struct {
+	Version     int
+	Description string
+	apply       func(tx *sql.Tx) error
+}
+
+// migrations lists every migration in order; the last one's version is
+// CurrentSchemaVersion
+var migrations = []Migration{
+	{1, "create the sessions and samples tables", migrateV1},
+	{2, "backfill incorrect_chars", migrateV2},
+	{3, "add the source column", migrateV3},
+	{4, "add the consistency column", migrateV4},
+	{5, "add the key_stats table", migrateV5},
+	{6, "add the correction column", migrateV6},
+	{7, "add the target_text and seed columns", migrateV7},
+	{8, "add the target_wpm column", migrateV8},
+	{9, "add the corrections column", migrateV9},
+	{10, "add the time_to_first_key_ms column", migrateV10},
+	{11, "add the peak_wpm and median_wpm columns", migrateV11},
+	{12, "add the errors column to samples", migrateV12},
+	{13, "add the typed_text column", migrateV13},
+	{14, "add the difficulty column", migrateV14},
+	{15, "add the mistake_drill column", migrateV15},
+	{16, "add the first_try_accuracy column", migrateV16},
+	{17, "add the meta table", migrateV17},
+	{18, "add the target_hash column", migrateV18},
+}
+
+// PendingMigrations returns the migrations a database at version still
+// needs, oldest first
+func PendingMigrations(version int) []Migration {
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// MigratePath brings the database at dbPath up to CurrentSchemaVersion,
+// creating it if needed. It returns the version the database was at and
+// the migrations applied; running it again applies nothing.
+func MigratePath(ctx context.Context, dbPath string) (int, []Migration, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store := &Store{db: db}
+	return store.applyMigrations(ctx)
 }
 
 // migrate runs database migrations
-func (s *Store) migrate() error {
+func (s *Store) migrate(ctx context.Context) error {
+	_, _, err := s.applyMigrations(ctx)
+	return err
+}
+
+// applyMigrations runs the pending migrations in order and returns the
+// version the database started at and the migrations applied
+func (s *Store) applyMigrations(ctx context.Context) (int, []Migration, error) {
 	// Create schema_version table if it doesn't exist
-	_, err := s.db.Exec(`
+	_, err := s.db.ExecContext(ctx, `
 		CREATE TABLE IF NOT EXISTS schema_version (
 			version INTEGER PRIMARY KEY
 		)
 	`)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
-	// Get current version
-	var version int
-	err = s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	version, err := s.schemaVersion(ctx)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
-	// Apply migrations
-	if version < 1 {
-		if err := s.migrateV1(); err != nil {
-			return err
+	var applied []Migration
+	for _, m := range PendingMigrations(version) {
+		if err := s.runMigration(ctx, m); err != nil {
+			return version, applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
 		}
+		applied = append(applied, m)
 	}
+	return version, applied, nil
+}
 
-	return nil
+func (s *Store) schemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
 }
 
-// migrateV1 creates the initial schema
-func (s *Store) migrateV1() error {
-	tx, err := s.db.Begin()
+// runMigration applies m and records its version in one transaction
+func (s *Store) runMigration(ctx context.Context, m Migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := m.apply(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_version (version) VALUES (?)`, m.Version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrateV1 creates the initial schema
+func migrateV1(tx *sql.Tx) error {
 	// Create sessions table
-	_, err = tx.Exec(`
+	_, err := tx.Exec(`
 		CREATE TABLE IF NOT EXISTS sessions (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			started_at DATETIME NOT NULL,
@@ -147,16 +283,173 @@ func (s *Store) migrateV1() error {
 	}
 
 	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_samples_session_id ON samples(session_id)`)
+	return err
+}
+
+// migrateV2 backfills incorrect_chars, which was always stored as zero.
+// The exact count of wrong keystrokes is unknown for old rows, so it is
+// approximated as total_typed - correct_chars.
+func migrateV2(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		UPDATE sessions
+		SET incorrect_chars = total_typed - correct_chars
+		WHERE incorrect_chars = 0 AND total_typed > correct_chars
+	`)
+	return err
+}
+
+// migrateV3 adds the source column (quote author or text file name)
+func migrateV3(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN source TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// migrateV4 adds the consistency column
+func migrateV4(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN consistency REAL NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV5 adds the key_stats table for per-character error tracking
+func migrateV5(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS key_stats (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id INTEGER NOT NULL,
+			key TEXT NOT NULL,
+			attempts INTEGER NOT NULL,
+			errors INTEGER NOT NULL,
+			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_key_stats_session_id ON key_stats(session_id)`)
+	return err
+}
+
+// migrateV6 adds the correction column recording no-backspace and stop-on-error runs
+func migrateV6(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN correction TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// migrateV7 adds the target_text and seed columns used to repeat a session
+func migrateV7(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN target_text TEXT NOT NULL DEFAULT ''`)
 	if err != nil {
 		return err
 	}
 
-	// Update schema version
-	_, err = tx.Exec(`INSERT INTO schema_version (version) VALUES (1)`)
+	_, err = tx.Exec(`ALTER TABLE sessions ADD COLUMN seed INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV8 adds the target_wpm column for per-session goals
+func migrateV8(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN target_wpm REAL NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV9 adds the corrections column counting backspace presses
+func migrateV9(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN corrections INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV10 adds the time_to_first_key_ms column
+func migrateV10(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN time_to_first_key_ms INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV11 adds the peak_wpm and median_wpm columns
+func migrateV11(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN peak_wpm REAL NOT NULL DEFAULT 0`)
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	_, err = tx.Exec(`ALTER TABLE sessions ADD COLUMN median_wpm REAL NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV12 adds the errors column to samples for the error-rate chart
+func migrateV12(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE samples ADD COLUMN errors INTEGER NOT NULL DEFAULT 0`)
+	return err
 }
 
+// migrateV13 adds the typed_text column so show can highlight mistakes
+func migrateV13(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN typed_text TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// migrateV14 adds the difficulty column for --difficulty presets
+func migrateV14(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN difficulty TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// migrateV15 adds the mistake_drill column for follow-up tests on missed
+// words
+func migrateV15(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN mistake_drill INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV16 adds the first_try_accuracy column, accuracy that doesn't
+// forgive corrected mistakes
+func migrateV16(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN first_try_accuracy REAL NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateV17 adds the meta table for settings that belong to the database
+// rather than the config file, such as when it was last synced
+func migrateV17(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE meta (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)
+	`)
+	return err
+}
+
+// migrateV18 adds the target_hash column that identifies a session's text
+// across machines, and fills it in for sessions whose text was stored
+func migrateV18(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE sessions ADD COLUMN target_hash TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, target_text FROM sessions WHERE target_text != ''`)
+	if err != nil {
+		return err
+	}
+	hashes := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			rows.Close()
+			return err
+		}
+		hashes[id] = TargetHash(text)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, hash := range hashes {
+		if _, err := tx.Exec(`UPDATE sessions SET target_hash = ? WHERE id = ?`, hash, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// v1Schema is the schema of the first released database, written out by
+// hand so the test doesn't depend on migrateV1 staying the same
+const v1Schema = `
+	CREATE TABLE schema_version (version INTEGER PRIMARY KEY);
+	INSERT INTO schema_version (version) VALUES (1);
+	CREATE TABLE sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at DATETIME NOT NULL,
+		mode TEXT NOT NULL,
+		seconds INTEGER DEFAULT 0,
+		words INTEGER DEFAULT 0,
+		quote_id TEXT DEFAULT '',
+		target_len INTEGER NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		correct_chars INTEGER NOT NULL,
+		incorrect_chars INTEGER NOT NULL DEFAULT 0,
+		total_typed INTEGER NOT NULL,
+		accuracy REAL NOT NULL,
+		wpm REAL NOT NULL,
+		raw_wpm REAL NOT NULL
+	);
+	CREATE TABLE samples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id INTEGER NOT NULL,
+		time_ms INTEGER NOT NULL,
+		wpm REAL NOT NULL,
+		raw_wpm REAL NOT NULL,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+`
+
+// buildV1 writes a version 1 database at path holding one session with
+// one sample
+func buildV1(t *testing.T, path string, startedAt time.Time) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(v1Schema); err != nil {
+		t.Fatalf("create v1 schema: %v", err)
+	}
+	// Version 1 didn't fill in incorrect_chars; migration 2 backfills it
+	_, err = db.Exec(`
+		INSERT INTO sessions (started_at, mode, words, target_len, duration_ms,
+			correct_chars, total_typed, accuracy, wpm, raw_wpm)
+		VALUES (?, 'words', 10, 50, 12000, 45, 50, 90, 45, 50)
+	`, startedAt)
+	if err != nil {
+		t.Fatalf("insert v1 session: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO samples (session_id, time_ms, wpm, raw_wpm) VALUES (1, 500, 40, 44)`); err != nil {
+		t.Fatalf("insert v1 sample: %v", err)
+	}
+}
+
+// migrateTwice brings the database at path up to date, checks that it
+// started at version from, and that migrating again applies nothing
+func migrateTwice(t *testing.T, path string, from int) {
+	t.Helper()
+	ctx := context.Background()
+
+	version, applied, err := MigratePath(ctx, path)
+	if err != nil {
+		t.Fatalf("MigratePath: %v", err)
+	}
+	if version != from {
+		t.Errorf("started at version %d, want %d", version, from)
+	}
+	if want := CurrentSchemaVersion - from; len(applied) != want {
+		t.Errorf("applied %d migrations, want %d", len(applied), want)
+	}
+	if n := len(applied); n > 0 && applied[n-1].Version != CurrentSchemaVersion {
+		t.Errorf("last migration applied is %d, want %d", applied[n-1].Version, CurrentSchemaVersion)
+	}
+
+	got, err := CheckFile(path)
+	if err != nil {
+		t.Fatalf("CheckFile: %v", err)
+	}
+	if got != CurrentSchemaVersion {
+		t.Errorf("schema version %d after migrating, want %d", got, CurrentSchemaVersion)
+	}
+
+	// Once up to date, migrating again changes nothing
+	store, err := OpenPath(path)
+	if err != nil {
+		t.Fatalf("OpenPath: %v", err)
+	}
+	defer store.Close()
+	version, applied, err = store.applyMigrations(ctx)
+	if err != nil {
+		t.Fatalf("applyMigrations: %v", err)
+	}
+	if version != CurrentSchemaVersion || len(applied) != 0 {
+		t.Errorf("second run started at %d and applied %d migrations, want %d and none", version, len(applied), CurrentSchemaVersion)
+	}
+}
+
+func TestMigrateFresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mtcli.db")
+	migrateTwice(t, path, 0)
+
+	// The full schema takes a session with every column
+	store, err := OpenPath(path)
+	if err != nil {
+		t.Fatalf("OpenPath: %v", err)
+	}
+	defer store.Close()
+	session := &Session{StartedAt: time.Now(), Mode: "words", TargetText: "abc", TargetHash: TargetHash("abc")}
+	id, err := store.SaveSession(session, []SessionSample{{TimeMs: 500, WPM: 30, RawWPM: 30}}, []SessionKeyStat{{Key: "a", Attempts: 1}})
+	if err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+	got, err := store.GetSession(id)
+	if err != nil || got == nil || got.TargetHash != session.TargetHash {
+		t.Errorf("GetSession = %+v, %v, want the saved session", got, err)
+	}
+}
+
+func TestMigrateV1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mtcli.db")
+	startedAt := time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC)
+	buildV1(t, path, startedAt)
+	migrateTwice(t, path, 1)
+
+	store, err := OpenPath(path)
+	if err != nil {
+		t.Fatalf("OpenPath: %v", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.ListAllSessions("")
+	if err != nil {
+		t.Fatalf("ListAllSessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions after migrating, want 1", len(sessions))
+	}
+	s := sessions[0]
+	if !s.StartedAt.Equal(startedAt) || s.Mode != "words" || s.WPM != 45 {
+		t.Errorf("session = %+v, want the v1 session", s)
+	}
+	if s.IncorrectChars != 5 {
+		t.Errorf("IncorrectChars = %d, want 5 backfilled", s.IncorrectChars)
+	}
+	if s.TargetText != "" || s.TargetHash != "" || s.Source != "" {
+		t.Errorf("new columns = %q, %q, %q, want them empty", s.TargetText, s.TargetHash, s.Source)
+	}
+
+	samples, err := store.GetSamples(s.ID)
+	if err != nil {
+		t.Fatalf("GetSamples: %v", err)
+	}
+	if len(samples) != 1 || samples[0].TimeMs != 500 || samples[0].Errors != 0 {
+		t.Errorf("samples = %+v, want the v1 sample", samples)
+	}
+}
+
+func TestMigrationsInOrder(t *testing.T) {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d", i+1, m.Version)
+		}
+	}
+	if last := migrations[len(migrations)-1].Version; last != CurrentSchemaVersion {
+		t.Errorf("last migration is %d, CurrentSchemaVersion is %d", last, CurrentSchemaVersion)
+	}
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+// Path returns the database file that Open uses
+func Path() (string, error) {
+	return getDBPath()
+}
+
+// Vacuum rebuilds the database file, returning the space left behind by
+// deleted rows to the file system
+func (s *Store) Vacuum() error {
+	_, err := s.db.Exec("VACUUM")
+	return err
+}
+
+// IntegrityCheck runs SQLite's integrity check and returns the problems it
+// reports; a healthy database yields the single line "ok"
+func (s *Store) IntegrityCheck() ([]string, error) {
+	rows, err := s.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		results = append(results, line)
+	}
+	return results, rows.Err()
+}
+
+// TableCount is the number of rows in one table
+type TableCount struct {
+	Table string
+	Rows  int64
+}
+
+// TableCounts returns the row count of each table holding test data
+func (s *Store) TableCounts() ([]TableCount, error) {
+	tables := []string{"sessions", "samples", "key_stats"}
+	counts := make([]TableCount, len(tables))
+	for i, table := range tables {
+		counts[i].Table = table
+		// Table names come from the fixed list above, never from input
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&counts[i].Rows); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// FreePages returns the number of unused pages in the database file, which
+// Vacuum reclaims
+func (s *Store) FreePages() (int64, error) {
+	var n int64
+	err := s.db.QueryRow("PRAGMA freelist_count").Scan(&n)
+	return n, err
+}
@@ -0,0 +1,30 @@
+package sqlite
+
+import "database/sql"
+
+// Meta keys
+const (
+	MetaLastSync = "last_sync" // RFC 3339 time of the last 'mtcli sync'
+)
+
+// GetMeta returns the value stored under key, and false if there is none
+func (s *Store) GetMeta(key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM meta WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// SetMeta stores value under key, replacing any previous value
+func (s *Store) SetMeta(key, value string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO meta (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	return err
+}
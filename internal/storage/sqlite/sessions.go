@@ -1,26 +1,108 @@
 package sqlite
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
 // Session represents a stored typing test session
 type Session struct {
-	ID             int64
-	StartedAt      time.Time
-	Mode           string
-	Seconds        int
-	Words          int
-	QuoteID        string
-	TargetLen      int
-	DurationMs     int64
-	CorrectChars   int
-	IncorrectChars int
-	TotalTyped     int
-	Accuracy       float64
-	WPM            float64
-	RawWPM         float64
+	ID               int64
+	StartedAt        time.Time
+	Mode             string
+	Seconds          int
+	Words            int
+	QuoteID          string
+	TargetLen        int
+	DurationMs       int64
+	CorrectChars     int
+	IncorrectChars   int
+	TotalTyped       int
+	Accuracy         float64
+	WPM              float64
+	RawWPM           float64
+	Source           string  // quote author or text file name
+	Consistency      float64 // 0-100; 0 for sessions saved before it was tracked
+	Correction       string  // "no-backspace", "stop-on-error", or empty
+	TargetText       string  // the exact text typed, for --repeat
+	Seed             int64   // generator seed; 0 for text files and older sessions
+	TargetWPM        float64 // WPM goal; 0 when none was set
+	Corrections      int     // backspace presses that removed a character
+	TimeToFirstKeyMs int64   // from the end of the countdown to the first key; 0 if unknown
+	PeakWPM          float64 // 0 for sessions saved before it was tracked
+	MedianWPM        float64
+	TypedText        string  // what was actually typed, aligned with TargetText
+	Difficulty       string  // "easy", "medium", "hard", or empty when no preset was used
+	MistakeDrill     bool    // a follow-up test on the words missed in the one before
+	FirstTryAccuracy float64 // percent right the first time; 0 for sessions saved before it was tracked
+	TargetHash       string  // TargetHash of the text typed; empty when the text is unknown
+}
+
+// TargetHash returns the hash that identifies a target text, so the same
+// session can be recognized on another machine without the text itself
+func TargetHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionColumns lists the sessions columns in the order scanSession reads them
+const sessionColumns = `id, started_at, mode, seconds, words, quote_id, target_len,
+		       duration_ms, correct_chars, incorrect_chars, total_typed,
+		       accuracy, wpm, raw_wpm, source, consistency,
+		       correction, target_text, seed, target_wpm,
+		       corrections, time_to_first_key_ms, peak_wpm,
+		       median_wpm, typed_text, difficulty, mistake_drill,
+		       first_try_accuracy, target_hash`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row rowScanner) (*Session, error) {
+	session := &Session{}
+	err := row.Scan(
+		&session.ID,
+		&session.StartedAt,
+		&session.Mode,
+		&session.Seconds,
+		&session.Words,
+		&session.QuoteID,
+		&session.TargetLen,
+		&session.DurationMs,
+		&session.CorrectChars,
+		&session.IncorrectChars,
+		&session.TotalTyped,
+		&session.Accuracy,
+		&session.WPM,
+		&session.RawWPM,
+		&session.Source,
+		&session.Consistency,
+		&session.Correction,
+		&session.TargetText,
+		&session.Seed,
+		&session.TargetWPM,
+		&session.Corrections,
+		&session.TimeToFirstKeyMs,
+		&session.PeakWPM,
+		&session.MedianWPM,
+		&session.TypedText,
+		&session.Difficulty,
+		&session.MistakeDrill,
+		&session.FirstTryAccuracy,
+		&session.TargetHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
 // SessionSample represents a speed sample for a session
@@ -30,25 +112,121 @@ type SessionSample struct {
 	TimeMs    int64
 	WPM       float64
 	RawWPM    float64
+	Errors    int // cumulative incorrect keystrokes; 0 for older sessions
 }
 
-// SaveSession saves a completed session and its samples
-func (s *Store) SaveSession(session *Session, samples []SessionSample) (int64, error) {
-	tx, err := s.db.Begin()
+// SessionKeyStat holds keystroke counts for one target character in a session
+type SessionKeyStat struct {
+	SessionID int64
+	Key       string
+	Attempts  int
+	Errors    int
+}
+
+// SaveSession saves a completed session with its samples and key stats
+func (s *Store) SaveSession(session *Session, samples []SessionSample, keyStats []SessionKeyStat) (int64, error) {
+	return s.SaveSessionContext(context.Background(), session, samples, keyStats)
+}
+
+// SaveSessionContext is SaveSession with a context; cancelling it rolls the
+// whole session back
+func (s *Store) SaveSessionContext(ctx context.Context, session *Session, samples []SessionSample, keyStats []SessionKeyStat) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sessionID, err := insertSession(ctx, tx, session, samples, keyStats)
 	if err != nil {
 		return 0, err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return sessionID, nil
+}
+
+// SaveImportedSession saves a session exported from another database,
+// keeping its original start time. A session with the same start time,
+// mode, and target hash is taken to be the same run and is not saved again.
+// The start time is stored in the local time zone, like that of a session
+// recorded here. It returns the new ID, or 0 and false for a duplicate.
+func (s *Store) SaveImportedSession(session *Session, samples []SessionSample) (int64, bool, error) {
+	return s.SaveImportedSessionContext(context.Background(), session, samples)
+}
+
+// SaveImportedSessionContext is SaveImportedSession with a context
+func (s *Store) SaveImportedSessionContext(ctx context.Context, session *Session, samples []SessionSample) (int64, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
 	defer tx.Rollback()
 
+	exists, err := hasSession(ctx, tx, session)
+	if err != nil || exists {
+		return 0, false, err
+	}
+
+	sessionID, err := insertSession(ctx, tx, session, samples, nil)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+
+	return sessionID, true, nil
+}
+
+// hasSession reports whether a session with the start time, mode, and
+// target hash of session is stored. A session without a hash, from before
+// hashes were kept, matches on start time and mode alone.
+func hasSession(ctx context.Context, tx *sql.Tx, session *Session) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT started_at FROM sessions
+		WHERE mode = ? AND (target_hash = ? OR target_hash = '' OR ? = '')
+	`, session.Mode, session.TargetHash, session.TargetHash)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	// Start times are compared in Go: the same instant is stored as a
+	// different string when the two machines are in different time zones
+	for rows.Next() {
+		var startedAt time.Time
+		if err := rows.Scan(&startedAt); err != nil {
+			return false, err
+		}
+		if startedAt.Equal(session.StartedAt) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// insertSession inserts a session with its samples and key stats in tx and
+// returns its ID. The start time is stored in the local time zone: it is
+// kept as text that sorts and filters as a string, so every row must carry
+// the same offset, whichever machine recorded it.
+func insertSession(ctx context.Context, tx *sql.Tx, session *Session, samples []SessionSample, keyStats []SessionKeyStat) (int64, error) {
 	// Insert session
-	result, err := tx.Exec(`
+	result, err := tx.ExecContext(ctx, `
 		INSERT INTO sessions (
 			started_at, mode, seconds, words, quote_id, target_len,
 			duration_ms, correct_chars, incorrect_chars, total_typed,
-			accuracy, wpm, raw_wpm
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			accuracy, wpm, raw_wpm, source, consistency, correction,
+			target_text, seed, target_wpm, corrections,
+			time_to_first_key_ms, peak_wpm, median_wpm, typed_text,
+			difficulty, mistake_drill, first_try_accuracy, target_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
-		session.StartedAt,
+		session.StartedAt.Local(),
 		session.Mode,
 		session.Seconds,
 		session.Words,
@@ -61,6 +239,21 @@ func (s *Store) SaveSession(session *Session, samples []SessionSample) (int64, e
 		session.Accuracy,
 		session.WPM,
 		session.RawWPM,
+		session.Source,
+		session.Consistency,
+		session.Correction,
+		session.TargetText,
+		session.Seed,
+		session.TargetWPM,
+		session.Corrections,
+		session.TimeToFirstKeyMs,
+		session.PeakWPM,
+		session.MedianWPM,
+		session.TypedText,
+		session.Difficulty,
+		session.MistakeDrill,
+		session.FirstTryAccuracy,
+		session.TargetHash,
 	)
 	if err != nil {
 		return 0, err
@@ -71,48 +264,53 @@ func (s *Store) SaveSession(session *Session, samples []SessionSample) (int64, e
 		return 0, err
 	}
 
-	// Insert samples
+	// Insert samples, preparing the statement once since long tests have
+	// hundreds of them
+	sampleStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO samples (session_id, time_ms, wpm, raw_wpm, errors)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer sampleStmt.Close()
 	for _, sample := range samples {
-		_, err = tx.Exec(`
-			INSERT INTO samples (session_id, time_ms, wpm, raw_wpm)
-			VALUES (?, ?, ?, ?)
-		`, sessionID, sample.TimeMs, sample.WPM, sample.RawWPM)
+		_, err = sampleStmt.ExecContext(ctx, sessionID, sample.TimeMs, sample.WPM, sample.RawWPM, sample.Errors)
 		if err != nil {
 			return 0, err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	// Insert key stats
+	keyStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO key_stats (session_id, key, attempts, errors)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
 		return 0, err
 	}
+	defer keyStmt.Close()
+	for _, stat := range keyStats {
+		_, err = keyStmt.ExecContext(ctx, sessionID, stat.Key, stat.Attempts, stat.Errors)
+		if err != nil {
+			return 0, err
+		}
+	}
 
 	return sessionID, nil
 }
 
 // GetSession retrieves a session by ID
 func (s *Store) GetSession(id int64) (*Session, error) {
-	session := &Session{}
-	err := s.db.QueryRow(`
-		SELECT id, started_at, mode, seconds, words, quote_id, target_len,
-		       duration_ms, correct_chars, incorrect_chars, total_typed,
-		       accuracy, wpm, raw_wpm
+	return s.GetSessionContext(context.Background(), id)
+}
+
+// GetSessionContext is GetSession with a context
+func (s *Store) GetSessionContext(ctx context.Context, id int64) (*Session, error) {
+	session, err := scanSession(s.db.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+`
 		FROM sessions WHERE id = ?
-	`, id).Scan(
-		&session.ID,
-		&session.StartedAt,
-		&session.Mode,
-		&session.Seconds,
-		&session.Words,
-		&session.QuoteID,
-		&session.TargetLen,
-		&session.DurationMs,
-		&session.CorrectChars,
-		&session.IncorrectChars,
-		&session.TotalTyped,
-		&session.Accuracy,
-		&session.WPM,
-		&session.RawWPM,
-	)
+	`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -124,8 +322,13 @@ func (s *Store) GetSession(id int64) (*Session, error) {
 
 // GetSamples retrieves samples for a session
 func (s *Store) GetSamples(sessionID int64) ([]SessionSample, error) {
-	rows, err := s.db.Query(`
-		SELECT id, session_id, time_ms, wpm, raw_wpm
+	return s.GetSamplesContext(context.Background(), sessionID)
+}
+
+// GetSamplesContext is GetSamples with a context
+func (s *Store) GetSamplesContext(ctx context.Context, sessionID int64) ([]SessionSample, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, session_id, time_ms, wpm, raw_wpm, errors
 		FROM samples WHERE session_id = ?
 		ORDER BY time_ms
 	`, sessionID)
@@ -137,7 +340,7 @@ func (s *Store) GetSamples(sessionID int64) ([]SessionSample, error) {
 	var samples []SessionSample
 	for rows.Next() {
 		var sample SessionSample
-		err := rows.Scan(&sample.ID, &sample.SessionID, &sample.TimeMs, &sample.WPM, &sample.RawWPM)
+		err := rows.Scan(&sample.ID, &sample.SessionID, &sample.TimeMs, &sample.WPM, &sample.RawWPM, &sample.Errors)
 		if err != nil {
 			return nil, err
 		}
@@ -147,30 +350,190 @@ func (s *Store) GetSamples(sessionID int64) ([]SessionSample, error) {
 	return samples, rows.Err()
 }
 
-// ListSessions retrieves recent sessions with optional mode filter
-func (s *Store) ListSessions(limit int, mode string) ([]Session, error) {
+// samplesBatchSize caps the IDs per query, well under SQLite's limit on
+// bound parameters
+const samplesBatchSize = 500
+
+// GetSamplesForSessions retrieves the samples of several sessions, keyed by
+// session ID, with one query per batch of IDs rather than one per session.
+// Sessions without samples are left out.
+func (s *Store) GetSamplesForSessions(sessionIDs []int64) (map[int64][]SessionSample, error) {
+	return s.GetSamplesForSessionsContext(context.Background(), sessionIDs)
+}
+
+// GetSamplesForSessionsContext is GetSamplesForSessions with a context
+func (s *Store) GetSamplesForSessionsContext(ctx context.Context, sessionIDs []int64) (map[int64][]SessionSample, error) {
+	samples := make(map[int64][]SessionSample)
+	for start := 0; start < len(sessionIDs); start += samplesBatchSize {
+		batch := sessionIDs[start:min(start+samplesBatchSize, len(sessionIDs))]
+		if err := s.loadSamples(ctx, batch, samples); err != nil {
+			return nil, err
+		}
+	}
+	return samples, nil
+}
+
+// loadSamples adds the samples of the given sessions to samples
+func (s *Store) loadSamples(ctx context.Context, sessionIDs []int64, samples map[int64][]SessionSample) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(sessionIDs)), ",")
+	args := make([]interface{}, len(sessionIDs))
+	for i, id := range sessionIDs {
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, session_id, time_ms, wpm, raw_wpm, errors
+		FROM samples WHERE session_id IN (`+placeholders+`)
+		ORDER BY session_id, time_ms
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sample SessionSample
+		err := rows.Scan(&sample.ID, &sample.SessionID, &sample.TimeMs, &sample.WPM, &sample.RawWPM, &sample.Errors)
+		if err != nil {
+			return err
+		}
+		samples[sample.SessionID] = append(samples[sample.SessionID], sample)
+	}
+
+	return rows.Err()
+}
+
+// SessionFilter selects sessions for ListSessions. Zero values leave the
+// corresponding filter off.
+type SessionFilter struct {
+	Limit       int       // maximum number of sessions; 0 for no limit
+	Mode        string    // only this mode
+	Since       time.Time // started at or after
+	Until       time.Time // started before
+	MinWPM      float64
+	MaxWPM      float64
+	MinAccuracy float64 // percent
+}
+
+// ListSessions retrieves the most recent sessions matching filter
+func (s *Store) ListSessions(filter SessionFilter) ([]Session, error) {
+	return s.ListSessionsContext(context.Background(), filter)
+}
+
+// ListSessionsContext is ListSessions with a context
+func (s *Store) ListSessionsContext(ctx context.Context, filter SessionFilter) ([]Session, error) {
+	return s.querySessions(ctx, filter, "started_at DESC")
+}
+
+// ListLatestSessions retrieves the most recent sessions matching filter
+// like ListSessions, but oldest first, for following a trend through them
+func (s *Store) ListLatestSessions(filter SessionFilter) ([]Session, error) {
+	return s.ListLatestSessionsContext(context.Background(), filter)
+}
+
+// ListLatestSessionsContext is ListLatestSessions with a context
+func (s *Store) ListLatestSessionsContext(ctx context.Context, filter SessionFilter) ([]Session, error) {
+	sessions, err := s.querySessions(ctx, filter, "started_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(sessions)
+	return sessions, nil
+}
+
+// Rank keys accepted by TopSessions
+const (
+	RankByWPM         = "wpm"
+	RankByAccuracy    = "accuracy"
+	RankByConsistency = "consistency"
+)
+
+// TopSessions retrieves the best sessions matching filter, ranked by the
+// given key from best to worst. Ties go to the faster, then earlier, run.
+func (s *Store) TopSessions(filter SessionFilter, by string) ([]Session, error) {
+	ctx := context.Background()
+	switch by {
+	case RankByWPM:
+		return s.querySessions(ctx, filter, "wpm DESC, started_at")
+	case RankByAccuracy:
+		return s.querySessions(ctx, filter, "accuracy DESC, wpm DESC, started_at")
+	case RankByConsistency:
+		// Sessions too short to score have a consistency of 0; leave them out
+		return s.querySessions(ctx, filter, "consistency DESC, wpm DESC, started_at", "consistency > 0")
+	default:
+		return nil, fmt.Errorf("unknown rank key: %s (use wpm, accuracy, or consistency)", by)
+	}
+}
+
+// querySessions selects sessions matching filter and any extra conditions
+// in the given order. order and extra are trusted SQL and must never come
+// from user input.
+func (s *Store) querySessions(ctx context.Context, filter SessionFilter, order string, extra ...string) ([]Session, error) {
+	where, args := rangeFilter(filter.Since, filter.Until)
+	where = append(where, extra...)
+	if filter.Mode != "" {
+		where = append(where, "mode = ?")
+		args = append(args, filter.Mode)
+	}
+	if filter.MinWPM > 0 {
+		where = append(where, "wpm >= ?")
+		args = append(args, filter.MinWPM)
+	}
+	if filter.MaxWPM > 0 {
+		where = append(where, "wpm <= ?")
+		args = append(args, filter.MaxWPM)
+	}
+	if filter.MinAccuracy > 0 {
+		where = append(where, "accuracy >= ?")
+		args = append(args, filter.MinAccuracy)
+	}
+
+	// SQLite treats a negative limit as no limit
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		`+whereClause(where)+`
+		ORDER BY `+order+`
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// ListAllSessions retrieves every session in chronological order, with an
+// optional mode filter
+func (s *Store) ListAllSessions(mode string) ([]Session, error) {
+	return s.ListAllSessionsContext(context.Background(), mode)
+}
+
+// ListAllSessionsContext is ListAllSessions with a context
+func (s *Store) ListAllSessionsContext(ctx context.Context, mode string) ([]Session, error) {
 	var rows *sql.Rows
 	var err error
 
 	if mode != "" {
-		rows, err = s.db.Query(`
-			SELECT id, started_at, mode, seconds, words, quote_id, target_len,
-			       duration_ms, correct_chars, incorrect_chars, total_typed,
-			       accuracy, wpm, raw_wpm
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT `+sessionColumns+`
 			FROM sessions
 			WHERE mode = ?
-			ORDER BY started_at DESC
-			LIMIT ?
-		`, mode, limit)
+			ORDER BY started_at
+		`, mode)
 	} else {
-		rows, err = s.db.Query(`
-			SELECT id, started_at, mode, seconds, words, quote_id, target_len,
-			       duration_ms, correct_chars, incorrect_chars, total_typed,
-			       accuracy, wpm, raw_wpm
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT `+sessionColumns+`
 			FROM sessions
-			ORDER BY started_at DESC
-			LIMIT ?
-		`, limit)
+			ORDER BY started_at
+		`)
 	}
 
 	if err != nil {
@@ -178,29 +541,18 @@ func (s *Store) ListSessions(limit int, mode string) ([]Session, error) {
 	}
 	defer rows.Close()
 
+	return scanSessions(rows)
+}
+
+// scanSessions scans all remaining rows selected with sessionColumns
+func scanSessions(rows *sql.Rows) ([]Session, error) {
 	var sessions []Session
 	for rows.Next() {
-		var session Session
-		err := rows.Scan(
-			&session.ID,
-			&session.StartedAt,
-			&session.Mode,
-			&session.Seconds,
-			&session.Words,
-			&session.QuoteID,
-			&session.TargetLen,
-			&session.DurationMs,
-			&session.CorrectChars,
-			&session.IncorrectChars,
-			&session.TotalTyped,
-			&session.Accuracy,
-			&session.WPM,
-			&session.RawWPM,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, err
 		}
-		sessions = append(sessions, session)
+		sessions = append(sessions, *session)
 	}
 
 	return sessions, rows.Err()
@@ -208,14 +560,17 @@ func (s *Store) ListSessions(limit int, mode string) ([]Session, error) {
 
 // Stats represents aggregate statistics
 type Stats struct {
-	TotalTests       int
-	TotalTimeMs      int64
-	AverageWPM       float64
-	BestWPM          float64
-	AverageAccuracy  float64
-	Last7DaysAvgWPM  float64
-	Last30DaysAvgWPM float64
-	ModeStats        map[string]ModeStats
+	TotalTests         int
+	TotalTimeMs        int64
+	AverageWPM         float64
+	BestWPM            float64
+	BestPeakWPM        float64
+	AverageAccuracy    float64
+	BestAccuracy       float64
+	AverageConsistency float64
+	Last7DaysAvgWPM    float64
+	Last30DaysAvgWPM   float64
+	ModeStats          map[string]ModeStats
 }
 
 // ModeStats represents statistics for a specific mode
@@ -225,24 +580,78 @@ type ModeStats struct {
 	BestWPM    float64
 }
 
-// GetStats calculates aggregate statistics
-func (s *Store) GetStats() (*Stats, error) {
+// rangeFilter returns the conditions and arguments restricting started_at
+// to [since, until), skipping zero bounds. The bounds are compared as
+// text, so they are given in the local time zone that start times are
+// stored in.
+func rangeFilter(since, until time.Time) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+	if !since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, since.Local())
+	}
+	if !until.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, until.Local())
+	}
+	return where, args
+}
+
+// whereClause joins conditions into a WHERE clause, or "" if there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// GetStats calculates aggregate statistics over sessions started in
+// [since, until); a zero time leaves that end of the range open
+func (s *Store) GetStats(since, until time.Time) (*Stats, error) {
+	return s.GetStatsForModeContext(context.Background(), "", since, until)
+}
+
+// GetStatsContext is GetStats with a context
+func (s *Store) GetStatsContext(ctx context.Context, since, until time.Time) (*Stats, error) {
+	return s.GetStatsForModeContext(ctx, "", since, until)
+}
+
+// GetStatsForMode is GetStats restricted to one mode, or to all modes if
+// mode is empty
+func (s *Store) GetStatsForMode(mode string, since, until time.Time) (*Stats, error) {
+	return s.GetStatsForModeContext(context.Background(), mode, since, until)
+}
+
+// GetStatsForModeContext is GetStatsForMode with a context
+func (s *Store) GetStatsForModeContext(ctx context.Context, mode string, since, until time.Time) (*Stats, error) {
 	stats := &Stats{
 		ModeStats: make(map[string]ModeStats),
 	}
+	where, args := rangeFilter(since, until)
+	if mode != "" {
+		where = append(where, "mode = ?")
+		args = append(args, mode)
+	}
 
 	// Overall stats
-	err := s.db.QueryRow(`
+	err := s.db.QueryRowContext(ctx, `
 		SELECT COUNT(*), COALESCE(SUM(duration_ms), 0), 
 		       COALESCE(AVG(wpm), 0), COALESCE(MAX(wpm), 0), 
-		       COALESCE(AVG(accuracy), 0)
+		       COALESCE(MAX(peak_wpm), 0),
+		       COALESCE(AVG(accuracy), 0), COALESCE(MAX(accuracy), 0),
+		       COALESCE(AVG(NULLIF(consistency, 0)), 0)
 		FROM sessions
-	`).Scan(
+		`+whereClause(where)+`
+	`, args...).Scan(
 		&stats.TotalTests,
 		&stats.TotalTimeMs,
 		&stats.AverageWPM,
 		&stats.BestWPM,
+		&stats.BestPeakWPM,
 		&stats.AverageAccuracy,
+		&stats.BestAccuracy,
+		&stats.AverageConsistency,
 	)
 	if err != nil {
 		return nil, err
@@ -250,32 +659,33 @@ func (s *Store) GetStats() (*Stats, error) {
 
 	// Last 7 days average
 	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
-	err = s.db.QueryRow(`
+	err = s.db.QueryRowContext(ctx, `
 		SELECT COALESCE(AVG(wpm), 0)
 		FROM sessions
-		WHERE started_at >= ?
-	`, sevenDaysAgo).Scan(&stats.Last7DaysAvgWPM)
+		`+whereClause(append(where, "started_at >= ?"))+`
+	`, append(args, sevenDaysAgo)...).Scan(&stats.Last7DaysAvgWPM)
 	if err != nil {
 		return nil, err
 	}
 
 	// Last 30 days average
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
-	err = s.db.QueryRow(`
+	err = s.db.QueryRowContext(ctx, `
 		SELECT COALESCE(AVG(wpm), 0)
 		FROM sessions
-		WHERE started_at >= ?
-	`, thirtyDaysAgo).Scan(&stats.Last30DaysAvgWPM)
+		`+whereClause(append(where, "started_at >= ?"))+`
+	`, append(args, thirtyDaysAgo)...).Scan(&stats.Last30DaysAvgWPM)
 	if err != nil {
 		return nil, err
 	}
 
 	// Per-mode stats
-	rows, err := s.db.Query(`
+	rows, err := s.db.QueryContext(ctx, `
 		SELECT mode, COUNT(*), COALESCE(AVG(wpm), 0), COALESCE(MAX(wpm), 0)
 		FROM sessions
+		`+whereClause(where)+`
 		GROUP BY mode
-	`)
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -294,7 +704,7 @@ func (s *Store) GetStats() (*Stats, error) {
 	return stats, rows.Err()
 }
 
-// DeleteSession deletes a session and its samples
+// DeleteSession deletes a session with its samples and key stats
 func (s *Store) DeleteSession(id int64) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -307,6 +717,11 @@ func (s *Store) DeleteSession(id int64) error {
 		return err
 	}
 
+	_, err = tx.Exec("DELETE FROM key_stats WHERE session_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	_, err = tx.Exec("DELETE FROM sessions WHERE id = ?", id)
 	if err != nil {
 		return err
@@ -315,3 +730,131 @@ func (s *Store) DeleteSession(id int64) error {
 	return tx.Commit()
 }
 
+// DeleteAllSessions deletes every session, sample and key stat and resets
+// the ID counters so the next session starts again at 1
+func (s *Store) DeleteAllSessions() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM samples")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM key_stats")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM sessions")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM sqlite_sequence WHERE name IN ('sessions', 'samples', 'key_stats')")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// KeyStat holds keystroke counts for one character aggregated over sessions
+type KeyStat struct {
+	Key      string
+	Attempts int
+	Errors   int
+}
+
+// GetKeyStats aggregates key stats across all sessions
+func (s *Store) GetKeyStats() ([]KeyStat, error) {
+	rows, err := s.db.Query(`
+		SELECT key, SUM(attempts), SUM(errors)
+		FROM key_stats
+		GROUP BY key
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []KeyStat
+	for rows.Next() {
+		var stat KeyStat
+		if err := rows.Scan(&stat.Key, &stat.Attempts, &stat.Errors); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetWeakestKeys returns up to limit characters with the highest error rate
+// across all sessions, ignoring characters typed fewer than minAttempts times
+func (s *Store) GetWeakestKeys(limit, minAttempts int) ([]KeyStat, error) {
+	return s.GetWeakestKeysContext(context.Background(), limit, minAttempts)
+}
+
+// GetWeakestKeysContext is GetWeakestKeys with a context
+func (s *Store) GetWeakestKeysContext(ctx context.Context, limit, minAttempts int) ([]KeyStat, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT key, SUM(attempts) AS total_attempts, SUM(errors) AS total_errors
+		FROM key_stats
+		GROUP BY key
+		HAVING total_attempts >= ? AND total_errors > 0
+		ORDER BY CAST(total_errors AS REAL) / total_attempts DESC, total_errors DESC
+		LIMIT ?
+	`, minAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []KeyStat
+	for rows.Next() {
+		var stat KeyStat
+		if err := rows.Scan(&stat.Key, &stat.Attempts, &stat.Errors); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetDailyCounts returns the number of sessions started on each calendar
+// day, keyed by date as YYYY-MM-DD. Days are bucketed in the local time
+// zone in Go, since SQLite's DATE() would bucket stored offsets in UTC.
+func (s *Store) GetDailyCounts() (map[string]int, error) {
+	return s.GetDailyCountsMinWPM(0)
+}
+
+// GetDailyCountsMinWPM is like GetDailyCounts but only counts sessions of
+// at least minWPM
+func (s *Store) GetDailyCountsMinWPM(minWPM float64) (map[string]int, error) {
+	return s.GetDailyCountsMinWPMContext(context.Background(), minWPM)
+}
+
+// GetDailyCountsMinWPMContext is GetDailyCountsMinWPM with a context
+func (s *Store) GetDailyCountsMinWPMContext(ctx context.Context, minWPM float64) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT started_at FROM sessions WHERE wpm >= ?`, minWPM)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var startedAt time.Time
+		if err := rows.Scan(&startedAt); err != nil {
+			return nil, err
+		}
+		counts[startedAt.Local().Format("2006-01-02")]++
+	}
+
+	return counts, rows.Err()
+}
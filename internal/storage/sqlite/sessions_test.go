@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// openTestStore opens a fresh database in a temporary directory
+func openTestStore(tb testing.TB) *Store {
+	tb.Helper()
+	store, err := OpenPath(filepath.Join(tb.TempDir(), "mtcli.db"))
+	if err != nil {
+		tb.Fatalf("OpenPath: %v", err)
+	}
+	tb.Cleanup(func() { store.Close() })
+	return store
+}
+
+// base is the start time the test sessions count from
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// saveTestSessions saves sessions and returns them with their IDs set
+func saveTestSessions(t *testing.T, store *Store, sessions []Session) []Session {
+	t.Helper()
+	for i := range sessions {
+		id, err := store.SaveSession(&sessions[i], nil, nil)
+		if err != nil {
+			t.Fatalf("SaveSession: %v", err)
+		}
+		sessions[i].ID = id
+	}
+	return sessions
+}
+
+func TestListSessionsFilter(t *testing.T) {
+	store := openTestStore(t)
+	day := 24 * time.Hour
+	saved := saveTestSessions(t, store, []Session{
+		{StartedAt: base, Mode: "words", WPM: 40, Accuracy: 90},                 // 1
+		{StartedAt: base.Add(1 * day), Mode: "words", WPM: 60, Accuracy: 98},    // 2
+		{StartedAt: base.Add(2 * day), Mode: "timer", WPM: 70, Accuracy: 97},    // 3
+		{StartedAt: base.Add(3 * day), Mode: "words", WPM: 80, Accuracy: 95},    // 4
+		{StartedAt: base.Add(4 * day), Mode: "words", WPM: 90, Accuracy: 99},    // 5
+		{StartedAt: base.Add(5 * day), Mode: "words", WPM: 120, Accuracy: 99},   // 6
+		{StartedAt: base.Add(6 * day), Mode: "words", WPM: 75, Accuracy: 96.5},  // 7
+		{StartedAt: base.Add(7 * day), Mode: "quote", WPM: 75, Accuracy: 100},   // 8
+		{StartedAt: base.Add(8 * day), Mode: "words", WPM: 85, Accuracy: 100},   // 9
+		{StartedAt: base.Add(9 * day), Mode: "words", WPM: 65.5, Accuracy: 100}, // 10
+	})
+	id := func(n int) int64 { return saved[n-1].ID }
+
+	tests := []struct {
+		name   string
+		filter SessionFilter
+		want   []int64 // newest first
+	}{
+		{"no filter", SessionFilter{}, []int64{id(10), id(9), id(8), id(7), id(6), id(5), id(4), id(3), id(2), id(1)}},
+		{"limit", SessionFilter{Limit: 3}, []int64{id(10), id(9), id(8)}},
+		{"mode", SessionFilter{Mode: "timer"}, []int64{id(3)}},
+		{"WPM range is inclusive", SessionFilter{MinWPM: 65.5, MaxWPM: 85}, []int64{id(10), id(9), id(8), id(7), id(4), id(3)}},
+		{"since inclusive, until exclusive", SessionFilter{Since: base.Add(2 * day), Until: base.Add(4 * day)}, []int64{id(4), id(3)}},
+		{
+			"mode, WPM, and accuracy",
+			SessionFilter{Mode: "words", MinWPM: 60, MaxWPM: 100, MinAccuracy: 96.5},
+			[]int64{id(10), id(9), id(7), id(5), id(2)},
+		},
+		{
+			"everything",
+			SessionFilter{
+				Mode:        "words",
+				MinWPM:      60,
+				MaxWPM:      100,
+				MinAccuracy: 96,
+				Since:       base.Add(1 * day),
+				Until:       base.Add(9 * day),
+				Limit:       2,
+			},
+			[]int64{id(9), id(7)},
+		},
+		{"nothing matches", SessionFilter{Mode: "quote", MaxWPM: 50}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := store.ListSessions(tt.filter)
+			if err != nil {
+				t.Fatalf("ListSessions: %v", err)
+			}
+			var got []int64
+			for _, s := range sessions {
+				got = append(got, s.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListSessions(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListLatestSessionsOrder(t *testing.T) {
+	store := openTestStore(t)
+	saved := saveTestSessions(t, store, []Session{
+		{StartedAt: base, Mode: "words", WPM: 50},
+		{StartedAt: base.Add(time.Hour), Mode: "words", WPM: 60},
+		{StartedAt: base.Add(2 * time.Hour), Mode: "words", WPM: 70},
+	})
+
+	// The two most recent, oldest first
+	sessions, err := store.ListLatestSessions(SessionFilter{Limit: 2, MinWPM: 55})
+	if err != nil {
+		t.Fatalf("ListLatestSessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != saved[1].ID || sessions[1].ID != saved[2].ID {
+		t.Errorf("ListLatestSessions = %+v, want sessions %d and %d", sessions, saved[1].ID, saved[2].ID)
+	}
+}
+
+func BenchmarkSaveSession(b *testing.B) {
+	store := openTestStore(b)
+
+	// A four-minute test sampled every 500ms, with per-key stats for
+	// the lowercase letters
+	samples := make([]SessionSample, 500)
+	for i := range samples {
+		samples[i] = SessionSample{TimeMs: int64(i) * 500, WPM: 70, RawWPM: 75, Errors: i / 20}
+	}
+	keyStats := make([]SessionKeyStat, 0, 26)
+	for r := 'a'; r <= 'z'; r++ {
+		keyStats = append(keyStats, SessionKeyStat{Key: string(r), Attempts: 40, Errors: 2})
+	}
+	session := Session{StartedAt: base, Mode: "timer", Seconds: 250, DurationMs: 250000, WPM: 70, RawWPM: 75}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		session.StartedAt = base.Add(time.Duration(i) * time.Minute)
+		if _, err := store.SaveSession(&session, samples, keyStats); err != nil {
+			b.Fatalf("SaveSession: %v", err)
+		}
+	}
+}
+
+func TestImportedSessionOtherTimeZone(t *testing.T) {
+	store := openTestStore(t)
+
+	// 12:00 in Tokyo is 03:00 UTC, before the local session at 05:00 UTC.
+	// Stored with its own offset, its text would sort after it.
+	tokyo := time.FixedZone("JST", 9*60*60)
+	local := saveTestSessions(t, store, []Session{{StartedAt: base.Add(-7 * time.Hour).Local(), Mode: "words"}})[0]
+	imported := &Session{StartedAt: base.In(tokyo).Add(-9 * time.Hour), Mode: "words"}
+	importedID, saved, err := store.SaveImportedSession(imported, nil)
+	if err != nil || !saved {
+		t.Fatalf("SaveImportedSession = %v, %v", saved, err)
+	}
+
+	sessions, err := store.ListSessions(SessionFilter{})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != local.ID || sessions[1].ID != importedID {
+		t.Errorf("ListSessions order = %+v, want the local session first, then the imported one", sessions)
+	}
+	if !sessions[1].StartedAt.Equal(imported.StartedAt) {
+		t.Errorf("imported StartedAt = %v, want %v", sessions[1].StartedAt, imported.StartedAt)
+	}
+
+	// Since 04:00 UTC takes the local session only
+	since, err := store.ListSessions(SessionFilter{Since: base.Add(-8 * time.Hour)})
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(since) != 1 || since[0].ID != local.ID {
+		t.Errorf("ListSessions since 04:00 UTC = %+v, want only session %d", since, local.ID)
+	}
+
+	// Importing it again matches the stored copy despite the other offset
+	if _, saved, err := store.SaveImportedSession(imported, nil); err != nil || saved {
+		t.Errorf("second SaveImportedSession = %v, %v, want a duplicate", saved, err)
+	}
+}
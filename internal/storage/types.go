@@ -4,20 +4,35 @@ import "time"
 
 // Session represents a stored typing test session
 type Session struct {
-	ID            int64
-	StartedAt     time.Time
-	Mode          string
-	Seconds       int
-	Words         int
-	QuoteID       string
-	TargetLen     int
-	DurationMs    int64
-	CorrectChars  int
-	IncorrectChars int
-	TotalTyped    int
-	Accuracy      float64
-	WPM           float64
-	RawWPM        float64
+	ID               int64
+	StartedAt        time.Time
+	Mode             string
+	Seconds          int
+	Words            int
+	QuoteID          string
+	TargetLen        int
+	DurationMs       int64
+	CorrectChars     int
+	IncorrectChars   int
+	TotalTyped       int
+	Accuracy         float64
+	WPM              float64
+	RawWPM           float64
+	Source           string
+	Consistency      float64
+	Correction       string
+	TargetText       string
+	Seed             int64
+	TargetWPM        float64
+	Corrections      int
+	TimeToFirstKeyMs int64
+	PeakWPM          float64
+	MedianWPM        float64
+	TypedText        string
+	Difficulty       string
+	MistakeDrill     bool
+	FirstTryAccuracy float64
+	TargetHash       string
 }
 
 // SessionSample represents a speed sample for a session
@@ -26,18 +41,29 @@ type SessionSample struct {
 	TimeMs    int64
 	WPM       float64
 	RawWPM    float64
+	Errors    int
+}
+
+// SessionKeyStat holds keystroke counts for one target character in a session
+type SessionKeyStat struct {
+	SessionID int64
+	Key       string
+	Attempts  int
+	Errors    int
 }
 
 // Stats represents aggregate statistics
 type Stats struct {
-	TotalTests    int
-	TotalTime     time.Duration
-	AverageWPM    float64
-	BestWPM       float64
-	AverageAccuracy float64
-	Last7DaysAvgWPM  float64
-	Last30DaysAvgWPM float64
-	ModeStats     map[string]ModeStats
+	TotalTests         int
+	TotalTime          time.Duration
+	AverageWPM         float64
+	BestWPM            float64
+	AverageAccuracy    float64
+	BestAccuracy       float64
+	AverageConsistency float64
+	Last7DaysAvgWPM    float64
+	Last30DaysAvgWPM   float64
+	ModeStats          map[string]ModeStats
 }
 
 // ModeStats represents statistics for a specific mode
@@ -47,10 +73,22 @@ type ModeStats struct {
 	BestWPM    float64
 }
 
+// SessionFilter selects sessions for ListSessions. Zero values leave the
+// corresponding filter off.
+type SessionFilter struct {
+	Limit       int
+	Mode        string
+	Since       time.Time
+	Until       time.Time
+	MinWPM      float64
+	MaxWPM      float64
+	MinAccuracy float64
+}
+
 // Store defines the interface for session storage
 type Store interface {
 	// SaveSession saves a completed session and its samples
-	SaveSession(session *Session, samples []SessionSample) (int64, error)
+	SaveSession(session *Session, samples []SessionSample, keyStats []SessionKeyStat) (int64, error)
 
 	// GetSession retrieves a session by ID
 	GetSession(id int64) (*Session, error)
@@ -58,13 +96,18 @@ type Store interface {
 	// GetSamples retrieves samples for a session
 	GetSamples(sessionID int64) ([]SessionSample, error)
 
-	// ListSessions retrieves recent sessions with optional filtering
-	ListSessions(limit int, mode string) ([]Session, error)
+	// GetSamplesForSessions retrieves samples for several sessions at once
+	GetSamplesForSessions(sessionIDs []int64) (map[int64][]SessionSample, error)
+
+	// ListSessions retrieves recent sessions matching a filter
+	ListSessions(filter SessionFilter) ([]Session, error)
 
-	// GetStats calculates aggregate statistics
-	GetStats() (*Stats, error)
+	// GetStats calculates aggregate statistics over a date range
+	GetStats(since, until time.Time) (*Stats, error)
+
+	// GetStatsForMode calculates aggregate statistics for one mode
+	GetStatsForMode(mode string, since, until time.Time) (*Stats, error)
 
 	// Close closes the storage connection
 	Close() error
 }
-
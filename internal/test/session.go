@@ -1,16 +1,53 @@
 package test
 
 import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"sync"
 	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
-// Session manages the state of a typing test
+// Session manages the state of a typing test. It is safe for concurrent use:
+// the timer goroutine, the input loop and the renderer all go through mu.
 type Session struct {
+	mu           sync.Mutex
 	state        *SessionState
 	metrics      *MetricsTracker
 	onUpdate     func(*SessionState)
 	timerSeconds int
 	timerDone    chan struct{}
+	maxSeconds   int // time cap outside timer mode; 0 for none
+	maxWords     int // word cap in timer mode; 0 for none
+	wordCapAt    int // typed length that completes the word cap; 0 for none
+	strictWords  bool
+	correction   Correction
+	extend       func() string // grows the target; nil once it is fixed
+
+	// In edit mode the caret can move back into the typed text, and back
+	// counts the typed characters after it. Typing, backspace, and delete
+	// then work at the caret.
+	edit bool
+	back int
+
+	// lastFirstTry is the first-try state of the last typed position from
+	// before it was typed, restored if a combining mark corrects it
+	lastFirstTry firstTryState
+
+	// Pause bookkeeping: pausedAt is set while paused, pausedTotal is the
+	// time spent paused after the session started
+	pausedAt    time.Time
+	pausedTotal time.Duration
+
+	// AFK bookkeeping: once no key has come for afkTimeout the clock
+	// freezes until the next one, and the frozen time is added to idleTotal
+	afkTimeout time.Duration
+	lastKeyAt  time.Time
+	idleTotal  time.Duration
 }
 
 // MetricsTracker tracks typing metrics during the session
@@ -20,48 +57,279 @@ type MetricsTracker struct {
 	sampleInterval time.Duration
 	totalTyped     int
 	correctChars   int
+	incorrectChars int // wrong keystrokes; not undone by backspace
+	corrections    int // backspace presses that removed a character
+	keyStats       map[rune]KeyStat
+	firstTry       []firstTryState // per target position
+
+	// Keystroke timing: keyTimes holds when the first maxKeyTimes keys
+	// landed, in active milliseconds since the start. The gap total and
+	// count cover every key, so the average stays exact past the cap.
+	keyTimes    []int64
+	maxKeyTimes int
+	lastKeyMs   int64
+	keyCount    int
+	gapTotalMs  int64
+}
+
+// firstTryState records how a target position has been typed so far
+type firstTryState uint8
+
+const (
+	firstTryUntouched firstTryState = iota
+	firstTryClean                   // right on the first attempt and never erased
+	firstTryDirty                   // typed wrong or erased at some point
+)
+
+// markTyped records an attempt at target position idx
+func (m *MetricsTracker) markTyped(idx int, correct bool) {
+	if !correct {
+		m.firstTry[idx] = firstTryDirty
+	} else if m.firstTry[idx] == firstTryUntouched {
+		m.firstTry[idx] = firstTryClean
+	}
+}
+
+// markErased records that the character at idx was backspaced
+func (m *MetricsTracker) markErased(idx int) {
+	m.firstTry[idx] = firstTryDirty
+}
+
+// markKey records a keystroke landing at ms into the session
+func (m *MetricsTracker) markKey(ms int64) {
+	if m.keyCount > 0 {
+		m.gapTotalMs += ms - m.lastKeyMs
+	}
+	m.keyCount++
+	m.lastKeyMs = ms
+	if len(m.keyTimes) < m.maxKeyTimes {
+		m.keyTimes = append(m.keyTimes, ms)
+	}
+}
+
+// keyLatency returns the mean and 95th percentile time between keystrokes
+// in milliseconds, or zeros with fewer than two keys. The percentile comes
+// from the recorded keystrokes only.
+func (m *MetricsTracker) keyLatency() (avg, p95 float64) {
+	if m.keyCount < 2 {
+		return 0, 0
+	}
+	avg = float64(m.gapTotalMs) / float64(m.keyCount-1)
+
+	gaps := KeyGaps(m.keyTimes)
+	if len(gaps) == 0 {
+		return avg, 0
+	}
+	slices.Sort(gaps)
+	rank := int(math.Ceil(0.95*float64(len(gaps)))) - 1
+	return avg, float64(gaps[rank])
+}
+
+// KeyGaps returns the time between each keystroke and the one before it,
+// given when they landed
+func KeyGaps(keyTimes []int64) []int64 {
+	if len(keyTimes) < 2 {
+		return nil
+	}
+	gaps := make([]int64, len(keyTimes)-1)
+	for i := range gaps {
+		gaps[i] = keyTimes[i+1] - keyTimes[i]
+	}
+	return gaps
 }
 
-// NewMetricsTracker creates a new metrics tracker
-func NewMetricsTracker() *MetricsTracker {
+// firstTryAccuracy returns the percentage of attempted target positions
+// that were typed right on the first attempt and never erased
+func (m *MetricsTracker) firstTryAccuracy() float64 {
+	var attempted, clean int
+	for _, state := range m.firstTry {
+		switch state {
+		case firstTryClean:
+			clean++
+			attempted++
+		case firstTryDirty:
+			attempted++
+		}
+	}
+	if attempted == 0 {
+		return 0
+	}
+	return float64(clean) / float64(attempted) * 100
+}
+
+// Sample interval bounds; shorter intervals give more chart points
+const (
+	DefaultSampleInterval = 500 * time.Millisecond
+	MinSampleInterval     = 50 * time.Millisecond
+)
+
+// DefaultMaxKeyTimes is how many keystroke times a session keeps unless
+// told otherwise, enough for a few minutes at any speed
+const DefaultMaxKeyTimes = 20000
+
+// NewMetricsTracker creates a new metrics tracker that samples speed every
+// interval, or every DefaultSampleInterval if interval is 0
+func NewMetricsTracker(interval time.Duration) *MetricsTracker {
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
 	return &MetricsTracker{
 		samples:        make([]Sample, 0),
-		sampleInterval: 500 * time.Millisecond, // Sample every 500ms for better chart resolution
+		sampleInterval: interval,
+		keyStats:       make(map[rune]KeyStat),
 	}
 }
 
 // SessionOptions holds options for creating a session
 type SessionOptions struct {
-	Target       *Target
-	TimerSeconds int // Only used in timer mode
-	OnUpdate     func(*SessionState)
+	Target         *Target
+	TimerSeconds   int  // Only used in timer mode
+	MaxWords       int  // Timer mode: also finish after this many words
+	MaxSeconds     int  // Other modes: also finish after this many seconds
+	StrictWords    bool // Space skips to the next word, marking the rest incorrect
+	Correction     Correction
+	Edit           bool          // Arrow keys, Home, and End move the caret to edit text already typed
+	SampleInterval time.Duration // Time between speed samples; 0 for the default
+	MaxKeyTimes    int           // Keystroke times kept for latency stats; 0 for DefaultMaxKeyTimes
+	AFKTimeout     time.Duration // Freeze the clock after this long without a key; 0 disables
+	OnUpdate       func(*SessionState)
 }
 
-// NewSession creates a new typing session
+// NewSession creates a new typing session. The target text is normalized
+// to NFC, so an accented letter is one character whether the text spelled
+// it precomposed or as a base letter and combining mark.
 func NewSession(opts SessionOptions) *Session {
+	opts.Target.Text = norm.NFC.String(opts.Target.Text)
 	targetRunes := []rune(opts.Target.Text)
 	charStates := make([]CharState, len(targetRunes))
 	for i := range charStates {
 		charStates[i] = CharUnattempted
 	}
 
-	return &Session{
+	metrics := NewMetricsTracker(opts.SampleInterval)
+	metrics.firstTry = make([]firstTryState, len(targetRunes))
+	metrics.maxKeyTimes = opts.MaxKeyTimes
+	if metrics.maxKeyTimes <= 0 {
+		metrics.maxKeyTimes = DefaultMaxKeyTimes
+	}
+
+	s := &Session{
 		state: &SessionState{
 			Target:      opts.Target,
 			TargetRunes: targetRunes,
 			TypedRunes:  make([]rune, 0, len(targetRunes)),
 			CharStates:  charStates,
 		},
-		metrics:      NewMetricsTracker(),
+		metrics:      metrics,
 		onUpdate:     opts.OnUpdate,
 		timerSeconds: opts.TimerSeconds,
+		maxSeconds:   opts.MaxSeconds,
+		maxWords:     opts.MaxWords,
+		wordCapAt:    wordEnd(targetRunes, opts.MaxWords),
+		strictWords:  opts.StrictWords,
+		correction:   opts.Correction,
+		extend:       opts.Target.Extend,
+		edit:         opts.Edit,
+		afkTimeout:   opts.AFKTimeout,
+	}
+	s.grow()
+	return s
+}
+
+// extendMargin is how many characters an extensible target keeps ahead of
+// the caret, enough to fill the lines the renderer shows past it
+const extendMargin = 300
+
+// grow appends text from the target's Extend until extendMargin characters
+// lie ahead of the caret. All target slices grow together under s.mu, and
+// readers only ever see snapshot copies, so the render and sample loops
+// can't catch them half-grown. The caller must hold s.mu.
+func (s *Session) grow() {
+	for s.extend != nil && len(s.state.TargetRunes)-len(s.state.TypedRunes) < extendMargin {
+		more := norm.NFC.String(s.extend())
+		if more == "" {
+			// A source that runs dry leaves a fixed text, finished as usual
+			s.extend = nil
+			return
+		}
+
+		runes := []rune(" " + more)
+		if len(s.state.TargetRunes) == 0 {
+			runes = runes[1:]
+		}
+		s.state.TargetRunes = append(s.state.TargetRunes, runes...)
+		s.state.CharStates = append(s.state.CharStates, make([]CharState, len(runes))...)
+		s.metrics.firstTry = append(s.metrics.firstTry, make([]firstTryState, len(runes))...)
 	}
 }
 
+// wordEnd returns the rune offset just past the nth word of target, or 0
+// if n is 0 or target has fewer than n words
+func wordEnd(target []rune, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	words := 0
+	for i, r := range target {
+		if isWordBoundary(r) {
+			continue
+		}
+		if i+1 == len(target) || isWordBoundary(target[i+1]) {
+			words++
+			if words == n {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// missedWords returns the words of target with at least one character
+// left incorrect, each once, in the order they first appear
+func missedWords(target []rune, states []CharState) []string {
+	var missed []string
+	seen := make(map[string]bool)
+	start := 0
+	for i := 0; i <= len(target); i++ {
+		if i < len(target) && !isWordBoundary(target[i]) {
+			continue
+		}
+		if i > start {
+			word := string(target[start:i])
+			if !seen[word] && slices.Contains(states[start:i], CharIncorrect) {
+				seen[word] = true
+				missed = append(missed, word)
+			}
+		}
+		start = i + 1
+	}
+	return missed
+}
+
 // Start begins the session (called when first key is pressed or timer starts)
 func (s *Session) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start()
+}
+
+// Arm marks the moment the test is ready for input, i.e. when the countdown
+// finishes. The time from here to the first keystroke is reported as
+// TimeToFirstKeyMs.
+func (s *Session) Arm() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state.ArmedAt.IsZero() {
+		s.state.ArmedAt = time.Now()
+	}
+}
+
+// start begins the session; the caller must hold s.mu
+func (s *Session) start() {
 	s.state.StartedAt = time.Now()
 	s.metrics.lastSampleTime = s.state.StartedAt
+	s.lastKeyAt = s.state.StartedAt
 
 	// Take initial sample
 	s.metrics.samples = append(s.metrics.samples, Sample{
@@ -70,56 +338,258 @@ func (s *Session) Start() {
 		RawWPM: 0,
 	})
 
-	// Start timer for timer mode
-	if s.state.Target.Mode == ModeTimer && s.timerSeconds > 0 {
+	// Start timer for timer mode, or for the time cap in other modes
+	limit := s.maxSeconds
+	if s.state.Target.Mode == ModeTimer {
+		limit = s.timerSeconds
+	}
+	if limit > 0 {
 		s.timerDone = make(chan struct{})
-		go func() {
-			timer := time.NewTimer(time.Duration(s.timerSeconds) * time.Second)
-			select {
-			case <-timer.C:
-				s.state.Finished = true
-				s.state.EndedAt = time.Now()
-			case <-s.timerDone:
-				timer.Stop()
-			}
-		}()
+		go s.runTimer(time.Duration(limit)*time.Second, s.timerDone)
+	}
+}
+
+// pausePollInterval is how often the timer goroutine checks for a resume
+const pausePollInterval = 100 * time.Millisecond
+
+// runTimer finishes the session once limit of (unpaused) time has elapsed.
+// The deadline is recomputed after every wakeup so paused time is excluded.
+func (s *Session) runTimer(limit time.Duration, done chan struct{}) {
+	for {
+		s.mu.Lock()
+		if s.state.Finished || s.state.Aborted {
+			s.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		wait := limit - s.elapsed()
+		if s.pausedAt.IsZero() && !s.idle(now) && wait <= 0 {
+			s.state.Finished = true
+			s.state.EndedAt = time.Now()
+			s.state.EndReason = EndTime
+			s.mu.Unlock()
+			return
+		}
+		if !s.pausedAt.IsZero() || s.idle(now) {
+			wait = pausePollInterval
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Pause freezes the session clock until Resume is called. Pausing before
+// the first keystroke is allowed; the clock simply hasn't started yet.
+func (s *Session) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state.Finished || s.state.Aborted || !s.pausedAt.IsZero() {
+		return
+	}
+	s.pausedAt = time.Now()
+}
+
+// Resume restarts the session clock after Pause
+func (s *Session) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pausedAt.IsZero() {
+		return
 	}
+	if !s.state.StartedAt.IsZero() {
+		paused := time.Since(s.pausedAt)
+		s.pausedTotal += paused
+		// A pause is not idle time
+		s.lastKeyAt = s.lastKeyAt.Add(paused)
+	} else if !s.state.ArmedAt.IsZero() {
+		// Don't count a pause before the first key as reaction time
+		s.state.ArmedAt = s.state.ArmedAt.Add(time.Since(s.pausedAt))
+	}
+	s.pausedAt = time.Time{}
+}
+
+// IsPaused returns whether the session is paused
+func (s *Session) IsPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.pausedAt.IsZero()
 }
 
 // HandleKey processes a key input and updates session state
 func (s *Session) HandleKey(keyType int, r rune) {
-	if s.state.Finished || s.state.Aborted {
+	s.mu.Lock()
+	if s.state.Finished || s.state.Aborted || !s.pausedAt.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+
+	// Only edit mode has a caret to move, and text after it to delete
+	if !s.edit && keyType >= KeyTypeDelete && keyType <= KeyTypeEnd {
+		s.mu.Unlock()
+		return
+	}
+
+	// Moving the caret, or deleting past the end, leaves the text as it
+	// is, so it neither starts the clock nor counts as a keystroke
+	if keyType >= KeyTypeLeft && keyType <= KeyTypeEnd || keyType == KeyTypeDelete && s.back == 0 {
+		s.moveCaret(keyType)
+		snapshot := s.snapshot()
+		s.mu.Unlock()
+		if s.onUpdate != nil {
+			s.onUpdate(snapshot)
+		}
 		return
 	}
 
 	// Start on first keystroke if not started
 	if s.state.StartedAt.IsZero() {
-		s.Start()
+		s.start()
 	}
+	s.noteKey()
+	s.metrics.markKey(s.elapsed().Milliseconds())
 
 	switch keyType {
 	case KeyTypeRune:
-		s.handleRune(r)
+		if s.back > 0 {
+			s.insertRune(r)
+			break
+		}
+		if unicode.Is(unicode.M, r) && s.handleMark(r) {
+			break
+		}
+		if r == ' ' && s.strictWords {
+			s.handleSpace()
+		} else {
+			s.handleRune(r)
+		}
 	case KeyTypeBackspace:
 		s.handleBackspace()
+	case KeyTypeDeleteWord:
+		s.handleDeleteWord()
+	case KeyTypeDelete:
+		s.handleDelete()
 	}
 
-	// Check for completion (words/quote mode)
-	if s.state.Target.Mode != ModeTimer {
-		if len(s.state.TypedRunes) >= len(s.state.TargetRunes) {
-			s.finish()
-		}
+	s.grow()
+
+	// Check for completion (words/quote mode), or for the word cap
+	switch {
+	case s.extend == nil && s.state.Target.Mode != ModeTimer && len(s.state.TypedRunes) >= len(s.state.TargetRunes):
+		s.state.EndReason = EndCompleted
+		s.finish()
+	case s.wordCapAt > 0 && len(s.state.TypedRunes) >= s.wordCapAt:
+		s.state.EndReason = EndWords
+		s.finish()
 	}
 
 	// Take sample if interval has passed
 	s.maybeTakeSample()
 
-	// Notify listener
+	snapshot := s.snapshot()
+	s.mu.Unlock()
+
+	// Notify listener outside the lock so it may call back into the session
 	if s.onUpdate != nil {
-		s.onUpdate(s.state)
+		s.onUpdate(snapshot)
 	}
 }
 
+// moveCaret moves the edit caret for an arrow, Home, or End key. Any other
+// key leaves it where it is.
+func (s *Session) moveCaret(keyType int) {
+	switch keyType {
+	case KeyTypeLeft:
+		s.back = min(s.back+1, len(s.state.TypedRunes))
+	case KeyTypeRight:
+		s.back = max(s.back-1, 0)
+	case KeyTypeHome:
+		s.back = len(s.state.TypedRunes) - s.lineStart()
+	case KeyTypeEnd:
+		s.back = 0
+	}
+}
+
+// Submit finishes a non-timer session early when the caret is on or past
+// the last character, even if that character was typed wrong. It reports
+// whether the session was finished; mid-text, before the first keystroke,
+// and in timer mode it does nothing.
+func (s *Session) Submit() bool {
+	s.mu.Lock()
+	if s.state.Finished || s.state.Aborted || !s.pausedAt.IsZero() ||
+		s.state.StartedAt.IsZero() || s.state.Target.Mode == ModeTimer ||
+		len(s.state.TypedRunes) < len(s.state.TargetRunes)-1 {
+		s.mu.Unlock()
+		return false
+	}
+
+	s.noteKey()
+	s.state.EndReason = EndCompleted
+	s.finish()
+
+	snapshot := s.snapshot()
+	s.mu.Unlock()
+
+	if s.onUpdate != nil {
+		s.onUpdate(snapshot)
+	}
+	return true
+}
+
+// Stop ends the session by hand, for a target with no finish of its own
+// such as zen mode. The untyped rest of the text is dropped, since it was
+// only there to type into. It reports whether the session was stopped;
+// before the first keystroke there is nothing to report and it does
+// nothing.
+func (s *Session) Stop() bool {
+	s.mu.Lock()
+	if s.state.Finished || s.state.Aborted || s.state.StartedAt.IsZero() {
+		s.mu.Unlock()
+		return false
+	}
+
+	n := len(s.state.TypedRunes)
+	s.state.TargetRunes = s.state.TargetRunes[:n]
+	s.state.CharStates = s.state.CharStates[:n]
+	s.metrics.firstTry = s.metrics.firstTry[:n]
+
+	s.extend = nil
+	s.state.EndReason = EndStopped
+	s.finish()
+
+	snapshot := s.snapshot()
+	s.mu.Unlock()
+
+	if s.onUpdate != nil {
+		s.onUpdate(snapshot)
+	}
+	return true
+}
+
+// noteKey records a keystroke, ending any AFK stretch. The caller must
+// hold s.mu.
+func (s *Session) noteKey() {
+	now := time.Now()
+	if s.idle(now) {
+		s.idleTotal += now.Sub(s.lastKeyAt.Add(s.afkTimeout))
+	}
+	s.lastKeyAt = now
+}
+
+// idle reports whether the clock is frozen at now because no key has come
+// for afkTimeout. The caller must hold s.mu.
+func (s *Session) idle(now time.Time) bool {
+	return s.afkTimeout > 0 && !s.lastKeyAt.IsZero() && now.Sub(s.lastKeyAt) > s.afkTimeout
+}
+
 // handleRune processes a typed character
 func (s *Session) handleRune(r rune) {
 	idx := len(s.state.TypedRunes)
@@ -129,24 +599,260 @@ func (s *Session) handleRune(r rune) {
 		return
 	}
 
-	s.state.TypedRunes = append(s.state.TypedRunes, r)
 	s.metrics.totalTyped++
 
+	target := s.state.TargetRunes[idx]
+	keyStat := s.metrics.keyStats[target]
+	keyStat.Attempts++
+
+	// In stop-on-error mode a wrong keystroke is rejected without advancing.
+	// It still counts as typed and incorrect, so accuracy reflects it.
+	if s.correction == CorrectionStopOnError && r != target {
+		s.metrics.incorrectChars++
+		s.metrics.markTyped(idx, false)
+		keyStat.Errors++
+		s.metrics.keyStats[target] = keyStat
+		return
+	}
+
+	s.state.TypedRunes = append(s.state.TypedRunes, r)
+	s.lastFirstTry = s.metrics.firstTry[idx]
+	s.metrics.markTyped(idx, r == target)
+
 	// Update char state
-	if r == s.state.TargetRunes[idx] {
+	if r == target {
 		s.state.CharStates[idx] = CharCorrect
 		s.metrics.correctChars++
 	} else {
 		s.state.CharStates[idx] = CharIncorrect
+		s.metrics.incorrectChars++
+		keyStat.Errors++
 	}
+
+	s.metrics.keyStats[target] = keyStat
 }
 
-// handleBackspace removes the last typed character
+// handleMark attaches a combining mark, which some input methods send as a
+// key of its own after the base letter, to the last typed character. The
+// pair is rescored as one keystroke: 'e' then U+0301 counts as a correct
+// 'é'. It reports false, leaving the mark to be typed as a character, if
+// the target spells the mark out, the pair has no precomposed form, or the
+// base letter was rejected in stop-on-error mode.
+func (s *Session) handleMark(r rune) bool {
+	idx := len(s.state.TypedRunes) - 1
+	if idx < 0 || s.correction == CorrectionStopOnError {
+		return false
+	}
+	if idx+1 < len(s.state.TargetRunes) && s.state.TargetRunes[idx+1] == r {
+		return false
+	}
+	composed := []rune(norm.NFC.String(string([]rune{s.state.TypedRunes[idx], r})))
+	if len(composed) != 1 {
+		return false
+	}
+
+	target := s.state.TargetRunes[idx]
+	keyStat := s.metrics.keyStats[target]
+	wasCorrect := s.state.CharStates[idx] == CharCorrect
+	correct := composed[0] == target
+	s.state.TypedRunes[idx] = composed[0]
+
+	switch {
+	case correct && !wasCorrect:
+		s.state.CharStates[idx] = CharCorrect
+		s.metrics.correctChars++
+		s.metrics.incorrectChars--
+		keyStat.Errors--
+		s.metrics.firstTry[idx] = s.lastFirstTry
+		s.metrics.markTyped(idx, true)
+	case !correct && wasCorrect:
+		s.state.CharStates[idx] = CharIncorrect
+		s.metrics.correctChars--
+		s.metrics.incorrectChars++
+		keyStat.Errors++
+		s.metrics.markTyped(idx, false)
+	}
+	s.metrics.keyStats[target] = keyStat
+	return true
+}
+
+// handleSpace processes a space in strict word mode. If the current word
+// still has untyped characters, the cursor jumps to the start of the next
+// word and the skipped characters are marked incorrect.
+func (s *Session) handleSpace() {
+	idx := len(s.state.TypedRunes)
+	if idx >= len(s.state.TargetRunes) {
+		return
+	}
+
+	// Word already complete: the space is typed normally
+	if isWordBoundary(s.state.TargetRunes[idx]) {
+		s.handleRune(' ')
+		return
+	}
+
+	// Nothing typed in the current word yet: ignore the space
+	if idx == 0 || isWordBoundary(s.state.TargetRunes[idx-1]) {
+		return
+	}
+
+	// Find the boundary that ends the current word (or the end of the text)
+	next := idx
+	for next < len(s.state.TargetRunes) && !isWordBoundary(s.state.TargetRunes[next]) {
+		next++
+	}
+
+	// Skipped characters count as typed but incorrect
+	for i := idx; i < next; i++ {
+		s.state.TypedRunes = append(s.state.TypedRunes, ' ')
+		s.state.CharStates[i] = CharIncorrect
+		s.metrics.totalTyped++
+		s.metrics.incorrectChars++
+		s.metrics.markTyped(i, false)
+	}
+
+	// Type the word-separating space itself; a line break or tab still needs
+	// its own key
+	if next < len(s.state.TargetRunes) && s.state.TargetRunes[next] == ' ' {
+		s.handleRune(' ')
+	}
+}
+
+// isWordBoundary reports whether r separates words in the target
+func isWordBoundary(r rune) bool {
+	return r == ' ' || r == '\n' || r == '\t'
+}
+
+// handleBackspace removes the typed character before the caret
 func (s *Session) handleBackspace() {
-	if len(s.state.TypedRunes) == 0 {
+	if s.correction == CorrectionNoBackspace || s.caret() == 0 {
 		return
 	}
 
+	s.metrics.corrections++
+	s.eraseBefore()
+}
+
+// handleDeleteWord removes the typed characters back to the start of the
+// current word, or of the previous one when the caret sits just after a
+// word boundary. It counts as a single correction.
+func (s *Session) handleDeleteWord() {
+	if s.correction == CorrectionNoBackspace || s.caret() == 0 {
+		return
+	}
+
+	s.metrics.corrections++
+	for n := s.caret(); n > 0 && isWordBoundary(s.state.TargetRunes[n-1]); n-- {
+		s.eraseBefore()
+	}
+	for n := s.caret(); n > 0 && !isWordBoundary(s.state.TargetRunes[n-1]); n-- {
+		s.eraseBefore()
+	}
+}
+
+// handleDelete removes the typed character after the caret, in edit mode.
+// Like backspace it counts as a correction.
+func (s *Session) handleDelete() {
+	if s.correction == CorrectionNoBackspace || s.back == 0 {
+		return
+	}
+
+	s.metrics.corrections++
+	s.removeAt(s.caret())
+	s.back--
+}
+
+// caret returns where the next key lands in the typed text
+func (s *Session) caret() int {
+	return len(s.state.TypedRunes) - s.back
+}
+
+// lineStart returns the start of the target line the caret is on
+func (s *Session) lineStart() int {
+	for i := s.caret(); i > 0; i-- {
+		if s.state.TargetRunes[i-1] == '\n' {
+			return i
+		}
+	}
+	return 0
+}
+
+// eraseBefore removes the typed character before the caret
+func (s *Session) eraseBefore() {
+	if s.back == 0 {
+		s.erase()
+		return
+	}
+	s.removeAt(s.caret() - 1)
+}
+
+// insertRune types r at the caret inside the typed text, in edit mode. The
+// characters after it move along one place. Once the typed text is as long
+// as the target there is no room left, and the key is ignored.
+func (s *Session) insertRune(r rune) {
+	if len(s.state.TypedRunes) >= len(s.state.TargetRunes) {
+		return
+	}
+	idx := s.caret()
+
+	s.metrics.totalTyped++
+
+	target := s.state.TargetRunes[idx]
+	keyStat := s.metrics.keyStats[target]
+	keyStat.Attempts++
+	if r != target {
+		s.metrics.incorrectChars++
+		keyStat.Errors++
+	}
+	s.metrics.keyStats[target] = keyStat
+
+	s.state.TypedRunes = slices.Insert(s.state.TypedRunes, idx, r)
+	s.rescore(idx)
+	s.metrics.markTyped(idx, r == target)
+}
+
+// removeAt removes the typed character at idx, moving the characters after
+// it back one place
+func (s *Session) removeAt(idx int) {
+	s.state.TypedRunes = slices.Delete(s.state.TypedRunes, idx, idx+1)
+	s.rescore(idx)
+}
+
+// rescore compares the typed text with the target again from idx on, after
+// an edit there shifted the rest of it. A position whose state changes
+// counts as typed again, or as erased if it is no longer typed at all.
+func (s *Session) rescore(idx int) {
+	end := min(len(s.state.TypedRunes)+1, len(s.state.TargetRunes))
+	for i := idx; i < end; i++ {
+		state := CharUnattempted
+		if i < len(s.state.TypedRunes) {
+			state = CharIncorrect
+			if s.state.TypedRunes[i] == s.state.TargetRunes[i] {
+				state = CharCorrect
+			}
+		}
+
+		old := s.state.CharStates[i]
+		if state == old {
+			continue
+		}
+		if old == CharCorrect {
+			s.metrics.correctChars--
+		}
+		if state == CharCorrect {
+			s.metrics.correctChars++
+		}
+		if state == CharUnattempted {
+			s.metrics.markErased(i)
+		} else {
+			s.metrics.markTyped(i, state == CharCorrect)
+		}
+		s.state.CharStates[i] = state
+	}
+}
+
+// erase removes the last typed character, reverting its state
+func (s *Session) erase() {
 	idx := len(s.state.TypedRunes) - 1
 
 	// Revert char state
@@ -154,18 +860,21 @@ func (s *Session) handleBackspace() {
 		s.metrics.correctChars--
 	}
 	s.state.CharStates[idx] = CharUnattempted
+	s.metrics.markErased(idx)
 
 	s.state.TypedRunes = s.state.TypedRunes[:idx]
 }
 
-// maybeTakeSample takes a metrics sample if the interval has passed
+// maybeTakeSample takes a metrics sample if the interval has passed.
+// No samples are taken while paused or AFK, and sample times exclude that
+// time.
 func (s *Session) maybeTakeSample() {
-	if s.state.StartedAt.IsZero() {
+	now := time.Now()
+	if s.state.StartedAt.IsZero() || !s.pausedAt.IsZero() || s.idle(now) {
 		return
 	}
-	now := time.Now()
 	if now.Sub(s.metrics.lastSampleTime) >= s.metrics.sampleInterval {
-		elapsed := now.Sub(s.state.StartedAt)
+		elapsed := s.elapsed()
 		sample := s.calculateSample(elapsed)
 		s.metrics.samples = append(s.metrics.samples, sample)
 		s.metrics.lastSampleTime = now
@@ -174,6 +883,9 @@ func (s *Session) maybeTakeSample() {
 
 // TakeSample forces a sample to be taken (called from ticker)
 func (s *Session) TakeSample() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if s.state.StartedAt.IsZero() || s.state.Finished || s.state.Aborted {
 		return
 	}
@@ -195,11 +907,18 @@ func (s *Session) calculateSample(elapsed time.Duration) Sample {
 		TimeMs: elapsed.Milliseconds(),
 		WPM:    netWPM,
 		RawWPM: rawWPM,
+		Errors: s.metrics.incorrectChars,
 	}
 }
 
 // Abort cancels the session
 func (s *Session) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state.Finished || s.state.Aborted {
+		return
+	}
 	s.state.Aborted = true
 	s.state.EndedAt = time.Now()
 	if s.timerDone != nil {
@@ -218,29 +937,56 @@ func (s *Session) finish() {
 
 // IsFinished returns whether the session has ended
 func (s *Session) IsFinished() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.state.Finished || s.state.Aborted
 }
 
 // IsAborted returns whether the session was aborted
 func (s *Session) IsAborted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.state.Aborted
 }
 
-// GetState returns the current session state
+// GetState returns a snapshot of the current session state. The snapshot
+// is a copy, so callers may read it while the session keeps changing.
 func (s *Session) GetState() *SessionState {
-	return s.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot()
+}
+
+// snapshot copies the session state; the caller must hold s.mu
+func (s *Session) snapshot() *SessionState {
+	state := *s.state
+	state.Caret = s.caret()
+	state.TargetRunes = append([]rune(nil), s.state.TargetRunes...)
+	state.TypedRunes = append([]rune(nil), s.state.TypedRunes...)
+	state.CharStates = append([]CharState(nil), s.state.CharStates...)
+	return &state
 }
 
 // GetResult calculates and returns the final session result
 func (s *Session) GetResult() *SessionResult {
-	// Take final sample
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Take final sample. An interval sample taken just before the end would
+	// sit right next to it, so it is replaced instead; the threshold matches
+	// the one consistency() uses for intervals too short to compare.
 	if !s.state.StartedAt.IsZero() && !s.state.EndedAt.IsZero() {
-		elapsed := s.state.EndedAt.Sub(s.state.StartedAt)
-		finalSample := s.calculateSample(elapsed)
-		s.metrics.samples = append(s.metrics.samples, finalSample)
+		finalSample := s.calculateSample(s.elapsed())
+		samples := s.metrics.samples
+		minGap := (s.metrics.sampleInterval / 2).Milliseconds()
+		if n := len(samples); n > 0 && finalSample.TimeMs-samples[n-1].TimeMs < minGap {
+			samples[n-1] = finalSample
+		} else {
+			s.metrics.samples = append(samples, finalSample)
+		}
 	}
 
-	duration := s.state.EndedAt.Sub(s.state.StartedAt)
+	duration := s.elapsed()
 	minutes := duration.Minutes()
 	if minutes < 0.001 {
 		minutes = 0.001
@@ -249,43 +995,179 @@ func (s *Session) GetResult() *SessionResult {
 	totalTyped := s.metrics.totalTyped
 	correctChars := s.metrics.correctChars
 
+	// Correct characters that were later backspaced are neither correct nor
+	// incorrect, so incorrect keystrokes are counted directly instead of
+	// being derived as totalTyped - correctChars.
+	incorrectChars := s.metrics.incorrectChars
+
 	var accuracy float64
 	if totalTyped > 0 {
 		accuracy = float64(correctChars) / float64(totalTyped) * 100
 	}
 
-	rawWPM := (float64(totalTyped) / 5.0) / minutes
-	netWPM := (float64(correctChars) / 5.0) / minutes
+	rawCPM := float64(totalTyped) / minutes
+	netCPM := float64(correctChars) / minutes
+	rawWPM := rawCPM / 5.0
+	netWPM := netCPM / 5.0
+
+	avgLatency, p95Latency := s.metrics.keyLatency()
+
+	// Zero if the session was never armed or no key was pressed
+	var timeToFirstKeyMs int64
+	if !s.state.ArmedAt.IsZero() && !s.state.StartedAt.IsZero() {
+		timeToFirstKeyMs = s.state.StartedAt.Sub(s.state.ArmedAt).Milliseconds()
+	}
 
 	return &SessionResult{
-		Mode:         s.state.Target.Mode,
-		StartedAt:    s.state.StartedAt,
-		Duration:     duration,
-		TargetLen:    len(s.state.TargetRunes),
-		TotalTyped:   totalTyped,
-		CorrectChars: correctChars,
-		WPM:          netWPM,
-		RawWPM:       rawWPM,
-		Accuracy:     accuracy,
-		Samples:      s.metrics.samples,
-		Metadata:     s.state.Target.Metadata,
+		Mode:             s.state.Target.Mode,
+		StartedAt:        s.state.StartedAt,
+		Duration:         duration,
+		TargetLen:        len(s.state.TargetRunes),
+		TotalTyped:       totalTyped,
+		CorrectChars:     correctChars,
+		IncorrectChars:   incorrectChars,
+		Corrections:      s.metrics.corrections,
+		TimeToFirstKeyMs: timeToFirstKeyMs,
+		WPM:              netWPM,
+		RawWPM:           rawWPM,
+		CPM:              netCPM,
+		RawCPM:           rawCPM,
+		Accuracy:         accuracy,
+		FirstTryAccuracy: s.metrics.firstTryAccuracy(),
+		Consistency:      s.consistency(),
+		PeakWPM:          s.peakWPM(),
+		MedianWPM:        s.medianWPM(),
+		AvgKeyLatencyMs:  avgLatency,
+		P95KeyLatencyMs:  p95Latency,
+		KeyTimesMs:       slices.Clone(s.metrics.keyTimes),
+		Correction:       s.correction,
+		TargetText:       string(s.state.TargetRunes),
+		TypedText:        string(s.state.TypedRunes),
+		Samples:          append([]Sample(nil), s.metrics.samples...),
+		KeyStats:         maps.Clone(s.metrics.keyStats),
+		MissedWords:      missedWords(s.state.TargetRunes, s.state.CharStates),
+		Metadata:         s.state.Target.Metadata,
+		EndReason:        s.state.EndReason,
+		MaxWords:         s.maxWords,
+		MaxSeconds:       s.maxSeconds,
+		IdleTime:         s.idleTotal,
+	}
+}
+
+// consistency scores how steady the typing speed was, from 0 to 100, based
+// on the coefficient of variation of the raw WPM within each sample
+// interval. With fewer than two intervals there is nothing to compare, so the
+// session counts as perfectly consistent. The caller must hold s.mu.
+func (s *Session) consistency() float64 {
+	minInterval := s.metrics.sampleInterval / 2
+
+	var speeds []float64
+	for i := 1; i < len(s.metrics.samples); i++ {
+		prev, cur := s.metrics.samples[i-1], s.metrics.samples[i]
+		dt := time.Duration(cur.TimeMs-prev.TimeMs) * time.Millisecond
+		if dt < minInterval {
+			continue
+		}
+
+		// Samples hold running averages; recover the characters typed in between
+		prevChars := prev.RawWPM * 5 * float64(prev.TimeMs) / 60000
+		curChars := cur.RawWPM * 5 * float64(cur.TimeMs) / 60000
+		speeds = append(speeds, (curChars-prevChars)/5/dt.Minutes())
+	}
+
+	if len(speeds) < 2 {
+		return 100
+	}
+
+	var sum float64
+	for _, v := range speeds {
+		sum += v
+	}
+	mean := sum / float64(len(speeds))
+	if mean <= 0 {
+		return 0
+	}
+
+	var variance float64
+	for _, v := range speeds {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(speeds)))
+
+	return 100 * (1 - math.Tanh(stddev/mean))
+}
+
+// peakWPM returns the highest sampled WPM, skipping the first second where a
+// couple of quick keystrokes give meaningless spikes; the caller must hold s.mu
+func (s *Session) peakWPM() float64 {
+	var peak float64
+	for _, sample := range s.metrics.samples {
+		if sample.TimeMs >= 1000 && sample.WPM > peak {
+			peak = sample.WPM
+		}
+	}
+	return peak
+}
+
+// medianWPM returns the median sampled WPM, ignoring the zero sample taken
+// at the start; the caller must hold s.mu
+func (s *Session) medianWPM() float64 {
+	var speeds []float64
+	for _, sample := range s.metrics.samples {
+		if sample.TimeMs > 0 {
+			speeds = append(speeds, sample.WPM)
+		}
+	}
+	if len(speeds) == 0 {
+		return 0
+	}
+
+	sort.Float64s(speeds)
+	mid := len(speeds) / 2
+	if len(speeds)%2 == 0 {
+		return (speeds[mid-1] + speeds[mid]) / 2
 	}
+	return speeds[mid]
 }
 
 // GetElapsed returns time elapsed since session start
 func (s *Session) GetElapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.elapsed()
+}
+
+// elapsed returns time elapsed since session start, excluding paused and
+// AFK time. The caller must hold s.mu.
+func (s *Session) elapsed() time.Duration {
 	if s.state.StartedAt.IsZero() {
 		return 0
 	}
+
+	end := time.Now()
 	if !s.state.EndedAt.IsZero() {
-		return s.state.EndedAt.Sub(s.state.StartedAt)
+		end = s.state.EndedAt
+	}
+	// The clock is frozen while paused
+	if !s.pausedAt.IsZero() && s.pausedAt.Before(end) {
+		end = s.pausedAt
 	}
-	return time.Since(s.state.StartedAt)
+	// ... and once the keys have stopped for afkTimeout
+	if s.afkTimeout > 0 && !s.lastKeyAt.IsZero() {
+		if idleFrom := s.lastKeyAt.Add(s.afkTimeout); idleFrom.Before(end) {
+			end = idleFrom
+		}
+	}
+
+	return end.Sub(s.state.StartedAt) - s.pausedTotal - s.idleTotal
 }
 
 // GetLiveWPM returns the current WPM (net)
 func (s *Session) GetLiveWPM() float64 {
-	elapsed := s.GetElapsed()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := s.elapsed()
 	if elapsed < time.Second {
 		return 0
 	}
@@ -293,13 +1175,45 @@ func (s *Session) GetLiveWPM() float64 {
 	return (float64(s.metrics.correctChars) / 5.0) / minutes
 }
 
-// KeyType constants for the session (matching input package)
+// GetLiveRawWPM returns the current raw WPM, computed like the final result
+func (s *Session) GetLiveRawWPM() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := s.elapsed()
+	if elapsed < time.Second {
+		return 0
+	}
+	minutes := elapsed.Minutes()
+	return (float64(s.metrics.totalTyped) / 5.0) / minutes
+}
+
+// GetLiveAccuracy returns the current accuracy percentage, computed like the
+// final result; 0 before anything is typed
+func (s *Session) GetLiveAccuracy() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metrics.totalTyped == 0 {
+		return 0
+	}
+	return float64(s.metrics.correctChars) / float64(s.metrics.totalTyped) * 100
+}
+
+// KeyType constants for the session. HandleKey tells the edit-mode keys
+// apart by range, so KeyTypeDelete through KeyTypeEnd must stay contiguous
+// with the caret moves last.
 const (
 	KeyTypeRune = iota
 	KeyTypeBackspace
+	KeyTypeDeleteWord
+	KeyTypeDelete // forward delete and caret movement, in edit mode only
+	KeyTypeLeft
+	KeyTypeRight
+	KeyTypeHome
+	KeyTypeEnd
 	KeyTypeEnter
 	KeyTypeEscape
 	KeyTypeCtrlC
 	KeyTypeUnknown
 )
-
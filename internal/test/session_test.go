@@ -0,0 +1,202 @@
+package test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newWordsSession returns a words-mode session for text
+func newWordsSession(text string) *Session {
+	return NewSession(SessionOptions{Target: &Target{Text: text, Mode: ModeWords}})
+}
+
+// typeKeys feeds keys to s, with '\b' standing for backspace
+func typeKeys(s *Session, keys string) {
+	for _, r := range keys {
+		if r == '\b' {
+			s.HandleKey(KeyTypeBackspace, 0)
+		} else {
+			s.HandleKey(KeyTypeRune, r)
+		}
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestFirstTryAccuracy(t *testing.T) {
+	tests := []struct {
+		name         string
+		keys         string
+		accuracy     float64
+		firstTry     float64
+		typedCorrect int
+	}{
+		// Typo fixed: the wrong key counts against both, the fixed
+		// position only against first-try
+		{"typo", "tx\bhe cat", 87.5, 6.0 / 7 * 100, 7},
+		// Correct letter erased and retyped: it still costs a keystroke,
+		// and first-try counts the position as retried
+		{"retyped", "the\be cat", 87.5, 6.0 / 7 * 100, 7},
+		{"typos and backspaces", "tx\bhe co\bat", 7.0 / 9 * 100, 5.0 / 7 * 100, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newWordsSession("the cat")
+			typeKeys(s, tt.keys)
+			if !s.IsFinished() {
+				t.Fatal("session not finished after typing the whole text")
+			}
+
+			result := s.GetResult()
+			if result.CorrectChars != tt.typedCorrect {
+				t.Errorf("CorrectChars = %d, want %d", result.CorrectChars, tt.typedCorrect)
+			}
+			if !approxEqual(result.Accuracy, tt.accuracy) {
+				t.Errorf("Accuracy = %.2f, want %.2f", result.Accuracy, tt.accuracy)
+			}
+			if !approxEqual(result.FirstTryAccuracy, tt.firstTry) {
+				t.Errorf("FirstTryAccuracy = %.2f, want %.2f", result.FirstTryAccuracy, tt.firstTry)
+			}
+			if result.FirstTryAccuracy >= result.Accuracy {
+				t.Errorf("FirstTryAccuracy %.2f not below Accuracy %.2f", result.FirstTryAccuracy, result.Accuracy)
+			}
+		})
+	}
+}
+
+func TestFirstTryAccuracyClean(t *testing.T) {
+	s := newWordsSession("the cat")
+	typeKeys(s, "the cat")
+
+	result := s.GetResult()
+	if result.Accuracy != 100 || result.FirstTryAccuracy != 100 {
+		t.Errorf("Accuracy = %.2f, FirstTryAccuracy = %.2f, want both 100", result.Accuracy, result.FirstTryAccuracy)
+	}
+}
+
+// finishAt ends s with the given interval samples, as if it started at
+// start and its last key landed end after that
+func finishAt(s *Session, start time.Time, end time.Duration, samples []Sample) {
+	s.state.StartedAt = start
+	s.state.EndedAt = start.Add(end)
+	s.state.Finished = true
+	s.metrics.samples = samples
+}
+
+func TestGetResultFinalSample(t *testing.T) {
+	// Interval samples every 500ms of a steady 60 WPM, five characters a
+	// second, with the zero sample from the start
+	interval := []Sample{
+		{TimeMs: 0},
+		{TimeMs: 500, WPM: 60, RawWPM: 60},
+		{TimeMs: 1000, WPM: 60, RawWPM: 60},
+		{TimeMs: 1500, WPM: 60, RawWPM: 60},
+		{TimeMs: 2000, WPM: 60, RawWPM: 60},
+	}
+
+	tests := []struct {
+		name    string
+		endMs   int64
+		typed   string
+		count   int
+		wantWPM float64
+	}{
+		// 100ms after the 2000ms sample, within half the interval: the
+		// final sample replaces it. 21 characters in 2.1s is 120 WPM.
+		{"replaces close sample", 2100, "the cat sat on a mat.", 5, 120},
+		// 300ms after: the final sample is added. 23 characters in 2.3s
+		{"appends distant sample", 2300, "the cat sat on the mat.", 6, 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSession(SessionOptions{
+				Target:         &Target{Text: tt.typed, Mode: ModeWords},
+				SampleInterval: 500 * time.Millisecond,
+			})
+			typeKeys(s, tt.typed)
+			finishAt(s, time.Now(), time.Duration(tt.endMs)*time.Millisecond, slices.Clone(interval))
+
+			result := s.GetResult()
+			if len(result.Samples) != tt.count {
+				t.Fatalf("got %d samples, want %d", len(result.Samples), tt.count)
+			}
+			for i, sample := range result.Samples[:tt.count-1] {
+				if sample != interval[i] {
+					t.Errorf("sample %d = %+v, want %+v", i, sample, interval[i])
+				}
+			}
+			last := result.Samples[tt.count-1]
+			if last.TimeMs != tt.endMs {
+				t.Errorf("final sample at %dms, want %dms", last.TimeMs, tt.endMs)
+			}
+			if !approxEqual(last.WPM, tt.wantWPM) || !approxEqual(last.RawWPM, tt.wantWPM) {
+				t.Errorf("final sample WPM = %.2f, raw %.2f, want %.2f", last.WPM, last.RawWPM, tt.wantWPM)
+			}
+			if last.Errors != 0 {
+				t.Errorf("final sample errors = %d, want 0", last.Errors)
+			}
+		})
+	}
+}
+
+func TestPeakAndMedianWPM(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []Sample
+		peak    float64
+		median  float64
+	}{
+		{
+			// The 300 WPM spike inside the first second is no peak, but
+			// counts towards the median; the zero sample counts for neither
+			name: "odd count",
+			samples: []Sample{
+				{TimeMs: 0}, {TimeMs: 500, WPM: 300}, {TimeMs: 1000, WPM: 80},
+				{TimeMs: 1500, WPM: 60}, {TimeMs: 2000, WPM: 100}, {TimeMs: 2500, WPM: 70},
+			},
+			peak:   100,
+			median: 80,
+		},
+		{
+			name: "even count",
+			samples: []Sample{
+				{TimeMs: 0}, {TimeMs: 500, WPM: 300}, {TimeMs: 1000, WPM: 80},
+				{TimeMs: 1500, WPM: 60}, {TimeMs: 2000, WPM: 100},
+			},
+			peak:   100,
+			median: 90,
+		},
+		{
+			name:    "peak skips the first second",
+			samples: []Sample{{TimeMs: 0}, {TimeMs: 500, WPM: 50}, {TimeMs: 999, WPM: 55}},
+			peak:    0,
+			median:  52.5,
+		},
+		{
+			name:    "only the zero sample",
+			samples: []Sample{{TimeMs: 0}},
+			peak:    0,
+			median:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newWordsSession("the cat")
+			s.metrics.samples = tt.samples
+
+			if got := s.peakWPM(); got != tt.peak {
+				t.Errorf("peakWPM = %.2f, want %.2f", got, tt.peak)
+			}
+			if got := s.medianWPM(); got != tt.median {
+				t.Errorf("medianWPM = %.2f, want %.2f", got, tt.median)
+			}
+		})
+	}
+}
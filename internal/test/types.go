@@ -1,14 +1,30 @@
 package test
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 // Mode represents the type of typing test
 type Mode string
 
 const (
-	ModeTimer Mode = "timer"
-	ModeWords Mode = "words"
-	ModeQuote Mode = "quote"
+	ModeTimer    Mode = "timer"
+	ModeWords    Mode = "words"
+	ModeQuote    Mode = "quote"
+	ModeText     Mode = "text"
+	ModePractice Mode = "practice"
+	ModeCode     Mode = "code"
+	ModeZen      Mode = "zen"
+)
+
+// Correction controls whether and how mistakes can be corrected
+type Correction string
+
+const (
+	CorrectionNormal      Correction = ""              // backspace allowed
+	CorrectionNoBackspace Correction = "no-backspace"  // backspace ignored, mistakes stay
+	CorrectionStopOnError Correction = "stop-on-error" // wrong keys rejected until corrected
 )
 
 // CharState represents the state of a character in the target text
@@ -25,14 +41,21 @@ type Target struct {
 	Text     string
 	Mode     Mode
 	Metadata TargetMetadata
+
+	// Extend returns more text to append as the caret nears the end, for a
+	// target with no end of its own (zen mode); nil for a fixed text
+	Extend func() string
 }
 
 // TargetMetadata holds mode-specific metadata
 type TargetMetadata struct {
-	WordCount int    // for words mode
-	Seconds   int    // for timer mode
-	QuoteID   string // for quote mode
-	Source    string // quote source/author
+	WordCount    int    // for words mode
+	Seconds      int    // for timer mode
+	QuoteID      string // for quote mode
+	Source       string // quote source/author, text file name, or practiced keys
+	Seed         int64  // generator seed, 0 if the text was not generated
+	Difficulty   string // difficulty preset the words were generated with, if any
+	MistakeDrill bool   // the words are the ones missed in the previous test
 }
 
 // SessionState represents the current state of a typing session
@@ -41,25 +64,79 @@ type SessionState struct {
 	TargetRunes []rune
 	TypedRunes  []rune
 	CharStates  []CharState
-	StartedAt   time.Time
+	Caret       int       // where the next key lands: len(TypedRunes) unless moved back in edit mode
+	ArmedAt     time.Time // when input was first accepted; zero if never armed
+	StartedAt   time.Time // first keystroke
 	EndedAt     time.Time
 	Finished    bool
 	Aborted     bool
+	EndReason   EndReason
 }
 
+// EndReason records which finish condition ended a session
+type EndReason string
+
+const (
+	EndCompleted EndReason = "completed" // the whole text was typed
+	EndTime      EndReason = "time"      // the timer or time cap ran out
+	EndWords     EndReason = "words"     // the word cap was reached
+	EndStopped   EndReason = "stopped"   // ended by hand (zen mode)
+)
+
 // SessionResult holds the final results of a typing session
 type SessionResult struct {
-	Mode         Mode
-	StartedAt    time.Time
-	Duration     time.Duration
-	TargetLen    int
-	TotalTyped   int
-	CorrectChars int
-	WPM          float64
-	RawWPM       float64
-	Accuracy     float64
-	Samples      []Sample
-	Metadata     TargetMetadata
+	Mode             Mode
+	StartedAt        time.Time
+	Duration         time.Duration
+	TargetLen        int
+	TotalTyped       int
+	CorrectChars     int
+	IncorrectChars   int
+	Corrections      int   // backspace presses that removed a character
+	TimeToFirstKeyMs int64 // from Arm to the first keystroke; 0 if unknown
+	WPM              float64
+	RawWPM           float64
+	CPM              float64 // correct characters per minute
+	RawCPM           float64 // typed characters per minute
+	Accuracy         float64
+	FirstTryAccuracy float64 // share of attempted characters right the first time and never erased
+	Consistency      float64 // 0-100, steadiness of typing speed
+	PeakWPM          float64 // highest sampled WPM after the first second
+	MedianWPM        float64 // median sampled WPM
+	AvgKeyLatencyMs  float64 // mean time between keystrokes; 0 with fewer than two
+	P95KeyLatencyMs  float64 // 95th percentile time between keystrokes
+	Correction       Correction
+	TargetText       string
+	TypedText        string  // what was typed, one rune per target position
+	TargetWPM        float64 // WPM goal; 0 when none was set
+	Blind            bool    // correctness was hidden while typing
+	Record           Record  // personal best set by this run, if any
+	GoalTests        int     // tests a day the daily goal asks for; 0 for none
+	GoalDone         int     // tests towards the daily goal today, this one included
+	EndReason        EndReason
+	MaxWords         int           // word cap in timer mode; 0 for none
+	MaxSeconds       int           // time cap in other modes; 0 for none
+	IdleTime         time.Duration // AFK time left out of Duration
+	Samples          []Sample
+	KeyTimesMs       []int64          // when each keystroke landed, in active ms since the start; capped by SessionOptions.MaxKeyTimes
+	KeyStats         map[rune]KeyStat // keyed by target character
+	MissedWords      []string         // target words left with a mistake, each once
+	Metadata         TargetMetadata
+}
+
+// Record says which personal best a result beat
+type Record string
+
+const (
+	RecordNone    Record = ""        // no new best
+	RecordMode    Record = "mode"    // best WPM for this mode only
+	RecordOverall Record = "overall" // best WPM across all modes
+)
+
+// KeyStat counts keystrokes made against one target character
+type KeyStat struct {
+	Attempts int
+	Errors   int
 }
 
 // Sample represents a point-in-time speed measurement
@@ -67,5 +144,16 @@ type Sample struct {
 	TimeMs int64   // milliseconds since start
 	WPM    float64 // net WPM at this point
 	RawWPM float64 // raw WPM at this point
+	Errors int     // incorrect keystrokes so far
 }
 
+// ErrorRate returns the percentage of keystrokes so far that were wrong.
+// Samples hold running averages, so the keystroke count is recovered from
+// the raw WPM.
+func (s Sample) ErrorRate() float64 {
+	typed := s.RawWPM * 5 * float64(s.TimeMs) / 60000
+	if typed < 1 {
+		return 0
+	}
+	return math.Min(100, float64(s.Errors)/typed*100)
+}
@@ -2,6 +2,9 @@ package text
 
 import (
 	"fmt"
+	"io"
+	"strings"
+	"unicode"
 
 	"github.com/mmdbasi/mtcli/internal/test"
 )
@@ -10,21 +13,49 @@ import (
 type DefaultGenerator struct {
 	wordList  *WordList
 	quoteList *QuoteList
+	textOpts  TextOptions
+
+	// draw picks the words for words and timer modes; nil draws them
+	// independently from the word list
+	draw func(n int) []string
 }
 
 // GeneratorOptions holds configuration for the generator
 type GeneratorOptions struct {
+	Kind       string // registered generator name; empty for DefaultKind
 	WordsFile  string
+	Language   string // embedded word list, ignored when WordsFile is set
 	QuotesFile string
 	Seed       int64
+
+	// AllowRepeats lets the same word appear twice in a row
+	AllowRepeats bool
+
+	// Word length bounds in characters, 0 for no bound
+	MinWordLen int
+	MaxWordLen int
+
+	// Word post-processing (words and timer modes)
+	Punctuation    bool
+	Numbers        bool
+	NumbersDensity float64
 }
 
-// NewGenerator creates a new text generator
-func NewGenerator(opts GeneratorOptions) (*DefaultGenerator, error) {
-	wordList, err := NewWordList(opts.WordsFile, opts.Seed)
+// NewDefaultGenerator creates the default text generator, which draws
+// words independently from the word list
+func NewDefaultGenerator(opts GeneratorOptions) (*DefaultGenerator, error) {
+	// Both lists draw from the same seed, so the one seed reproduces
+	// whichever mode the generator is used for
+	opts.Seed = ResolveSeed(opts.Seed)
+
+	wordList, err := NewWordList(opts.WordsFile, opts.Language, opts.Seed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load words: %w", err)
 	}
+	if err := wordList.FilterLength(opts.MinWordLen, opts.MaxWordLen); err != nil {
+		return nil, err
+	}
+	wordList.SetAllowRepeats(opts.AllowRepeats)
 
 	quoteList, err := NewQuoteList(opts.QuotesFile, opts.Seed)
 	if err != nil {
@@ -34,16 +65,26 @@ func NewGenerator(opts GeneratorOptions) (*DefaultGenerator, error) {
 	return &DefaultGenerator{
 		wordList:  wordList,
 		quoteList: quoteList,
+		textOpts: TextOptions{
+			Punctuation:    opts.Punctuation,
+			Numbers:        opts.Numbers,
+			NumbersDensity: opts.NumbersDensity,
+		},
 	}, nil
 }
 
+// Seed returns the seed the generator draws words and quotes with
+func (g *DefaultGenerator) Seed() int64 {
+	return g.wordList.Seed()
+}
+
 // GenerateWords generates a target with the specified number of words
 func (g *DefaultGenerator) GenerateWords(count int) (*test.Target, error) {
 	if count <= 0 {
 		return nil, fmt.Errorf("word count must be positive")
 	}
 
-	text := g.wordList.GenerateText(count)
+	text := g.generateText(count)
 
 	return &test.Target{
 		Text: text,
@@ -54,6 +95,59 @@ func (g *DefaultGenerator) GenerateWords(count int) (*test.Target, error) {
 	}, nil
 }
 
+// generateText draws count words and applies the text options
+func (g *DefaultGenerator) generateText(count int) string {
+	if g.draw == nil {
+		return g.wordList.GenerateTextWithOptions(count, g.textOpts)
+	}
+	return g.wordList.decorate(g.draw(count), g.textOpts)
+}
+
+// GeneratePractice generates count words that each contain at least one of
+// chars, for drilling weak keys
+func (g *DefaultGenerator) GeneratePractice(chars []rune, count int) (*test.Target, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("word count must be positive")
+	}
+	if len(chars) == 0 {
+		return nil, fmt.Errorf("no characters to practice")
+	}
+
+	text, ok := g.wordList.GenerateTextContaining(chars, count)
+	if !ok {
+		return nil, fmt.Errorf("no words contain the practice characters")
+	}
+
+	return &test.Target{
+		Text: text,
+		Mode: test.ModePractice,
+		Metadata: test.TargetMetadata{
+			WordCount: count,
+			Source:    "keys: " + string(chars),
+		},
+	}, nil
+}
+
+// GenerateFromWords generates a practice target of count words drawn from
+// words, e.g. to drill the words missed in a previous test
+func (g *DefaultGenerator) GenerateFromWords(words []string, count int) (*test.Target, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("word count must be positive")
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no words to practice")
+	}
+
+	return &test.Target{
+		Text: strings.Join(g.wordList.drawFrom(words, count), " "),
+		Mode: test.ModePractice,
+		Metadata: test.TargetMetadata{
+			WordCount: count,
+			Source:    "missed words",
+		},
+	}, nil
+}
+
 // GenerateForTimer generates enough words for a timed test
 // Assumes average typing speed of ~200 WPM (very fast) to ensure enough words
 func (g *DefaultGenerator) GenerateForTimer(seconds int) (*test.Target, error) {
@@ -68,7 +162,7 @@ func (g *DefaultGenerator) GenerateForTimer(seconds int) (*test.Target, error) {
 		wordCount = 50
 	}
 
-	text := g.wordList.GenerateText(wordCount)
+	text := g.generateText(wordCount)
 
 	return &test.Target{
 		Text: text,
@@ -97,6 +191,24 @@ func (g *DefaultGenerator) GetRandomQuote() (*test.Target, error) {
 	}, nil
 }
 
+// GetRandomQuoteBySource returns a random quote from a matching source as a
+// target; see QuoteList.GetRandomQuoteBySource
+func (g *DefaultGenerator) GetRandomQuoteBySource(source string) (*test.Target, error) {
+	quote := g.quoteList.GetRandomQuoteBySource(source)
+	if quote == nil {
+		return nil, fmt.Errorf("no quotes with a source matching %q (see 'mtcli quotes list')", source)
+	}
+
+	return &test.Target{
+		Text: quote.Text,
+		Mode: test.ModeQuote,
+		Metadata: test.TargetMetadata{
+			QuoteID: quote.ID,
+			Source:  quote.Source,
+		},
+	}, nil
+}
+
 // GetQuoteByID returns a specific quote as a target
 func (g *DefaultGenerator) GetQuoteByID(id string) (*test.Target, error) {
 	quote, err := g.quoteList.GetQuoteByID(id)
@@ -114,3 +226,69 @@ func (g *DefaultGenerator) GetQuoteByID(id string) (*test.Target, error) {
 	}, nil
 }
 
+// GenerateFromReader reads arbitrary text to type. The text is kept as-is
+// apart from whitespace: runs of spaces and tabs become a single space and,
+// unless keepNewlines is set, line breaks are collapsed too. With
+// keepNewlines, each non-empty line is kept on its own line.
+func (g *DefaultGenerator) GenerateFromReader(r io.Reader, source string, keepNewlines bool) (*test.Target, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text: %w", err)
+	}
+	return g.GenerateFromText(string(data), source, keepNewlines)
+}
+
+// GenerateCode reads source code to type verbatim: indentation, tabs, and
+// line breaks are all part of the target. Only CRLF line endings are
+// normalized and trailing whitespace at the end of the file is dropped, so
+// the test doesn't end on a stray Enter.
+func (g *DefaultGenerator) GenerateCode(r io.Reader, source string) (*test.Target, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code: %w", err)
+	}
+
+	code := strings.ReplaceAll(string(data), "\r\n", "\n")
+	code = strings.TrimRightFunc(code, unicode.IsSpace)
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("code is empty")
+	}
+
+	return &test.Target{
+		Text: code,
+		Mode: test.ModeCode,
+		Metadata: test.TargetMetadata{
+			WordCount: len(strings.Fields(code)),
+			Source:    source,
+		},
+	}, nil
+}
+
+// GenerateFromText builds a text-mode target from the given string
+func (g *DefaultGenerator) GenerateFromText(s, source string, keepNewlines bool) (*test.Target, error) {
+	var text string
+	if keepNewlines {
+		var lines []string
+		for _, line := range strings.Split(s, "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				lines = append(lines, strings.Join(fields, " "))
+			}
+		}
+		text = strings.Join(lines, "\n")
+	} else {
+		text = strings.Join(strings.Fields(s), " ")
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("text is empty")
+	}
+
+	return &test.Target{
+		Text: text,
+		Mode: test.ModeText,
+		Metadata: test.TargetMetadata{
+			WordCount: len(strings.Fields(text)),
+			Source:    source,
+		},
+	}, nil
+}
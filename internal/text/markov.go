@@ -0,0 +1,102 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Bounds on the bigram model, so a large quotes file can't grow it without
+// limit
+const (
+	maxMarkovWords      = 5000 // distinct words with recorded successors
+	maxMarkovSuccessors = 64   // successors recorded per word
+)
+
+func init() {
+	RegisterGenerator("markov", NewMarkovGenerator)
+}
+
+// NewMarkovGenerator creates a generator whose words follow a bigram model
+// of the quotes, so word pairs that occur together in real text come up
+// together in tests. Only words in the word list are modelled, which keeps
+// the language and length filters in effect; a word with no known
+// successor is followed by a random word from the list. Quote and text
+// modes are unaffected.
+func NewMarkovGenerator(opts GeneratorOptions) (Generator, error) {
+	g, err := NewDefaultGenerator(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	model := newBigramModel(g.quoteList.quotes, g.wordList.words)
+	g.draw = func(n int) []string {
+		return model.generate(g.wordList, n)
+	}
+	return g, nil
+}
+
+// bigramModel maps each word to the words seen after it. Successors are
+// kept with repeats, so drawing uniformly from them follows the observed
+// frequencies.
+type bigramModel struct {
+	next map[string][]string
+}
+
+// newBigramModel records the transitions between consecutive words of the
+// quotes that are both in vocabulary. Words are compared in lower case
+// without surrounding punctuation.
+func newBigramModel(quotes []Quote, vocabulary []string) *bigramModel {
+	known := make(map[string]bool, len(vocabulary))
+	for _, word := range vocabulary {
+		known[word] = true
+	}
+
+	m := &bigramModel{next: make(map[string][]string)}
+	for _, quote := range quotes {
+		var prev string
+		for _, field := range strings.Fields(quote.Text) {
+			word := strings.ToLower(strings.TrimFunc(field, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+			}))
+			if !known[word] {
+				prev = ""
+				continue
+			}
+			if prev != "" {
+				m.add(prev, word)
+			}
+			prev = word
+		}
+	}
+	return m
+}
+
+// add records that next followed word, unless the model is full
+func (m *bigramModel) add(word, next string) {
+	successors, ok := m.next[word]
+	if !ok && len(m.next) >= maxMarkovWords {
+		return
+	}
+	if len(successors) < maxMarkovSuccessors {
+		m.next[word] = append(successors, next)
+	}
+}
+
+// generate returns n words, each drawn from the successors of the one
+// before it, or from the whole word list at the start and at dead ends.
+// All randomness comes from the word list's rng.
+func (m *bigramModel) generate(wl *WordList, n int) []string {
+	result := make([]string, n)
+	for i := range result {
+		var prev string
+		pool := wl.words
+		if i > 0 {
+			prev = result[i-1]
+			if successors := m.next[prev]; len(successors) > 0 {
+				pool = successors
+			}
+		}
+		result[i] = wl.drawNext(pool, prev)
+	}
+	return result
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/mmdbasi/mtcli/internal/assets"
 )
@@ -30,17 +33,9 @@ func NewQuoteList(customFile string, seed int64) (*QuoteList, error) {
 		return nil, err
 	}
 
-	// Use provided seed or current time
-	var rng *rand.Rand
-	if seed != 0 {
-		rng = rand.New(rand.NewSource(seed))
-	} else {
-		rng = rand.New(rand.NewSource(rand.Int63()))
-	}
-
 	return &QuoteList{
 		quotes: quotes,
-		rng:    rng,
+		rng:    rand.New(rand.NewSource(ResolveSeed(seed))),
 	}, nil
 }
 
@@ -63,6 +58,74 @@ func loadQuotesFromFile(path string) ([]Quote, error) {
 	return quotes, err
 }
 
+// AddQuote appends a quote to the custom quotes file at path, creating the
+// file if needed, and returns the stored quote with its new ID. The file is
+// replaced atomically so a failed write never leaves it half-written.
+func AddQuote(path, text, source string) (*Quote, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("quote text must not be empty")
+	}
+
+	quotes, err := loadQuotesFromFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	quote := Quote{ID: nextQuoteID(quotes), Text: text, Source: strings.TrimSpace(source)}
+	quotes = append(quotes, quote)
+
+	data, err := json.MarshalIndent(quotes, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".quotes-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// nextQuoteID returns one more than the largest numeric ID in quotes,
+// skipping any non-numeric ID that happens to collide
+func nextQuoteID(quotes []Quote) string {
+	taken := make(map[string]bool, len(quotes))
+	maxID := 0
+	for _, q := range quotes {
+		taken[q.ID] = true
+		if n, err := strconv.Atoi(q.ID); err == nil && n > maxID {
+			maxID = n
+		}
+	}
+
+	id := maxID + 1
+	for taken[strconv.Itoa(id)] {
+		id++
+	}
+	return strconv.Itoa(id)
+}
+
 // GetRandomQuote returns a random quote
 func (ql *QuoteList) GetRandomQuote() *Quote {
 	if len(ql.quotes) == 0 {
@@ -72,6 +135,48 @@ func (ql *QuoteList) GetRandomQuote() *Quote {
 	return &ql.quotes[idx]
 }
 
+// GetRandomQuoteBySource returns a random quote whose source contains
+// substring, ignoring case, or nil if none match
+func (ql *QuoteList) GetRandomQuoteBySource(substring string) *Quote {
+	substring = strings.ToLower(substring)
+
+	var matches []int
+	for i, q := range ql.quotes {
+		if strings.Contains(strings.ToLower(q.Source), substring) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	idx := matches[ql.rng.Intn(len(matches))]
+	return &ql.quotes[idx]
+}
+
+// Search returns the quotes whose text or source contains term, ignoring
+// case, in file order. A term of several words matches them as a phrase;
+// runs of whitespace in the term and the quotes compare equal, so a phrase
+// still matches across a line break.
+func (ql *QuoteList) Search(term string) []Quote {
+	term = normalizeSearch(term)
+	if term == "" {
+		return nil
+	}
+
+	var matches []Quote
+	for _, q := range ql.quotes {
+		if strings.Contains(normalizeSearch(q.Text), term) || strings.Contains(normalizeSearch(q.Source), term) {
+			matches = append(matches, q)
+		}
+	}
+	return matches
+}
+
+// normalizeSearch lower-cases s and collapses its whitespace for Search
+func normalizeSearch(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
 // GetQuoteByID returns a quote by its ID
 func (ql *QuoteList) GetQuoteByID(id string) (*Quote, error) {
 	for _, q := range ql.quotes {
@@ -87,6 +192,11 @@ func (ql *QuoteList) Count() int {
 	return len(ql.quotes)
 }
 
+// All returns all quotes in file order
+func (ql *QuoteList) All() []Quote {
+	return ql.quotes
+}
+
 // ListIDs returns all available quote IDs
 func (ql *QuoteList) ListIDs() []string {
 	ids := make([]string, len(ql.quotes))
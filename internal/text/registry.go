@@ -0,0 +1,62 @@
+package text
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultKind is the generator used when GeneratorOptions.Kind is empty
+const DefaultKind = "default"
+
+// GeneratorFactory builds a generator from the options. Factories that only
+// change how words are picked can wrap NewDefaultGenerator and override its
+// word methods.
+type GeneratorFactory func(opts GeneratorOptions) (Generator, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]GeneratorFactory{
+		DefaultKind: func(opts GeneratorOptions) (Generator, error) {
+			return NewDefaultGenerator(opts)
+		},
+	}
+)
+
+// RegisterGenerator makes a generator available to NewGenerator under
+// name, replacing any registered before under the same name
+func RegisterGenerator(name string, factory GeneratorFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Generators returns the names of the registered generators, sorted
+func Generators() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewGenerator creates the generator registered under opts.Kind, or the
+// default generator if Kind is empty
+func NewGenerator(opts GeneratorOptions) (Generator, error) {
+	kind := opts.Kind
+	if kind == "" {
+		kind = DefaultKind
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[kind]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown generator: %s (use %s)", kind, strings.Join(Generators(), ", "))
+	}
+	return factory(opts)
+}
@@ -1,6 +1,10 @@
 package text
 
-import "github.com/mmdbasi/mtcli/internal/test"
+import (
+	"io"
+
+	"github.com/mmdbasi/mtcli/internal/test"
+)
 
 // Generator defines the interface for text generation
 type Generator interface {
@@ -15,6 +19,25 @@ type Generator interface {
 
 	// GetQuoteByID returns a specific quote
 	GetQuoteByID(id string) (*test.Target, error)
+
+	// GetRandomQuoteBySource returns a random quote from a matching source
+	GetRandomQuoteBySource(source string) (*test.Target, error)
+
+	// GeneratePractice generates words containing the given characters
+	GeneratePractice(chars []rune, count int) (*test.Target, error)
+
+	// GenerateFromWords generates a sequence drawn from the given words
+	GenerateFromWords(words []string, count int) (*test.Target, error)
+
+	// GenerateFromReader reads arbitrary text to type
+	GenerateFromReader(r io.Reader, source string, keepNewlines bool) (*test.Target, error)
+
+	// GenerateCode reads source code to type verbatim
+	GenerateCode(r io.Reader, source string) (*test.Target, error)
+
+	// Seed returns the seed the generator draws from, picked at random
+	// when none was given
+	Seed() int64
 }
 
 // Quote represents a quote with metadata
@@ -2,52 +2,95 @@ package text
 
 import (
 	"bufio"
+	"fmt"
 	"math/rand"
 	"os"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mmdbasi/mtcli/internal/assets"
 )
 
+// Default densities used when the corresponding TextOptions field is unset
+const (
+	defaultPunctuationDensity = 0.3
+	defaultNumbersDensity     = 0.15
+)
+
+// TextOptions controls the post-processing applied to generated words
+type TextOptions struct {
+	Punctuation        bool
+	PunctuationDensity float64 // 0-1, probability a word gets punctuation
+	Numbers            bool
+	NumbersDensity     float64 // 0-1, probability a word is replaced by a number
+}
+
+// maxRerolls bounds how often a word equal to the previous one is redrawn,
+// so lists with a single distinct word still terminate
+const maxRerolls = 10
+
 // WordList holds a list of words for generating typing tests
 type WordList struct {
 	words []string
 	rng   *rand.Rand
+	seed  int64 // what rng was seeded with
+
+	// allowRepeats lets the same word be drawn twice in a row
+	allowRepeats bool
 }
 
-// NewWordList creates a new word list from the embedded words or a custom file
-func NewWordList(customFile string, seed int64) (*WordList, error) {
+// NewWordList creates a new word list from a custom file or, if none is
+// given, the embedded list for language (empty for the default)
+func NewWordList(customFile, language string, seed int64) (*WordList, error) {
 	var words []string
 	var err error
 
 	if customFile != "" {
 		words, err = loadWordsFromFile(customFile)
 	} else {
-		words, err = loadEmbeddedWords()
+		words, err = loadEmbeddedWords(language)
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Use provided seed or current time
-	var rng *rand.Rand
-	if seed != 0 {
-		rng = rand.New(rand.NewSource(seed))
-	} else {
-		rng = rand.New(rand.NewSource(rand.Int63()))
-	}
-
+	seed = ResolveSeed(seed)
 	return &WordList{
 		words: words,
-		rng:   rng,
+		rng:   rand.New(rand.NewSource(seed)),
+		seed:  seed,
 	}, nil
 }
 
-// loadEmbeddedWords loads words from the embedded words.txt
-func loadEmbeddedWords() ([]string, error) {
+// ResolveSeed returns seed, or a random one if seed is 0. The result is
+// never 0, so it can always be shown and passed back as --seed.
+func ResolveSeed(seed int64) int64 {
+	for seed == 0 {
+		seed = rand.Int63()
+	}
+	return seed
+}
+
+// Seed returns the seed the list draws words with
+func (wl *WordList) Seed() int64 {
+	return wl.seed
+}
+
+// loadEmbeddedWords loads the embedded word list for a language
+func loadEmbeddedWords(language string) ([]string, error) {
+	if language == "" {
+		language = assets.DefaultLanguage
+	}
+	data, ok := assets.WordList(language)
+	if !ok {
+		return nil, fmt.Errorf("unknown language: %s (use %s)", language, strings.Join(assets.Languages(), ", "))
+	}
+
 	var words []string
-	scanner := bufio.NewScanner(strings.NewReader(assets.WordsData))
+	scanner := bufio.NewScanner(strings.NewReader(data))
 	for scanner.Scan() {
 		word := strings.TrimSpace(scanner.Text())
 		if word != "" {
@@ -76,28 +119,203 @@ func loadWordsFromFile(path string) ([]string, error) {
 	return words, scanner.Err()
 }
 
+// SetAllowRepeats controls whether the same word may be drawn twice in a
+// row. By default a repeat is redrawn.
+func (wl *WordList) SetAllowRepeats(allow bool) {
+	wl.allowRepeats = allow
+}
+
+// FilterLength keeps only words whose length in characters is within
+// [minLen, maxLen]. A bound of 0 leaves that end open. It returns an error,
+// leaving the list unchanged, if no word is left.
+func (wl *WordList) FilterLength(minLen, maxLen int) error {
+	if minLen < 0 || maxLen < 0 {
+		return fmt.Errorf("word length bounds must not be negative")
+	}
+	if maxLen > 0 && minLen > maxLen {
+		return fmt.Errorf("minimum word length %d is greater than maximum %d", minLen, maxLen)
+	}
+	if minLen == 0 && maxLen == 0 {
+		return nil
+	}
+
+	var kept []string
+	for _, word := range wl.words {
+		n := utf8.RuneCountInString(word)
+		if n >= minLen && (maxLen == 0 || n <= maxLen) {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		return fmt.Errorf("no words with length %s", describeLengthRange(minLen, maxLen))
+	}
+	wl.words = kept
+	return nil
+}
+
+// describeLengthRange formats a word length range for error messages
+func describeLengthRange(minLen, maxLen int) string {
+	switch {
+	case maxLen == 0:
+		return fmt.Sprintf("at least %d", minLen)
+	case minLen == 0:
+		return fmt.Sprintf("at most %d", maxLen)
+	case minLen == maxLen:
+		return fmt.Sprintf("exactly %d", minLen)
+	default:
+		return fmt.Sprintf("between %d and %d", minLen, maxLen)
+	}
+}
+
 // GetRandomWords returns n random words
 func (wl *WordList) GetRandomWords(n int) []string {
 	if n <= 0 {
 		return nil
 	}
+	return wl.drawFrom(wl.words, n)
+}
 
+// drawFrom returns n random words from pool, redrawing a word that equals
+// the one before it unless repeats are allowed
+func (wl *WordList) drawFrom(pool []string, n int) []string {
 	result := make([]string, n)
-	for i := 0; i < n; i++ {
-		idx := wl.rng.Intn(len(wl.words))
-		result[i] = wl.words[idx]
+	for i := range result {
+		var prev string
+		if i > 0 {
+			prev = result[i-1]
+		}
+		result[i] = wl.drawNext(pool, prev)
 	}
 	return result
 }
 
+// drawNext returns a random word from pool to follow prev, redrawing a
+// word equal to prev unless repeats are allowed. An empty prev is the start
+// of the text.
+func (wl *WordList) drawNext(pool []string, prev string) string {
+	word := pool[wl.rng.Intn(len(pool))]
+	if !wl.allowRepeats && prev != "" {
+		for tries := 0; word == prev && tries < maxRerolls; tries++ {
+			word = pool[wl.rng.Intn(len(pool))]
+		}
+	}
+	return word
+}
+
 // GenerateText generates a text string of n random words
 func (wl *WordList) GenerateText(n int) string {
 	words := wl.GetRandomWords(n)
 	return strings.Join(words, " ")
 }
 
+// GenerateTextContaining generates a text string of n random words drawn
+// only from words that contain at least one of chars. It reports false if
+// no word in the list contains any of them.
+func (wl *WordList) GenerateTextContaining(chars []rune, n int) (string, bool) {
+	var pool []string
+	for _, word := range wl.words {
+		if strings.ContainsAny(word, string(chars)) {
+			pool = append(pool, word)
+		}
+	}
+	if len(pool) == 0 || n <= 0 {
+		return "", false
+	}
+	return strings.Join(wl.drawFrom(pool, n), " "), true
+}
+
+// GenerateTextWithOptions generates a text string of n random words and
+// applies the post-processing passes enabled in opts. All randomness comes
+// from the word list's rng, so output is reproducible for a given seed.
+func (wl *WordList) GenerateTextWithOptions(n int, opts TextOptions) string {
+	return wl.decorate(wl.GetRandomWords(n), opts)
+}
+
+// decorate applies the post-processing passes enabled in opts to words and
+// joins them into a text
+func (wl *WordList) decorate(words []string, opts TextOptions) string {
+	if opts.Numbers {
+		wl.addNumbers(words, opts.NumbersDensity)
+	}
+	if opts.Punctuation {
+		wl.addPunctuation(words, opts.PunctuationDensity)
+	}
+	return strings.Join(words, " ")
+}
+
+// addNumbers replaces words in place with random integer tokens of one to
+// four digits. Replacing rather than inserting keeps the word count intact.
+func (wl *WordList) addNumbers(words []string, density float64) {
+	if density <= 0 {
+		density = defaultNumbersDensity
+	}
+
+	for i := range words {
+		if wl.rng.Float64() < density {
+			digits := wl.rng.Intn(4) + 1
+			limit := 1
+			for j := 0; j < digits; j++ {
+				limit *= 10
+			}
+			words[i] = strconv.Itoa(wl.rng.Intn(limit))
+		}
+	}
+}
+
+// addPunctuation decorates words in place with commas, periods, semicolons,
+// quotes and parentheses, capitalizing the first word of each sentence
+func (wl *WordList) addPunctuation(words []string, density float64) {
+	if density <= 0 {
+		density = defaultPunctuationDensity
+	}
+
+	sentenceStart := true
+	for i, word := range words {
+		if sentenceStart {
+			word = capitalize(word)
+		}
+
+		if wl.rng.Float64() < density {
+			switch roll := wl.rng.Intn(10); {
+			case roll < 4:
+				word += ","
+			case roll < 7:
+				word += "."
+			case roll < 8:
+				word += ";"
+			case roll < 9:
+				word = "\"" + word + "\""
+			default:
+				word = "(" + word + ")"
+			}
+		}
+
+		// Always close the final sentence
+		if i == len(words)-1 && !endsSentence(word) {
+			word += "."
+		}
+
+		sentenceStart = endsSentence(word)
+		words[i] = word
+	}
+}
+
+// endsSentence reports whether a word ends with sentence-ending punctuation
+func endsSentence(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+}
+
+// capitalize upper-cases the first letter of a word
+func capitalize(word string) string {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return word
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
 // Count returns the number of words in the list
 func (wl *WordList) Count() int {
 	return len(wl.words)
 }
-
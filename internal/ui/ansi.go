@@ -4,20 +4,30 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/mmdbasi/mtcli/internal/charts"
 	"golang.org/x/term"
 )
 
 // ANSI escape codes
 const (
-	escClearScreen   = "\033[2J"
-	escClearLine     = "\033[2K"
-	escMoveCursor    = "\033[%d;%dH" // row, col (1-indexed)
-	escMoveHome      = "\033[H"
-	escHideCursor    = "\033[?25l"
-	escShowCursor    = "\033[?25h"
-	escReset         = "\033[0m"
-	escBold          = "\033[1m"
-	escDim           = "\033[2m"
+	escClearScreen = "\033[2J"
+	escClearLine   = "\033[2K"
+	escMoveCursor  = "\033[%d;%dH" // row, col (1-indexed)
+	escMoveHome    = "\033[H"
+	escHideCursor  = "\033[?25l"
+	escShowCursor  = "\033[?25h"
+	escReset       = "\033[0m"
+	escBold        = "\033[1m"
+	escDim         = "\033[2m"
+	escUnderline   = "\033[4m"
+	escInverse     = "\033[7m"
+
+	// Bracketed paste makes the terminal wrap pastes in ESC[200~ and
+	// ESC[201~, so the input reader can tell them from typing
+	escPasteOn  = "\033[?2004h"
+	escPasteOff = "\033[?2004l"
+
+	bell = "\a"
 )
 
 // Color codes (256-color mode)
@@ -150,3 +160,14 @@ func YellowString(s string) string {
 	return colorYellow + s + escReset
 }
 
+// ChartColors enables chart colors using the summary palette: WPM in the
+// theme's success color, Raw WPM in its info color, the error rate in its
+// incorrect color, and dimmed axes
+func ChartColors(opts *charts.ChartOptions, theme Theme) {
+	opts.Color = true
+	opts.PrimaryColor = theme.Success
+	opts.SecondaryColor = theme.Info
+	opts.TertiaryColor = theme.Incorrect
+	opts.AxisColor = escDim
+	opts.ResetColor = escReset
+}
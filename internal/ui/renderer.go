@@ -2,42 +2,114 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mmdbasi/mtcli/internal/test"
+	"golang.org/x/term"
+	"golang.org/x/text/width"
 )
 
 // ANSIRenderer implements the Renderer interface using ANSI escape codes
 type ANSIRenderer struct {
-	width   int
-	height  int
-	noColor bool
-	mu      sync.Mutex
+	width     int
+	height    int
+	termWidth int
+	autoWidth bool // width follows the terminal on resize
+	noColor   bool
+	caret     CaretStyle
+	theme     Theme
+	showAcc   bool
+	showRaw   bool
+	showSpace bool      // draw every space as a visible glyph
+	unit      SpeedUnit // speed shown in the status line and summary
+	pace      float64   // band around the recent average, as a fraction; 0 disables pace colors
+	bell      BellMode
+	out       io.Writer
+	mu        sync.Mutex
+
+	// lastFrame holds the lines drawn by the previous Render so the next
+	// one only rewrites lines that changed; nil forces a full redraw
+	lastFrame []string
 }
 
 // RendererOptions holds configuration for the renderer
 type RendererOptions struct {
-	Width   int  // 0 means auto-detect
-	NoColor bool
+	Width          int // 0 means auto-detect
+	NoColor        bool
+	Caret          CaretStyle // defaults to CaretUnderline
+	Theme          Theme      // defaults to DefaultTheme
+	ShowAccuracy   bool       // live accuracy in the status line
+	ShowRaw        bool       // live raw WPM in the status line
+	ShowWhitespace bool       // draw spaces as glyphs, whatever their state
+	Unit           SpeedUnit  // defaults to UnitWPM
+	PaceMargin     float64    // percent around RenderState.PaceWPM that counts as on pace; 0 disables
+	Bell           BellMode   // when the summary rings; defaults to BellOff
+	Output         io.Writer  // where the UI is drawn; defaults to stdout
 }
 
 // NewANSIRenderer creates a new ANSI-based renderer
 func NewANSIRenderer(opts RendererOptions) *ANSIRenderer {
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	termWidth, height := terminalSize(out)
+
 	width := opts.Width
 	if width == 0 {
-		w, _, _ := GetTerminalSize()
-		width = w
+		width = termWidth
 	}
 
-	_, height, _ := GetTerminalSize()
+	caret := opts.Caret
+	if caret == "" {
+		caret = CaretUnderline
+	}
+
+	theme := opts.Theme
+	if theme == (Theme{}) {
+		theme = DefaultTheme
+	}
+
+	unit := opts.Unit
+	if unit == "" {
+		unit = UnitWPM
+	}
 
 	return &ANSIRenderer{
-		width:   width,
-		height:  height,
-		noColor: opts.NoColor,
+		width:     width,
+		height:    height,
+		termWidth: termWidth,
+		autoWidth: opts.Width == 0,
+		noColor:   opts.NoColor,
+		caret:     caret,
+		theme:     theme,
+		showAcc:   opts.ShowAccuracy,
+		showRaw:   opts.ShowRaw,
+		showSpace: opts.ShowWhitespace,
+		unit:      unit,
+		pace:      opts.PaceMargin / 100,
+		bell:      opts.Bell,
+		out:       out,
+	}
+}
+
+// terminalSize returns the size of the terminal behind out, falling back to
+// stdout's size when out is not a terminal
+func terminalSize(out io.Writer) (width, height int) {
+	if f, ok := out.(*os.File); ok {
+		if w, h, err := term.GetSize(int(f.Fd())); err == nil {
+			return w, h
+		}
 	}
+	width, height, _ = GetTerminalSize()
+	return width, height
 }
 
 // Init initializes the renderer
@@ -45,9 +117,8 @@ func (r *ANSIRenderer) Init() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	HideCursor()
-	ClearScreen()
-	MoveHome()
+	r.lastFrame = nil
+	fmt.Fprint(r.out, escPasteOn+escHideCursor+escClearScreen+escMoveHome)
 	return nil
 }
 
@@ -56,10 +127,8 @@ func (r *ANSIRenderer) Cleanup() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	ShowCursor()
-	Reset()
-	ClearScreen()
-	MoveHome()
+	r.lastFrame = nil
+	fmt.Fprint(r.out, escPasteOff+escShowCursor+escReset+escClearScreen+escMoveHome)
 }
 
 // GetWidth returns the terminal width
@@ -67,24 +136,31 @@ func (r *ANSIRenderer) GetWidth() int {
 	return r.width
 }
 
-// RenderCountdown renders the countdown before test starts
-func (r *ANSIRenderer) RenderCountdown(seconds int) error {
+// RenderCountdown renders one step of the countdown before the test starts
+func (r *ANSIRenderer) RenderCountdown(label string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	ClearScreen()
-	MoveHome()
+	r.lastFrame = nil
+
+	var frame strings.Builder
+	frame.WriteString(escHideCursor)
+	frame.WriteString(escClearScreen)
+	frame.WriteString(escMoveHome)
 
-	// Center the countdown number
+	// Center the label
 	centerRow := r.height / 2
+	col := max((r.width-utf8.RuneCountInString(label))/2, 1)
 
-	MoveCursor(centerRow, r.width/2-1)
+	frame.WriteString(fmt.Sprintf(escMoveCursor, centerRow, col))
 	if !r.noColor {
-		SetYellow()
-		SetBold()
+		frame.WriteString(r.theme.Warning)
+		frame.WriteString(escBold)
 	}
-	fmt.Printf("%d", seconds)
-	Reset()
+	frame.WriteString(label)
+	frame.WriteString(escReset)
+
+	fmt.Fprint(r.out, frame.String())
 
 	return nil
 }
@@ -94,30 +170,145 @@ func (r *ANSIRenderer) Render(state *RenderState) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// A resize invalidates everything on screen
+	resized := r.checkResize()
+
 	// Build the entire frame in memory first
 	var frame strings.Builder
 
-	// Clear screen and move home
-	frame.WriteString(escClearScreen)
-	frame.WriteString(escMoveHome)
-
 	// Header line
-	r.writeHeader(&frame, state)
-	frame.WriteString("\r\n\r\n")
+	if !state.Minimal {
+		r.writeHeader(&frame, state)
+		frame.WriteString("\r\n\r\n")
+	}
 
-	// Target text with coloring
-	r.writeTarget(&frame, state)
+	// Target text with coloring, scrolled to the caret when it doesn't fit
+	var target strings.Builder
+	caretLine := r.writeTarget(&target, state)
+	reserved := 2 // blank line and status line
+	if !state.Minimal {
+		reserved += 2
+	}
+	frame.WriteString(scrollLines(target.String(), caretLine, r.height-reserved))
 	frame.WriteString("\r\n\r\n")
 
 	// Status line
 	r.writeStatus(&frame, state)
 
-	// Output the entire frame at once
-	fmt.Print(frame.String())
+	lines := strings.Split(frame.String(), "\r\n")
+
+	// Output the changes all at once
+	if resized || r.lastFrame == nil || r.overflows(lines) {
+		// The summary shows the cursor, so a test after it hides it again
+		fmt.Fprint(r.out, escHideCursor+escClearScreen+escMoveHome+strings.Join(lines, "\r\n"))
+	} else {
+		fmt.Fprint(r.out, diffFrame(r.lastFrame, lines))
+	}
+	r.lastFrame = lines
 
 	return nil
 }
 
+// checkResize picks up a new terminal size and reports whether it changed;
+// the caller must hold r.mu
+func (r *ANSIRenderer) checkResize() bool {
+	w, h := terminalSize(r.out)
+	if w == r.termWidth && h == r.height {
+		return false
+	}
+	r.termWidth, r.height = w, h
+	if r.autoWidth {
+		r.width = w
+	}
+	return true
+}
+
+// overflows reports whether any line is wider than the terminal. Such lines
+// wrap on screen, so row numbers no longer match line indexes and only a
+// full redraw is safe.
+func (r *ANSIRenderer) overflows(lines []string) bool {
+	for _, line := range lines {
+		if visibleLen(line) > r.termWidth {
+			return true
+		}
+	}
+	return false
+}
+
+// diffFrame returns the output that turns the old frame into the new one by
+// rewriting only the lines that differ
+func diffFrame(old, lines []string) string {
+	var buf strings.Builder
+	for i, line := range lines {
+		if i < len(old) && old[i] == line {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf(escMoveCursor, i+1, 1))
+		buf.WriteString(escClearLine)
+		buf.WriteString(line)
+		buf.WriteString(escReset)
+	}
+
+	// Clear lines left over from a longer previous frame
+	for i := len(lines); i < len(old); i++ {
+		buf.WriteString(fmt.Sprintf(escMoveCursor, i+1, 1))
+		buf.WriteString(escClearLine)
+	}
+	return buf.String()
+}
+
+// visibleLen counts the terminal columns s occupies, skipping ANSI escape
+// sequences
+func visibleLen(s string) int {
+	n := 0
+	inEscape := false
+	for _, ch := range s {
+		switch {
+		case inEscape:
+			// CSI sequences end with a letter
+			if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') {
+				inEscape = false
+			}
+		case ch == '\033':
+			inEscape = true
+		default:
+			n += runeWidth(ch)
+		}
+	}
+	return n
+}
+
+// runeWidth returns the terminal columns ch occupies: two for wide and
+// fullwidth East Asian characters, none for combining marks and format
+// characters like the zero-width joiner, and one otherwise. Ambiguous
+// characters count as narrow, as terminals draw them outside East Asian
+// locales.
+func runeWidth(ch rune) int {
+	if unicode.In(ch, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(ch).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the terminal columns of text without escape
+// sequences
+func displayWidth(s string) int {
+	return runesWidth([]rune(s))
+}
+
+// runesWidth returns the terminal columns of runes
+func runesWidth(runes []rune) int {
+	n := 0
+	for _, ch := range runes {
+		n += runeWidth(ch)
+	}
+	return n
+}
+
 // writeHeader writes the header to the buffer
 func (r *ANSIRenderer) writeHeader(buf *strings.Builder, state *RenderState) {
 	modeStr := string(state.Mode)
@@ -125,21 +316,22 @@ func (r *ANSIRenderer) writeHeader(buf *strings.Builder, state *RenderState) {
 
 	switch state.Mode {
 	case test.ModeTimer:
-		remaining := float64(state.TimeLimit) - state.Elapsed
-		if remaining < 0 {
-			remaining = 0
-		}
-		infoStr = fmt.Sprintf("%ds remaining", int(remaining))
-	case test.ModeWords:
+		infoStr = fmt.Sprintf("%ds remaining", secondsLeft(state))
+	case test.ModeWords, test.ModeText, test.ModePractice, test.ModeCode:
 		wordCount := countWords(string(state.Target))
 		infoStr = fmt.Sprintf("%d words", wordCount)
 	case test.ModeQuote:
 		infoStr = "quote mode"
+	case test.ModeZen:
+		infoStr = fmt.Sprintf("%d words typed", countWords(string(state.Typed)))
+	}
+	if state.Mode != test.ModeTimer && state.TimeLimit > 0 {
+		infoStr += fmt.Sprintf(", %ds left", secondsLeft(state))
 	}
 
 	buf.WriteString("  ")
 	if !r.noColor {
-		buf.WriteString(colorCyan)
+		buf.WriteString(r.theme.Info)
 	}
 	buf.WriteString(strings.ToUpper(modeStr))
 	buf.WriteString(escReset)
@@ -147,9 +339,12 @@ func (r *ANSIRenderer) writeHeader(buf *strings.Builder, state *RenderState) {
 	buf.WriteString(infoStr)
 
 	// Right-align exit hint
-	hint := "Ctrl+C to exit"
-	usedWidth := 2 + len(modeStr) + 3 + len(infoStr)
-	padding := r.width - usedWidth - len(hint) - 2
+	hint := "Esc to pause, Ctrl+C to exit"
+	if state.Hint != "" {
+		hint = state.Hint
+	}
+	usedWidth := 2 + displayWidth(modeStr) + 3 + displayWidth(infoStr)
+	padding := r.width - usedWidth - displayWidth(hint) - 2
 	if padding > 0 {
 		buf.WriteString(strings.Repeat(" ", padding))
 	}
@@ -160,55 +355,230 @@ func (r *ANSIRenderer) writeHeader(buf *strings.Builder, state *RenderState) {
 	buf.WriteString(escReset)
 }
 
-// writeTarget writes the target text with per-character coloring
-func (r *ANSIRenderer) writeTarget(buf *strings.Builder, state *RenderState) {
+// secondsLeft returns the whole seconds left of the state's time limit
+func secondsLeft(state *RenderState) int {
+	return int(max(float64(state.TimeLimit)-state.Elapsed, 0))
+}
+
+// scrollLines returns the window of at most rows of the \r\n-separated
+// lines that keeps the caret's line second from the top, so the line just
+// typed stays in view. Lines that fit are returned whole, as is everything
+// on a terminal too small to pick a window on.
+func scrollLines(lines string, caretLine, rows int) string {
+	split := strings.Split(lines, "\r\n")
+	if rows < 2 || len(split) <= rows {
+		return lines
+	}
+	start := min(max(caretLine-1, 0), len(split)-rows)
+	return strings.Join(split[start:start+rows], "\r\n")
+}
+
+// writeTarget writes the target text with per-character coloring and
+// returns the line the caret is on
+func (r *ANSIRenderer) writeTarget(buf *strings.Builder, state *RenderState) int {
 	// Word wrap the target text
 	maxWidth := r.width - 4
 	if maxWidth < 20 {
 		maxWidth = 20
 	}
 
-	lines := r.wrapText(state.Target, maxWidth)
+	// With reveal-ahead the text past the window is still laid out, just
+	// drawn blank, so lines don't reflow as the window moves
+	hideFrom := revealEnd(state)
 
+	// Embedded newlines (text and code mode) force a line break; each
+	// paragraph is wrapped on its own and the newline itself is drawn as a
+	// typeable glyph
 	charIdx := 0
-	for lineNum, line := range lines {
-		if lineNum > 0 {
-			buf.WriteString("\r\n")
+	lineIdx, caretLine := 0, 0
+	for paraNum, para := range strings.Split(string(state.Target), "\n") {
+		runes := []rune(para)
+		lines := r.wrapText(runes, maxWidth)
+
+		// Whitespace from here to the line break is trailing
+		trailStart := len([]rune(strings.TrimRight(para, " \t")))
+		paraIdx := 0
+
+		for lineNum, line := range lines {
+			if paraNum > 0 || lineNum > 0 {
+				buf.WriteString("\r\n")
+				lineIdx++
+			}
+			buf.WriteString("  ") // Left margin
+
+			for i, ch := range line {
+				if charIdx == state.Caret {
+					caretLine = lineIdx
+				}
+				pos := posInline
+				switch {
+				case paraIdx >= trailStart:
+					pos = posTrailing
+				case i == len(line)-1:
+					pos = posWrap
+				}
+				if charIdx >= hideFrom {
+					writeHidden(buf, ch)
+				} else {
+					r.writeChar(buf, ch, charIdx, pos, state)
+				}
+				charIdx++
+				paraIdx++
+			}
 		}
-		buf.WriteString("  ") // Left margin
 
-		for _, ch := range line {
-			r.writeChar(buf, ch, charIdx, state)
+		if charIdx < len(state.Target) && state.Target[charIdx] == '\n' {
+			if charIdx == state.Caret {
+				caretLine = lineIdx
+			}
+			if charIdx >= hideFrom {
+				writeHidden(buf, '\n')
+			} else {
+				r.writeChar(buf, '\n', charIdx, posInline, state)
+			}
 			charIdx++
 		}
 	}
+
+	// Past the last character (timer mode can run out of text) the caret
+	// sits on an empty cell
+	if charIdx == state.Caret {
+		caretLine = lineIdx
+		if r.showCaret(state) {
+			r.writeCaretAttr(buf)
+			buf.WriteRune(' ')
+		}
+	}
 	buf.WriteString(escReset)
+	return caretLine
 }
 
+// revealEnd returns the index of the first target character hidden by
+// RevealAhead: the end of the RevealAhead-th word from the caret. The caret
+// itself is never hidden. With RevealAhead unset nothing is hidden.
+func revealEnd(state *RenderState) int {
+	if state.RevealAhead <= 0 || state.Finished {
+		return len(state.Target)
+	}
+
+	i := len(state.Typed)
+	for range state.RevealAhead {
+		for i < len(state.Target) && unicode.IsSpace(state.Target[i]) {
+			i++
+		}
+		for i < len(state.Target) && !unicode.IsSpace(state.Target[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+// writeHidden writes the blank cells that stand in for a character past the
+// reveal window
+func writeHidden(buf *strings.Builder, ch rune) {
+	if ch == '\n' || ch == '\t' {
+		ch = ' '
+	}
+	buf.WriteString(strings.Repeat(" ", runeWidth(ch)))
+}
+
+// showCaret reports whether the caret is drawn for this frame. A blinking
+// caret is off every other blink tick; only its line changes, so the diff
+// rewrites that line alone.
+func (r *ANSIRenderer) showCaret(state *RenderState) bool {
+	return r.caret != CaretOff && !state.Finished && state.Blinks%2 == 0
+}
+
+// writeCaretAttr starts the caret attribute
+func (r *ANSIRenderer) writeCaretAttr(buf *strings.Builder) {
+	switch r.caret {
+	case CaretBlock:
+		buf.WriteString(escInverse)
+	case CaretUnderline:
+		buf.WriteString(escUnderline)
+	}
+}
+
+// charPos is where a character sits on its line, for whitespace glyphs
+type charPos int
+
+const (
+	posInline   charPos = iota
+	posWrap             // last character of a wrapped line
+	posTrailing         // whitespace before a line break or the end of the text
+)
+
 // writeChar writes a single character with appropriate coloring
-func (r *ANSIRenderer) writeChar(buf *strings.Builder, ch rune, idx int, state *RenderState) {
+func (r *ANSIRenderer) writeChar(buf *strings.Builder, ch rune, idx int, pos charPos, state *RenderState) {
+	// Whitespace glyphs are all single-cell, so wrapping and the caret
+	// position are unaffected. Tabs are always drawn as arrows, and trailing
+	// whitespace is always shown so it isn't missed; other spaces only with
+	// show-whitespace, where the space a line wraps at gets its own glyph.
+	visible := false
+	switch {
+	case ch == '\n':
+		// Newlines are typed with Enter; show them as a return symbol
+		ch = '↵'
+	case ch == '\t':
+		ch, visible = '→', true
+	case ch == ' ' && (r.showSpace || pos == posTrailing):
+		ch, visible = '·', true
+		if r.showSpace && pos == posWrap {
+			ch = '␣'
+		}
+	}
+
+	// The caret marks where the next keystroke lands; its attribute is reset
+	// right after so it does not leak into the following characters
+	atCaret := idx == state.Caret && r.showCaret(state)
+	if atCaret {
+		defer buf.WriteString(escReset)
+	}
+
 	if r.noColor {
+		if atCaret {
+			r.writeCaretAttr(buf)
+		}
 		buf.WriteRune(ch)
 		return
 	}
 
 	if idx >= len(state.CharStates) {
-		buf.WriteString(colorGray)
+		buf.WriteString(r.theme.Unattempted)
+		if visible {
+			buf.WriteString(escDim)
+		}
+		if atCaret {
+			r.writeCaretAttr(buf)
+		}
 		buf.WriteRune(ch)
 		return
 	}
 
-	switch state.CharStates[idx] {
+	// In blind mode everything typed looks the same; correctness is only
+	// revealed on the summary
+	charState := state.CharStates[idx]
+	if state.Blind && charState == test.CharIncorrect {
+		charState = test.CharCorrect
+	}
+
+	switch charState {
 	case test.CharUnattempted:
-		buf.WriteString(colorGray)
+		buf.WriteString(r.theme.Unattempted)
 	case test.CharCorrect:
-		buf.WriteString(colorWhite)
+		buf.WriteString(r.theme.Correct)
 	case test.CharIncorrect:
-		buf.WriteString(colorOrange)
+		buf.WriteString(r.theme.Incorrect)
+	}
+	if visible && charState != test.CharIncorrect {
+		buf.WriteString(escDim)
+	}
+	if atCaret {
+		r.writeCaretAttr(buf)
 	}
 
 	// Handle space visibility for incorrect
-	if ch == ' ' && state.CharStates[idx] == test.CharIncorrect {
+	if ch == ' ' && charState == test.CharIncorrect {
 		buf.WriteRune('·') // Show incorrect space as middle dot
 	} else {
 		buf.WriteRune(ch)
@@ -219,99 +589,231 @@ func (r *ANSIRenderer) writeChar(buf *strings.Builder, ch rune, idx int, state *
 func (r *ANSIRenderer) writeStatus(buf *strings.Builder, state *RenderState) {
 	buf.WriteString("  ")
 
-	if state.Elapsed > 0.5 {
+	if state.Paused {
 		if !r.noColor {
-			buf.WriteString(colorGreen)
+			buf.WriteString(r.theme.Warning)
 			buf.WriteString(escBold)
 		}
-		buf.WriteString(fmt.Sprintf("%.0f WPM", state.LiveWPM))
+		buf.WriteString("PAUSED — press any key to resume")
 		buf.WriteString(escReset)
-		buf.WriteString("  ")
+		return
 	}
 
-	if !r.noColor {
-		buf.WriteString(escDim)
+	var parts []statusPart
+	if state.Elapsed > 0.5 && state.Blind {
+		// Net WPM and accuracy would give mistakes away; raw speed doesn't
+		parts = append(parts, statusPart{text: fmt.Sprintf("%.0f raw", r.unit.FromWPM(state.LiveRawWPM)), color: r.theme.Success + escBold})
+	} else if state.Elapsed > 0.5 {
+		parts = append(parts, statusPart{text: fmt.Sprintf("%.0f %s", r.unit.FromWPM(state.LiveWPM), r.unit.Label()), color: r.paceColor(state) + escBold})
+		if r.showRaw {
+			parts = append(parts, statusPart{text: fmt.Sprintf("%.0f raw", r.unit.FromWPM(state.LiveRawWPM)), color: r.theme.Info, optional: true})
+		}
+		if r.showAcc {
+			parts = append(parts, statusPart{text: fmt.Sprintf("%.0f%% acc", state.LiveAccuracy), color: r.theme.Info, optional: true})
+		}
+	}
+
+	if state.Minimal {
+		// With no header, what's left replaces the elapsed time
+		if state.TimeLimit > 0 {
+			parts = append(parts, statusPart{text: fmt.Sprintf("%ds", secondsLeft(state)), color: escDim})
+		}
+	} else {
+		parts = append(parts, statusPart{text: fmt.Sprintf("%.1fs", state.Elapsed), color: escDim})
 	}
-	buf.WriteString(fmt.Sprintf("%.1fs", state.Elapsed))
-	buf.WriteString(escReset)
 
-	// Progress for words/quote mode
-	if state.Mode != test.ModeTimer {
+	// Progress for words/quote mode; zen mode has no end to measure against
+	if state.Mode != test.ModeTimer && state.Mode != test.ModeZen {
 		progress := float64(len(state.Typed)) / float64(len(state.Target)) * 100
 		if progress > 100 {
 			progress = 100
 		}
-		buf.WriteString(fmt.Sprintf("  %.0f%%", progress))
+		parts = append(parts, statusPart{text: fmt.Sprintf("%.0f%%", progress)})
+	}
+
+	parts = fitStatus(parts, r.termWidth-2)
+	for i, part := range parts {
+		if i > 0 {
+			buf.WriteString("  ")
+		}
+		if !r.noColor && part.color != "" {
+			buf.WriteString(part.color)
+			buf.WriteString(part.text)
+			buf.WriteString(escReset)
+		} else {
+			buf.WriteString(part.text)
+		}
 	}
 }
 
-// wrapText wraps text to fit within the given width
+// paceColor returns the color of the live WPM: the success color above the
+// band around the recent average, the warning color within it, and the
+// mistake color below. Without a recent average it is always the success
+// color.
+func (r *ANSIRenderer) paceColor(state *RenderState) string {
+	if r.pace <= 0 || state.PaceWPM <= 0 {
+		return r.theme.Success
+	}
+	switch {
+	case state.LiveWPM > state.PaceWPM*(1+r.pace):
+		return r.theme.Success
+	case state.LiveWPM >= state.PaceWPM*(1-r.pace):
+		return r.theme.Warning
+	default:
+		return r.theme.Incorrect
+	}
+}
+
+// statusPart is one field of the status line
+type statusPart struct {
+	text     string
+	color    string
+	optional bool // dropped first when the line is too wide
+}
+
+// fitStatus drops optional parts from the right, then any trailing parts,
+// until the joined line fits in width; a width of 0 or less means unknown
+func fitStatus(parts []statusPart, width int) []statusPart {
+	if width <= 0 {
+		return parts
+	}
+
+	lineLen := func(parts []statusPart) int {
+		n := 0
+		for i, part := range parts {
+			if i > 0 {
+				n += 2
+			}
+			n += displayWidth(part.text)
+		}
+		return n
+	}
+
+	for lineLen(parts) > width {
+		dropped := false
+		for i := len(parts) - 1; i >= 0; i-- {
+			if parts[i].optional {
+				parts = append(parts[:i], parts[i+1:]...)
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			if len(parts) == 1 {
+				break
+			}
+			parts = parts[:len(parts)-1]
+		}
+	}
+	return parts
+}
+
+// wrapText breaks a paragraph into lines at most maxWidth columns wide,
+// counting wide characters as two columns. Lines break after spaces, which
+// stay at the end of their line (one may overhang maxWidth) so every target
+// character is drawn and the caret can sit on it. Words wider than a whole
+// line, such as CJK text without spaces, are split across lines. Every rune
+// of the input appears in the output exactly once and in order, so the
+// caller's character index stays aligned with CharStates.
 func (r *ANSIRenderer) wrapText(runes []rune, maxWidth int) [][]rune {
 	if maxWidth <= 0 {
 		maxWidth = 80
 	}
-
-	text := string(runes)
-	words := strings.Fields(text)
-	if len(words) == 0 {
+	if len(runes) == 0 {
 		return [][]rune{runes}
 	}
 
 	var lines [][]rune
-	var currentLine []rune
-
-	for i, word := range words {
-		wordRunes := []rune(word)
+	var line []rune
+	lineWidth := 0
+	add := func(part []rune) {
+		line = append(line, part...)
+		lineWidth += runesWidth(part)
+	}
+	flush := func() {
+		lines = append(lines, line)
+		line = nil
+		lineWidth = 0
+	}
 
-		if len(currentLine) > 0 {
-			// Check if word fits on current line (with space)
-			if len(currentLine)+1+len(wordRunes) <= maxWidth {
-				currentLine = append(currentLine, ' ')
-				currentLine = append(currentLine, wordRunes...)
-			} else {
-				// Start new line
-				lines = append(lines, currentLine)
-				currentLine = wordRunes
-			}
-		} else {
-			// First word on line
-			if len(wordRunes) <= maxWidth {
-				currentLine = wordRunes
-			} else {
-				// Word is too long, force break
-				currentLine = wordRunes[:maxWidth]
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' {
+			if lineWidth > maxWidth {
+				flush()
 			}
+			add(runes[i : i+1])
+			i++
+			continue
 		}
 
-		// Handle last word
-		if i == len(words)-1 && len(currentLine) > 0 {
-			lines = append(lines, currentLine)
+		// Find the end of the word
+		end := i
+		for end < len(runes) && runes[end] != ' ' {
+			end++
+		}
+		word := runes[i:end]
+		i = end
+
+		switch wordWidth := runesWidth(word); {
+		case lineWidth+wordWidth <= maxWidth:
+			add(word)
+		case wordWidth <= maxWidth:
+			flush()
+			add(word)
+		default:
+			// Too wide for any line: start it on a fresh line and hard-break
+			if len(line) > 0 {
+				flush()
+			}
+			for runesWidth(word) > maxWidth {
+				n := fitWidth(word, maxWidth)
+				add(word[:n])
+				flush()
+				word = word[n:]
+			}
+			add(word)
 		}
 	}
-
-	// Handle case where last line wasn't added
-	if len(lines) == 0 && len(currentLine) > 0 {
-		lines = append(lines, currentLine)
+	if len(line) > 0 {
+		flush()
 	}
 
 	return lines
 }
 
+// fitWidth returns how many leading runes of word fit in maxWidth columns,
+// at least one so a character wider than the line still makes progress.
+// Zero-width runes stay with the character before them.
+func fitWidth(word []rune, maxWidth int) int {
+	n, w := 0, 0
+	for n < len(word) && w+runeWidth(word[n]) <= maxWidth {
+		w += runeWidth(word[n])
+		n++
+	}
+	return max(n, 1)
+}
+
 // RenderSummary renders the final results summary
 func (r *ANSIRenderer) RenderSummary(result *test.SessionResult, chart string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.lastFrame = nil
+
 	// Build summary with \r\n for raw mode compatibility
 	var buf strings.Builder
 
+	// Ring for whoever looked away during a timer test
+	if r.bell == BellEnd || (r.bell == BellBest && result.Record != test.RecordNone) {
+		buf.WriteString(bell)
+	}
 	buf.WriteString(escClearScreen)
 	buf.WriteString(escMoveHome)
 	buf.WriteString(escShowCursor)
 
 	// Title
 	if !r.noColor {
-		buf.WriteString(colorGreen)
+		buf.WriteString(r.theme.Success)
 		buf.WriteString(escBold)
 	}
 	buf.WriteString("\r\n")
@@ -324,34 +826,81 @@ func (r *ANSIRenderer) RenderSummary(result *test.SessionResult, chart string) e
 	// Main stats
 	buf.WriteString("  ")
 	if !r.noColor {
-		buf.WriteString(colorGreen)
+		buf.WriteString(r.theme.Success)
 		buf.WriteString(escBold)
 	}
-	buf.WriteString(fmt.Sprintf("WPM: %.1f", result.WPM))
+	speed, raw := result.WPM, result.RawWPM
+	if r.unit == UnitCPM {
+		speed, raw = result.CPM, result.RawCPM
+	}
+	buf.WriteString(fmt.Sprintf("%s: %.1f", r.unit.Label(), speed))
 	buf.WriteString(escReset)
 
 	buf.WriteString("  |  ")
 	if !r.noColor {
-		buf.WriteString(colorCyan)
+		buf.WriteString(r.theme.Info)
 	}
-	buf.WriteString(fmt.Sprintf("Raw: %.1f", result.RawWPM))
+	buf.WriteString(fmt.Sprintf("Raw: %.1f", raw))
 	buf.WriteString(escReset)
 
 	buf.WriteString("  |  ")
 	if !r.noColor {
-		buf.WriteString(colorYellow)
+		buf.WriteString(r.theme.Warning)
 	}
-	buf.WriteString(fmt.Sprintf("Accuracy: %.1f%%", result.Accuracy))
+	buf.WriteString(fmt.Sprintf("Accuracy: %.1f%% (clean %.1f%%)", result.Accuracy, result.FirstTryAccuracy))
 	buf.WriteString(escReset)
-	buf.WriteString("\r\n\r\n")
+	buf.WriteString("\r\n")
+
+	// Personal best banner
+	if note := recordNote(result); note != "" {
+		buf.WriteString("\r\n  ")
+		if !r.noColor {
+			buf.WriteString(r.theme.Warning)
+			buf.WriteString(escBold)
+		}
+		buf.WriteString(note)
+		buf.WriteString(escReset)
+		buf.WriteString("\r\n")
+	}
+
+	if note := capNote(result); note != "" {
+		buf.WriteString("\r\n  " + note + "\r\n")
+	}
+	if note := blindNote(result); note != "" {
+		buf.WriteString("\r\n  " + note + "\r\n")
+	}
 
-	// Details
-	buf.WriteString(fmt.Sprintf("  Time:       %.1fs\r\n", result.Duration.Seconds()))
-	buf.WriteString(fmt.Sprintf("  Characters: %d/%d correct\r\n", result.CorrectChars, result.TotalTyped))
-	buf.WriteString(fmt.Sprintf("  Mode:       %s\r\n", result.Mode))
+	// Goal verdict
+	if note, met := goalNote(result); note != "" {
+		buf.WriteString("\r\n  ")
+		if !r.noColor {
+			if met {
+				buf.WriteString(r.theme.Success)
+			} else {
+				buf.WriteString(r.theme.Incorrect)
+			}
+			buf.WriteString(escBold)
+		}
+		buf.WriteString(note)
+		buf.WriteString(escReset)
+		buf.WriteString("\r\n")
+	}
+	if note := dailyNote(result); note != "" {
+		buf.WriteString("\r\n  " + note + "\r\n")
+	}
+	buf.WriteString("\r\n")
 
-	if result.Mode == test.ModeQuote && result.Metadata.Source != "" {
-		buf.WriteString(fmt.Sprintf("  Source:     %s\r\n", result.Metadata.Source))
+	// Details, with labels padded to a common column where they fit
+	for _, field := range summaryDetails(result, r.unit) {
+		buf.WriteString(fmt.Sprintf("  %-11s ", field.label+":"))
+		if field.miss && !r.noColor {
+			buf.WriteString(r.theme.Incorrect)
+			buf.WriteString(field.value)
+			buf.WriteString(escReset)
+		} else {
+			buf.WriteString(field.value)
+		}
+		buf.WriteString("\r\n")
 	}
 
 	buf.WriteString("\r\n")
@@ -374,16 +923,82 @@ func (r *ANSIRenderer) RenderSummary(result *test.SessionResult, chart string) e
 	buf.WriteString("  Press Enter to continue...")
 	buf.WriteString(escReset)
 
-	// Output all at once
-	fmt.Print(buf.String())
+	// Output all at once. The caller waits for the key, since it may
+	// already be reading input.
+	fmt.Fprint(r.out, buf.String())
 
-	// Wait for Enter
-	inputBuf := make([]byte, 1)
-	os.Stdin.Read(inputBuf)
+	return nil
+}
+
+// RenderPrompt replaces the "Press Enter" line at the bottom of the
+// summary with a question
+func (r *ANSIRenderer) RenderPrompt(question string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf strings.Builder
+	buf.WriteString("\r")
+	buf.WriteString(escClearLine)
+	buf.WriteString("  ")
+	if !r.noColor {
+		buf.WriteString(r.theme.Warning)
+	}
+	buf.WriteString(question)
+	buf.WriteString(escReset)
+	buf.WriteString(" ")
+
+	fmt.Fprint(r.out, buf.String())
 
 	return nil
 }
 
+// maxMissedWords is how many missed words the summary lists
+const maxMissedWords = 10
+
+// listWords joins up to n words, noting how many more were left out
+func listWords(words []string, n int) string {
+	if len(words) <= n {
+		return strings.Join(words, " ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(words[:n], " "), len(words)-n)
+}
+
+// problemKeys lists up to n characters with the most errors, e.g. "e (3), r (2)"
+func problemKeys(stats map[rune]test.KeyStat, n int) string {
+	var keys []rune
+	for key, stat := range stats {
+		if stat.Errors > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		ei, ej := stats[keys[i]].Errors, stats[keys[j]].Errors
+		if ei != ej {
+			return ei > ej
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		label := string(key)
+		switch key {
+		case ' ':
+			label = "space"
+		case '\n':
+			label = "enter"
+		case '\t':
+			label = "tab"
+		}
+		parts[i] = fmt.Sprintf("%s (%d)", label, stats[key].Errors)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // countWords counts words in a string
 func countWords(s string) int {
 	return len(strings.Fields(s))
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+// joinLines concatenates wrapped lines back into one slice of runes
+func joinLines(lines [][]rune) []rune {
+	var all []rune
+	for _, line := range lines {
+		all = append(all, line...)
+	}
+	return all
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "the cat", 20, []string{"the cat"}},
+		{"breaks after space", "the cat sat", 8, []string{"the cat ", "sat"}},
+		{"space overhangs", "the cat", 3, []string{"the ", "cat"}},
+		{"long word split", "a abcdefghij b", 4, []string{"a ", "abcd", "efgh", "ij b"}},
+		{"width one", "ab cd", 1, []string{"a", "b ", "c", "d"}},
+		{"empty", "", 10, []string{""}},
+	}
+
+	r := NewANSIRenderer(RendererOptions{Width: 80, NoColor: true, Output: &strings.Builder{}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runes := []rune(tt.text)
+			lines := r.wrapText(runes, tt.width)
+
+			if got := string(joinLines(lines)); got != tt.text {
+				t.Errorf("lines join to %q, want %q", got, tt.text)
+			}
+
+			got := make([]string, len(lines))
+			for i, line := range lines {
+				got[i] = string(line)
+				// Only the trailing space may overhang
+				if w := displayWidth(strings.TrimRight(got[i], " ")); w > tt.width {
+					t.Errorf("line %d %q is %d columns, more than %d", i, got[i], w, tt.width)
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		ch   rune
+		want int
+	}{
+		{'a', 1},
+		{'é', 1},
+		{'日', 2},
+		{'の', 2},
+		{'Ａ', 2},      // fullwidth A
+		{'\u0301', 0}, // combining acute accent
+		{'\u200d', 0}, // zero-width joiner
+	}
+	for _, tt := range tests {
+		if got := runeWidth(tt.ch); got != tt.want {
+			t.Errorf("runeWidth(%q) = %d, want %d", tt.ch, got, tt.want)
+		}
+	}
+
+	if got := displayWidth("ab日本語"); got != 8 {
+		t.Errorf("displayWidth mixed text = %d, want 8", got)
+	}
+}
+
+func TestWrapTextMixedWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"CJK word fills the line", "日本語 abc", 6, []string{"日本語 ", "abc"}},
+		{"CJK word one column over", "日本語 abc", 5, []string{"日本", "語 ", "abc"}},
+		{"CJK without spaces", "日本語のテキスト", 5, []string{"日本", "語の", "テキ", "スト"}},
+		{"ASCII then CJK", "ab日本", 3, []string{"ab", "日", "本"}},
+		{"ASCII words around CJK", "go 日本 go", 4, []string{"go ", "日本 ", "go"}},
+		{"wide character wider than the line", "日本", 1, []string{"日", "本"}},
+		{"combining marks stay with their letter", "e\u0301e\u0301e\u0301", 2, []string{"e\u0301e\u0301", "e\u0301"}},
+	}
+
+	r := NewANSIRenderer(RendererOptions{Width: 80, NoColor: true, Output: &strings.Builder{}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := r.wrapText([]rune(tt.text), tt.width)
+
+			if got := string(joinLines(lines)); got != tt.text {
+				t.Errorf("lines join to %q, want %q", got, tt.text)
+			}
+			got := make([]string, len(lines))
+			for i, line := range lines {
+				got[i] = string(line)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+// stripEscapes removes ANSI escape sequences, leaving the drawn text
+func stripEscapes(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' {
+			out.WriteByte(s[i])
+			continue
+		}
+		// Skip to the final byte of the sequence
+		for i++; i < len(s) && (s[i] < '@' || s[i] > '~' || s[i] == '['); i++ {
+		}
+	}
+	return out.String()
+}
+
+func TestHeaderPaddingMixedWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		hint string
+	}{
+		{"ASCII hint", "Esc to pause"},
+		{"CJK hint", "Escで一時停止"},
+		{"fullwidth hint", "ＥＳＣ"},
+	}
+
+	const width = 60
+	r := NewANSIRenderer(RendererOptions{Width: width, NoColor: true, Output: &strings.Builder{}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			r.writeHeader(&buf, &RenderState{Mode: "quote", Hint: tt.hint})
+
+			line := stripEscapes(buf.String())
+			if !strings.HasSuffix(line, tt.hint) {
+				t.Fatalf("header %q does not end with the hint", line)
+			}
+			// Two columns are left free after the hint
+			if got := displayWidth(line); got != width-2 {
+				t.Errorf("header %q is %d columns, want %d", line, got, width-2)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/test"
+)
+
+// SummaryFormat selects how WriteSummary lays out a result
+type SummaryFormat string
+
+const (
+	SummaryText     SummaryFormat = "text"     // plain lines, as on the summary screen
+	SummaryMarkdown SummaryFormat = "markdown" // a stats table with the chart in a code block
+)
+
+// ParseSummaryFormat validates a summary format name
+func ParseSummaryFormat(s string) (SummaryFormat, error) {
+	switch SummaryFormat(s) {
+	case SummaryText, SummaryMarkdown:
+		return SummaryFormat(s), nil
+	default:
+		return "", fmt.Errorf("unknown summary format: %s (use text or markdown)", s)
+	}
+}
+
+// summaryField is one line of the summary details
+type summaryField struct {
+	label string
+	value string
+	miss  bool // drawn in the mistake color
+}
+
+// summaryDetails returns the detail lines of the summary, shared by the
+// summary screen and WriteSummary. unit leads the summary, so the details
+// list the other one.
+func summaryDetails(result *test.SessionResult, unit SpeedUnit) []summaryField {
+	fields := []summaryField{{label: "Time", value: fmt.Sprintf("%.1fs", result.Duration.Seconds())}}
+	if result.IdleTime > 0 {
+		fields = append(fields, summaryField{label: "AFK", value: fmt.Sprintf("%.1fs not counted", result.IdleTime.Seconds())})
+	}
+	if result.TimeToFirstKeyMs > 0 {
+		fields = append(fields, summaryField{label: "Reaction", value: fmt.Sprintf("%.2fs to first key", float64(result.TimeToFirstKeyMs)/1000)})
+	}
+	fields = append(fields, summaryField{label: "Characters", value: fmt.Sprintf("%d/%d correct", result.CorrectChars, result.TotalTyped)})
+	// The unit not leading the summary, for reference
+	if unit == UnitCPM {
+		fields = append(fields, summaryField{label: "WPM", value: fmt.Sprintf("%.1f (raw %.1f)", result.WPM, result.RawWPM)})
+	} else {
+		fields = append(fields, summaryField{label: "CPM", value: fmt.Sprintf("%.0f (raw %.0f)", result.CPM, result.RawCPM)})
+	}
+	if result.AvgKeyLatencyMs > 0 {
+		fields = append(fields, summaryField{label: "Avg key latency", value: fmt.Sprintf("%.0fms (p95 %.0fms)", result.AvgKeyLatencyMs, result.P95KeyLatencyMs)})
+	}
+	fields = append(fields,
+		summaryField{label: "Corrections", value: fmt.Sprintf("%d", result.Corrections)},
+		summaryField{label: "Consistency", value: fmt.Sprintf("%.0f%%", result.Consistency)},
+	)
+	if result.PeakWPM > 0 {
+		fields = append(fields, summaryField{label: "Peak/Median", value: fmt.Sprintf("%.1f / %.1f %s", unit.FromWPM(result.PeakWPM), unit.FromWPM(result.MedianWPM), unit.Label())})
+	}
+	if problems := problemKeys(result.KeyStats, 3); problems != "" {
+		fields = append(fields, summaryField{label: "Missed keys", value: problems})
+	}
+	if len(result.MissedWords) > 0 {
+		fields = append(fields, summaryField{label: "Missed words", value: listWords(result.MissedWords, maxMissedWords), miss: true})
+	}
+	fields = append(fields, summaryField{label: "Mode", value: string(result.Mode)})
+	if result.Metadata.Source != "" {
+		fields = append(fields, summaryField{label: "Source", value: result.Metadata.Source})
+	}
+	// Passed back as --seed, the seed generates this text again
+	if result.Metadata.Seed != 0 {
+		fields = append(fields, summaryField{label: "Seed", value: fmt.Sprintf("%d", result.Metadata.Seed)})
+	}
+	if result.Correction != test.CorrectionNormal {
+		fields = append(fields, summaryField{label: "Correction", value: string(result.Correction)})
+	}
+	return fields
+}
+
+// recordNote announces the personal best set by result, if any
+func recordNote(result *test.SessionResult) string {
+	switch result.Record {
+	case test.RecordOverall:
+		return "🏆 New personal best!"
+	case test.RecordMode:
+		return fmt.Sprintf("🏆 New personal best for %s mode!", result.Mode)
+	}
+	return ""
+}
+
+// capNote says which cap ended the test, when it wasn't the mode's usual
+// finish
+func capNote(result *test.SessionResult) string {
+	switch {
+	case result.EndReason == test.EndWords:
+		return fmt.Sprintf("Finished early: %d-word cap reached", result.MaxWords)
+	case result.EndReason == test.EndTime && result.Mode != test.ModeTimer:
+		return fmt.Sprintf("Time's up: %d-second cap reached", result.MaxSeconds)
+	}
+	return ""
+}
+
+// goalNote gives the verdict on the WPM goal and whether it was met; it is
+// empty when no goal was set
+func goalNote(result *test.SessionResult) (string, bool) {
+	switch {
+	case result.TargetWPM <= 0:
+		return "", false
+	case result.WPM >= result.TargetWPM:
+		return fmt.Sprintf("GOAL MET (%.0f WPM)", result.TargetWPM), true
+	default:
+		return fmt.Sprintf("GOAL MISSED (need +%.1f)", result.TargetWPM-result.WPM), false
+	}
+}
+
+// blindNote reveals what blind mode kept hidden
+func blindNote(result *test.SessionResult) string {
+	if !result.Blind {
+		return ""
+	}
+	return fmt.Sprintf("Blind run revealed: %.1f%% accuracy (mistakes: %d)", result.Accuracy, result.IncorrectChars)
+}
+
+// dailyNote reports progress towards the daily goal
+func dailyNote(result *test.SessionResult) string {
+	switch {
+	case result.GoalTests <= 0:
+		return ""
+	case result.GoalDone >= result.GoalTests:
+		return fmt.Sprintf("Daily goal met: %d/%d tests today", result.GoalDone, result.GoalTests)
+	default:
+		return fmt.Sprintf("Daily goal: %d/%d tests done today", result.GoalDone, result.GoalTests)
+	}
+}
+
+// WriteSummary writes the summary of result without escape codes, for
+// pasting elsewhere. chart is drawn without colors, or empty for none.
+func WriteSummary(w io.Writer, result *test.SessionResult, chart string, format SummaryFormat, unit SpeedUnit) error {
+	speed, raw := result.WPM, result.RawWPM
+	if unit == UnitCPM {
+		speed, raw = result.CPM, result.RawCPM
+	}
+	goal, _ := goalNote(result)
+	var notes []string
+	for _, note := range []string{recordNote(result), capNote(result), blindNote(result), goal, dailyNote(result)} {
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
+	date := result.StartedAt.Local().Format("2006-01-02 15:04")
+
+	var buf strings.Builder
+	if format == SummaryMarkdown {
+		fmt.Fprintf(&buf, "### mtcli %s test, %s\n\n", result.Mode, date)
+		fmt.Fprintf(&buf, "**%.1f %s** · raw %.1f · %.1f%% accuracy (clean %.1f%%)\n\n", speed, unit.Label(), raw, result.Accuracy, result.FirstTryAccuracy)
+		for _, note := range notes {
+			fmt.Fprintf(&buf, "- %s\n", note)
+		}
+		if len(notes) > 0 {
+			buf.WriteString("\n")
+		}
+
+		buf.WriteString("| Stat | Value |\n")
+		buf.WriteString("| --- | --- |\n")
+		for _, field := range summaryDetails(result, unit) {
+			// Pipes would end the cell early
+			fmt.Fprintf(&buf, "| %s | %s |\n", field.label, strings.ReplaceAll(field.value, "|", `\|`))
+		}
+
+		if chart != "" {
+			buf.WriteString("\n```\n")
+			buf.WriteString(strings.TrimRight(chart, "\n"))
+			buf.WriteString("\n```\n")
+		}
+	} else {
+		fmt.Fprintf(&buf, "mtcli %s test, %s\n\n", result.Mode, date)
+		fmt.Fprintf(&buf, "%s: %.1f  |  Raw: %.1f  |  Accuracy: %.1f%% (clean %.1f%%)\n\n", unit.Label(), speed, raw, result.Accuracy, result.FirstTryAccuracy)
+		for _, note := range notes {
+			fmt.Fprintf(&buf, "%s\n", note)
+		}
+		if len(notes) > 0 {
+			buf.WriteString("\n")
+		}
+
+		for _, field := range summaryDetails(result, unit) {
+			fmt.Fprintf(&buf, "%-11s %s\n", field.label+":", field.value)
+		}
+
+		if chart != "" {
+			buf.WriteString("\nSpeed over time:\n\n")
+			buf.WriteString(strings.TrimRight(chart, "\n"))
+			buf.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Theme holds the semantic colors used by the renderer, as ANSI escape
+// sequences
+type Theme struct {
+	Unattempted string // text not yet typed
+	Correct     string // correctly typed text
+	Incorrect   string // mistakes
+	Success     string // WPM and positive results
+	Info        string // secondary stats
+	Warning     string // highlights and pauses
+}
+
+// ColorMode selects how colors are emitted
+type ColorMode string
+
+const (
+	ColorModeAuto      ColorMode = "auto"      // truecolor if COLORTERM advertises it, else 256
+	ColorMode256       ColorMode = "256"       // 38;5;N
+	ColorModeTruecolor ColorMode = "truecolor" // 38;2;R;G;B
+	ColorModeNone      ColorMode = "none"      // no colors at all
+)
+
+// ParseColorMode validates a color mode name; auto is resolved from the
+// environment, so the result is never ColorModeAuto
+func ParseColorMode(s string) (ColorMode, error) {
+	switch ColorMode(s) {
+	case ColorModeAuto, "":
+		return DetectColorMode(), nil
+	case ColorMode256, ColorModeTruecolor, ColorModeNone:
+		return ColorMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown color mode: %s (use auto, 256, truecolor, or none)", s)
+	}
+}
+
+// DetectColorMode picks truecolor when COLORTERM is "truecolor" or "24bit"
+// and falls back to 256 colors otherwise
+func DetectColorMode() ColorMode {
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return ColorModeTruecolor
+	default:
+		return ColorMode256
+	}
+}
+
+// themeColor is one palette entry in both 256-color and 24-bit form
+type themeColor struct {
+	index int    // 256-color palette index
+	rgb   uint32 // 0xRRGGBB
+}
+
+// sequence returns the foreground escape sequence for the given mode
+func (c themeColor) sequence(mode ColorMode) string {
+	switch mode {
+	case ColorModeTruecolor:
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", c.rgb>>16&0xff, c.rgb>>8&0xff, c.rgb&0xff)
+	case ColorModeNone:
+		return ""
+	default:
+		return fmt.Sprintf("\033[38;5;%dm", c.index)
+	}
+}
+
+// palette is a built-in theme before a color mode is chosen
+type palette struct {
+	unattempted, correct, incorrect, success, info, warning themeColor
+}
+
+func (p palette) theme(mode ColorMode) Theme {
+	return Theme{
+		Unattempted: p.unattempted.sequence(mode),
+		Correct:     p.correct.sequence(mode),
+		Incorrect:   p.incorrect.sequence(mode),
+		Success:     p.success.sequence(mode),
+		Info:        p.info.sequence(mode),
+		Warning:     p.warning.sequence(mode),
+	}
+}
+
+// palettes holds the built-in themes by name
+var palettes = map[string]palette{
+	"default": {
+		unattempted: themeColor{245, 0x8a8a8a},
+		correct:     themeColor{255, 0xeeeeee},
+		incorrect:   themeColor{208, 0xff8700},
+		success:     themeColor{114, 0x87d787},
+		info:        themeColor{80, 0x5fd7d7},
+		warning:     themeColor{220, 0xffd700},
+	},
+	"solarized": {
+		unattempted: themeColor{241, 0x586e75},
+		correct:     themeColor{230, 0xeee8d5},
+		incorrect:   themeColor{160, 0xdc322f},
+		success:     themeColor{106, 0x859900},
+		info:        themeColor{37, 0x2aa198},
+		warning:     themeColor{136, 0xb58900},
+	},
+	"dracula": {
+		unattempted: themeColor{61, 0x6272a4},
+		correct:     themeColor{255, 0xf8f8f2},
+		incorrect:   themeColor{203, 0xff5555},
+		success:     themeColor{84, 0x50fa7b},
+		info:        themeColor{117, 0x8be9fd},
+		warning:     themeColor{228, 0xf1fa8c},
+	},
+	"high-contrast": {
+		unattempted: themeColor{248, 0xa8a8a8},
+		correct:     themeColor{231, 0xffffff},
+		incorrect:   themeColor{196, 0xff0000},
+		success:     themeColor{46, 0x00ff00},
+		info:        themeColor{51, 0x00ffff},
+		warning:     themeColor{226, 0xffff00},
+	},
+}
+
+// DefaultTheme is the original mtcli palette in 256-color form
+var DefaultTheme = palettes["default"].theme(ColorMode256)
+
+// ThemeNames returns the names of the built-in themes in sorted order
+func ThemeNames() []string {
+	names := make([]string, 0, len(palettes))
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseTheme looks up a built-in theme by name and renders its colors for
+// the given mode
+func ParseTheme(name string, mode ColorMode) (Theme, error) {
+	p, ok := palettes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme: %s (use %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return p.theme(mode), nil
+}
@@ -1,18 +1,135 @@
 package ui
 
-import "github.com/mmdbasi/mtcli/internal/test"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mmdbasi/mtcli/internal/test"
+)
+
+// CaretStyle selects how the next character to type is highlighted
+type CaretStyle string
+
+const (
+	CaretBlock     CaretStyle = "block"     // inverse video
+	CaretUnderline CaretStyle = "underline" // underlined
+	CaretOff       CaretStyle = "off"       // no caret
+)
+
+// ParseCaretStyle validates a caret style name
+func ParseCaretStyle(s string) (CaretStyle, error) {
+	switch CaretStyle(s) {
+	case CaretBlock, CaretUnderline, CaretOff:
+		return CaretStyle(s), nil
+	default:
+		return "", fmt.Errorf("unknown caret style: %s (use block, underline, or off)", s)
+	}
+}
+
+// BellMode selects when the summary rings the terminal bell
+type BellMode string
+
+const (
+	BellOff  BellMode = "off"  // never
+	BellEnd  BellMode = "end"  // whenever a test completes
+	BellBest BellMode = "best" // only on a new personal best
+)
+
+// ParseBellMode validates a bell mode name
+func ParseBellMode(s string) (BellMode, error) {
+	switch BellMode(s) {
+	case BellOff, BellEnd, BellBest:
+		return BellMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown bell mode: %s (use off, end, or best)", s)
+	}
+}
+
+// CountdownStyle selects what the countdown before a test shows
+type CountdownStyle string
+
+const (
+	CountdownNumber CountdownStyle = "number" // the seconds left
+	CountdownWords  CountdownStyle = "words"  // labels such as Ready, Set, Go
+)
+
+// ParseCountdownStyle validates a countdown style name
+func ParseCountdownStyle(s string) (CountdownStyle, error) {
+	switch CountdownStyle(s) {
+	case CountdownNumber, CountdownWords:
+		return CountdownStyle(s), nil
+	default:
+		return "", fmt.Errorf("unknown countdown style: %s (use number or words)", s)
+	}
+}
+
+// SplitCountdownWords splits a comma-separated list of countdown labels,
+// dropping empty ones
+func SplitCountdownWords(s string) []string {
+	var words []string
+	for _, w := range strings.Split(s, ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// SpeedUnit selects which speed the status line and summary lead with
+type SpeedUnit string
+
+const (
+	UnitWPM SpeedUnit = "wpm" // words per minute, five characters to a word
+	UnitCPM SpeedUnit = "cpm" // characters per minute
+)
+
+// ParseSpeedUnit validates a speed unit name
+func ParseSpeedUnit(s string) (SpeedUnit, error) {
+	switch SpeedUnit(s) {
+	case UnitWPM, UnitCPM:
+		return SpeedUnit(s), nil
+	default:
+		return "", fmt.Errorf("unknown unit: %s (use wpm or cpm)", s)
+	}
+}
+
+// Label returns the unit as shown next to a speed, e.g. "WPM"
+func (u SpeedUnit) Label() string {
+	if u == UnitCPM {
+		return "CPM"
+	}
+	return "WPM"
+}
+
+// FromWPM converts a WPM value into this unit
+func (u SpeedUnit) FromWPM(wpm float64) float64 {
+	if u == UnitCPM {
+		return wpm * 5
+	}
+	return wpm
+}
 
 // RenderState holds the state needed for rendering
 type RenderState struct {
-	Target      []rune
-	Typed       []rune
-	CharStates  []test.CharState
-	Mode        test.Mode
-	Elapsed     float64 // seconds
-	LiveWPM     float64
-	TimeLimit   int // for timer mode
-	Countdown   int // countdown seconds remaining (-1 if started)
-	Finished    bool
+	Target       []rune
+	Typed        []rune
+	CharStates   []test.CharState
+	Caret        int // index of the character the next key lands on
+	Mode         test.Mode
+	Elapsed      float64 // seconds
+	LiveWPM      float64
+	LiveRawWPM   float64
+	LiveAccuracy float64 // percentage
+	TimeLimit    int     // timer mode duration, or the time cap of other modes
+	Countdown    int     // countdown seconds remaining (-1 if started)
+	Finished     bool
+	Paused       bool
+	Hint         string  // header hint; empty for the live test's key help
+	Blind        bool    // draw mistakes like correct characters and hide live accuracy
+	Minimal      bool    // no header; the status line shows time left instead of elapsed
+	PaceWPM      float64 // recent average WPM the live WPM is colored against; 0 for none
+	RevealAhead  int     // words shown from the caret on, counting the one being typed; 0 shows the whole text
+	Blinks       int     // caret blink ticks so far; the caret is hidden after odd counts
 }
 
 // Renderer defines the interface for UI rendering
@@ -23,16 +140,20 @@ type Renderer interface {
 	// Render renders the current state
 	Render(state *RenderState) error
 
-	// RenderCountdown renders the countdown before test starts
-	RenderCountdown(seconds int) error
+	// RenderCountdown renders one step of the countdown before the test
+	// starts, such as "3" or "Ready..."
+	RenderCountdown(label string) error
 
-	// RenderSummary renders the final summary
+	// RenderSummary renders the final summary. It doesn't wait for the
+	// key that dismisses it.
 	RenderSummary(result *test.SessionResult, chart string) error
 
+	// RenderPrompt replaces the summary's last line with a question
+	RenderPrompt(question string) error
+
 	// Cleanup restores terminal state
 	Cleanup()
 
 	// GetWidth returns the terminal width
 	GetWidth() int
 }
-